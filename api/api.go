@@ -7,27 +7,58 @@ import (
 	"strconv"
 
 	"github.com/aragon/zkmultisig-node/censusbuilder"
+	"github.com/aragon/zkmultisig-node/eth"
 	"github.com/aragon/zkmultisig-node/types"
 	"github.com/aragon/zkmultisig-node/votesaggregator"
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/gin-gonic/gin"
 	"go.vocdoni.io/dvote/log"
 )
 
+// EthSyncer is implemented by the eth.Client, and allows the API to report
+// the status of the synchronization with the Ethereum blockchain
+type EthSyncer interface {
+	SyncStatus() eth.SyncStatus
+}
+
+// Network is a zkMultisig contract of a chain whose processes are served by
+// the API, under the /chain/:chainid routes
+type Network struct {
+	ChainID         uint64
+	ContractAddr    common.Address
+	VotesAggregator *votesaggregator.VotesAggregator
+	// EthSyncer is optional, and enables the sync endpoint of the network
+	EthSyncer EthSyncer
+}
+
 // API allows external requests to the Node
 type API struct {
-	r  *gin.Engine
-	cb *censusbuilder.CensusBuilder
-	va *votesaggregator.VotesAggregator
+	r        *gin.Engine
+	cb       *censusbuilder.CensusBuilder
+	va       *votesaggregator.VotesAggregator
+	eth      EthSyncer
+	networks []Network
 }
 
-// New returns a new API with the endpoints, without starting to listen
+// New returns a new API with the endpoints, without starting to listen. The
+// given votesAggregator serves the /process routes, and each one of the given
+// networks the /chain/:chainid/process routes of its chainID, or the
+// /chain/:chainid/contract/:contract/process routes when several contracts of
+// the same chain are served. Each network must have a VotesAggregator.
 func New(censusBuilder *censusbuilder.CensusBuilder,
-	votesAggregator *votesaggregator.VotesAggregator) (*API, error) {
-	if censusBuilder == nil && votesAggregator == nil {
+	votesAggregator *votesaggregator.VotesAggregator, networks ...Network) (*API, error) {
+	if censusBuilder == nil && votesAggregator == nil && len(networks) == 0 {
 		return nil, fmt.Errorf("Can not create the API. At least" +
 			" censusBuilder or votesAggregator should be active to start" +
 			" the API. Use --help to see the list of available flags.")
 	}
+	for _, n := range networks {
+		if n.VotesAggregator == nil {
+			return nil, fmt.Errorf("Can not create the API, the network of"+
+				" ChainID=%d and contract %s has no votesAggregator",
+				n.ChainID, n.ContractAddr.Hex())
+		}
+	}
 
 	a := API{}
 	r := gin.Default()
@@ -46,6 +77,19 @@ func New(censusBuilder *censusbuilder.CensusBuilder,
 		a.va = votesAggregator
 		r.POST("/process/:processid", a.postVote)
 		r.GET("/process/:processid", a.getProcess)
+		r.GET("/process/:processid/result", a.getProcessResult)
+	}
+
+	if len(networks) > 0 {
+		a.networks = networks
+		r.GET("/networks", a.getNetworks)
+		for _, prefix := range []string{"/chain/:chainid",
+			"/chain/:chainid/contract/:contract"} {
+			r.POST(prefix+"/process/:processid", a.postVote)
+			r.GET(prefix+"/process/:processid", a.getProcess)
+			r.GET(prefix+"/process/:processid/result", a.getProcessResult)
+			r.GET(prefix+"/sync", a.getSyncStatus)
+		}
 	}
 
 	a.r = r
@@ -53,6 +97,13 @@ func New(censusBuilder *censusbuilder.CensusBuilder,
 	return &a, nil
 }
 
+// SetEthSyncer enables the endpoint that reports the status of the
+// synchronization with the Ethereum blockchain
+func (a *API) SetEthSyncer(ethSyncer EthSyncer) {
+	a.eth = ethSyncer
+	a.r.GET("/sync", a.getSyncStatus)
+}
+
 // Serve serves the API at the given port
 func (a *API) Serve(port string) error {
 	return a.r.Run(":" + port)
@@ -69,6 +120,57 @@ func returnErr(c *gin.Context, err error) {
 	})
 }
 
+// network returns the Network of the chainid and contract params of the
+// request. Requests without chainid param use the votesAggregator and
+// EthSyncer given to New and SetEthSyncer.
+func (a *API) network(c *gin.Context) (*Network, error) {
+	chainIDStr := c.Param("chainid")
+	if chainIDStr == "" {
+		return &Network{VotesAggregator: a.va, EthSyncer: a.eth}, nil
+	}
+	chainID, err := strconv.ParseUint(chainIDStr, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	contract := c.Param("contract")
+	if contract != "" && !common.IsHexAddress(contract) {
+		return nil, fmt.Errorf("invalid contract address: %s", contract)
+	}
+
+	var found []*Network
+	for i := range a.networks {
+		n := &a.networks[i]
+		if n.ChainID != chainID {
+			continue
+		}
+		if contract != "" && n.ContractAddr != common.HexToAddress(contract) {
+			continue
+		}
+		found = append(found, n)
+	}
+	if len(found) == 0 {
+		if contract != "" {
+			return nil, fmt.Errorf("contract %s of ChainID=%d not served by the node",
+				contract, chainID)
+		}
+		return nil, fmt.Errorf("ChainID=%d not served by the node", chainID)
+	}
+	if len(found) > 1 {
+		return nil, fmt.Errorf("several contracts of ChainID=%d served by the node,"+
+			" use /chain/%d/contract/:contract", chainID, chainID)
+	}
+	return found[0], nil
+}
+
+func (a *API) getNetworks(c *gin.Context) {
+	networks := []networkInfo{}
+	for _, n := range a.networks {
+		networks = append(networks, networkInfo{ChainID: n.ChainID,
+			ContractAddr: n.ContractAddr})
+	}
+	c.JSON(http.StatusOK, networks)
+}
+
 func (a *API) postNewCensus(c *gin.Context) {
 	var d newCensusReq
 	err := c.ShouldBindJSON(&d)
@@ -189,6 +291,12 @@ func (a *API) postVote(c *gin.Context) {
 	}
 	processID := uint64(processIDInt)
 
+	n, err := a.network(c)
+	if err != nil {
+		returnErr(c, err)
+		return
+	}
+
 	var vote types.VotePackage
 	err = c.ShouldBindJSON(&vote)
 	if err != nil {
@@ -196,7 +304,7 @@ func (a *API) postVote(c *gin.Context) {
 		return
 	}
 
-	err = a.va.AddVote(processID, vote)
+	err = n.VotesAggregator.AddVote(processID, vote)
 	if err != nil {
 		returnErr(c, err)
 		return
@@ -212,10 +320,48 @@ func (a *API) getProcess(c *gin.Context) {
 		returnErr(c, err)
 		return
 	}
-	processInfo, err := a.va.ProcessInfo(uint64(processID))
+	n, err := a.network(c)
+	if err != nil {
+		returnErr(c, err)
+		return
+	}
+	processInfo, err := n.VotesAggregator.ProcessInfo(uint64(processID))
 	if err != nil {
 		returnErr(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, processInfo)
 }
+
+func (a *API) getProcessResult(c *gin.Context) {
+	processIDStr := c.Param("processid")
+	processID, err := strconv.Atoi(processIDStr)
+	if err != nil {
+		returnErr(c, err)
+		return
+	}
+	n, err := a.network(c)
+	if err != nil {
+		returnErr(c, err)
+		return
+	}
+	result, err := n.VotesAggregator.ProcessResult(uint64(processID))
+	if err != nil {
+		returnErr(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+func (a *API) getSyncStatus(c *gin.Context) {
+	n, err := a.network(c)
+	if err != nil {
+		returnErr(c, err)
+		return
+	}
+	if n.EthSyncer == nil {
+		returnErr(c, fmt.Errorf("sync status not available"))
+		return
+	}
+	c.JSON(http.StatusOK, n.EthSyncer.SyncStatus())
+}
@@ -18,9 +18,11 @@ import (
 	"github.com/aragon/zkmultisig-node/census"
 	"github.com/aragon/zkmultisig-node/censusbuilder"
 	"github.com/aragon/zkmultisig-node/db"
+	"github.com/aragon/zkmultisig-node/eth"
 	"github.com/aragon/zkmultisig-node/test"
 	"github.com/aragon/zkmultisig-node/types"
 	"github.com/aragon/zkmultisig-node/votesaggregator"
+	"github.com/ethereum/go-ethereum/common"
 	qt "github.com/frankban/quicktest"
 	"github.com/gin-gonic/gin"
 	"github.com/iden3/go-iden3-crypto/babyjub"
@@ -157,7 +159,7 @@ func prepareVotes(c *qt.C, chainID, processID uint64, keys test.Keys,
 	proofs []types.CensusProof) []types.VotePackage {
 	var votes []types.VotePackage
 	for i := 0; i < len(keys.PrivateKeys); i++ {
-		voteBytes := []byte("test")
+		voteBytes := []byte{1}
 		msgToSign, err := types.HashVote(chainID, processID, voteBytes)
 		c.Assert(err, qt.IsNil)
 		sigUncomp := keys.PrivateKeys[i].SignPoseidon(msgToSign)
@@ -299,6 +301,163 @@ func TestGetProcessInfo(t *testing.T) {
 	c.Assert(process.Status, qt.Equals, types.ProcessStatusFrozen)
 }
 
+func TestGetProcessResult(t *testing.T) {
+	c := qt.New(t)
+
+	a, sqlite := newTestAPI(c, 3)
+	a.r.GET("/process/:processid/result", a.getProcessResult)
+
+	processID := uint64(123)
+	err := sqlite.StoreProcess(processID, []byte("testroot"), 100, 10, 20, 20,
+		20, 60, 1)
+	c.Assert(err, qt.IsNil)
+
+	req, err := http.NewRequest("GET", "/process/123/result", nil)
+	c.Assert(err, qt.IsNil)
+	w := httptest.NewRecorder()
+	a.r.ServeHTTP(w, req)
+	c.Assert(w.Code, qt.Equals, http.StatusBadRequest)
+
+	// the result published differs from the proof generated by the node
+	err = sqlite.StoreProof(processID, &types.ZKProof{
+		PublicInputs: []string{"3", "123", "1", "0", "10", "7", "0"}})
+	c.Assert(err, qt.IsNil)
+	err = sqlite.StoreResult(&types.ProcessResult{ProcessID: processID,
+		Publisher: []byte("publisher"), ReceiptsRoot: []byte{0}, Result: 8,
+		NVotes: 10, EthBlockNum: 25})
+	c.Assert(err, qt.IsNil)
+
+	w = httptest.NewRecorder()
+	a.r.ServeHTTP(w, req)
+	c.Assert(w.Code, qt.Equals, http.StatusOK)
+	var result types.ProcessResult
+	err = json.Unmarshal(w.Body.Bytes(), &result)
+	c.Assert(err, qt.IsNil)
+	c.Assert(result.Result, qt.Equals, uint64(8))
+	c.Assert(result.NVotes, qt.Equals, uint64(10))
+	c.Assert(result.Mismatch, qt.IsTrue)
+}
+
+type testEthSyncer struct {
+	status eth.SyncStatus
+}
+
+func (s *testEthSyncer) SyncStatus() eth.SyncStatus {
+	return s.status
+}
+
+func TestGetSyncStatus(t *testing.T) {
+	c := qt.New(t)
+
+	a, _ := newTestAPI(c, 3)
+	ethSyncer := &testEthSyncer{status: eth.SyncStatus{
+		Connected:        true,
+		HeadBlockNum:     20,
+		LastSyncBlockNum: 10,
+		BlocksBehind:     4,
+		LastError:        "new heads subscription error: connection lost",
+		Reconnections:    1,
+	}}
+	a.SetEthSyncer(ethSyncer)
+
+	req, err := http.NewRequest("GET", "/sync", nil)
+	c.Assert(err, qt.IsNil)
+	w := httptest.NewRecorder()
+	a.r.ServeHTTP(w, req)
+	c.Assert(w.Code, qt.Equals, http.StatusOK)
+
+	var status eth.SyncStatus
+	err = json.Unmarshal(w.Body.Bytes(), &status)
+	c.Assert(err, qt.IsNil)
+	c.Assert(status.Connected, qt.IsTrue)
+	c.Assert(status.Synced, qt.IsFalse)
+	c.Assert(status.BlocksBehind, qt.Equals, uint64(4))
+	c.Assert(status.LastError, qt.Equals, ethSyncer.status.LastError)
+	c.Assert(status.Reconnections, qt.Equals, uint64(1))
+}
+
+func TestNetworkRoutes(t *testing.T) {
+	c := qt.New(t)
+
+	sqlDB, err := sql.Open("sqlite3", filepath.Join(c.TempDir(), "testdb.sqlite3"))
+	c.Assert(err, qt.IsNil)
+	sqlite := db.NewSQLite(sqlDB)
+	c.Assert(sqlite.Migrate(), qt.IsNil)
+
+	// two contracts of the chain 3 and one of the chain 5, each one with a
+	// process 1 with a different censusRoot
+	addrs := []common.Address{common.HexToAddress("0x01"), common.HexToAddress("0x02"),
+		common.HexToAddress("0x01")}
+	chainIDs := []uint64{3, 3, 5}
+	var networks []Network
+	for i, addr := range addrs {
+		netDB, err := sqlite.WithNetwork(chainIDs[i], addr.Bytes())
+		c.Assert(err, qt.IsNil)
+		err = netDB.StoreProcess(1, []byte{byte(i)}, 100, 10, 20, 20, 20, 60, 1)
+		c.Assert(err, qt.IsNil)
+		va, err := votesaggregator.New(netDB, chainIDs[i])
+		c.Assert(err, qt.IsNil)
+		networks = append(networks, Network{ChainID: chainIDs[i], ContractAddr: addr,
+			VotesAggregator: va})
+	}
+	networks[2].EthSyncer = &testEthSyncer{status: eth.SyncStatus{HeadBlockNum: 20}}
+
+	// each network needs a VotesAggregator to serve its processes
+	_, err = New(nil, nil, append(networks[:len(networks):len(networks)],
+		Network{ChainID: 7, ContractAddr: addrs[0]})...)
+	c.Assert(err, qt.ErrorMatches, "Can not create the API, the network of ChainID=7 and"+
+		" contract 0x0000000000000000000000000000000000000001 has no votesAggregator")
+
+	a, err := New(nil, nil, networks...)
+	c.Assert(err, qt.IsNil)
+
+	get := func(path string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest("GET", path, nil)
+		c.Assert(err, qt.IsNil)
+		w := httptest.NewRecorder()
+		a.r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := get("/networks")
+	c.Assert(w.Code, qt.Equals, http.StatusOK)
+	var infos []networkInfo
+	c.Assert(json.Unmarshal(w.Body.Bytes(), &infos), qt.IsNil)
+	c.Assert(infos, qt.DeepEquals, []networkInfo{{3, addrs[0]}, {3, addrs[1]}, {5, addrs[2]}})
+
+	var process types.Process
+	w = get("/chain/5/process/1")
+	c.Assert(w.Code, qt.Equals, http.StatusOK)
+	c.Assert(json.Unmarshal(w.Body.Bytes(), &process), qt.IsNil)
+	c.Assert(process.CensusRoot, qt.DeepEquals, []byte{2})
+
+	w = get("/chain/3/contract/" + addrs[1].Hex() + "/process/1")
+	c.Assert(w.Code, qt.Equals, http.StatusOK)
+	c.Assert(json.Unmarshal(w.Body.Bytes(), &process), qt.IsNil)
+	c.Assert(process.CensusRoot, qt.DeepEquals, []byte{1})
+
+	w = get("/chain/3/process/1")
+	c.Assert(w.Code, qt.Equals, http.StatusBadRequest)
+	c.Assert(w.Body.String(), qt.Contains, "several contracts of ChainID=3")
+	w = get("/chain/7/process/1")
+	c.Assert(w.Code, qt.Equals, http.StatusBadRequest)
+	c.Assert(w.Body.String(), qt.Contains, "ChainID=7 not served by the node")
+	w = get("/chain/5/contract/0x03/process/1")
+	c.Assert(w.Code, qt.Equals, http.StatusBadRequest)
+
+	w = get("/chain/5/sync")
+	c.Assert(w.Code, qt.Equals, http.StatusOK)
+	var status eth.SyncStatus
+	c.Assert(json.Unmarshal(w.Body.Bytes(), &status), qt.IsNil)
+	c.Assert(status.HeadBlockNum, qt.Equals, uint64(20))
+	w = get("/chain/3/contract/" + addrs[0].Hex() + "/sync")
+	c.Assert(w.Code, qt.Equals, http.StatusBadRequest)
+
+	// the routes without chain are only served with a VotesAggregator
+	w = get("/process/1")
+	c.Assert(w.Code, qt.Equals, http.StatusNotFound)
+}
+
 func TestBuildCensusAndPostVoteHandler(t *testing.T) {
 	c := qt.New(t)
 
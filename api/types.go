@@ -3,6 +3,7 @@ package api
 import (
 	"math/big"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/iden3/go-iden3-crypto/babyjub"
 )
 
@@ -12,3 +13,8 @@ type newCensusReq struct {
 	PublicKeys []babyjub.PublicKey `json:"publicKeys"`
 	Weights    []*big.Int          `json:"weights"`
 }
+
+type networkInfo struct {
+	ChainID      uint64         `json:"chainID"`
+	ContractAddr common.Address `json:"contractAddr"`
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/aragon/zkmultisig-node/types"
+	"github.com/aragon/zkmultisig-node/verifier"
+	"github.com/aragon/zkmultisig-node/witnesscalc"
+)
+
+// circuitConfig contains the paths to the artifacts of a compiled circuit,
+// together with its metadata
+type circuitConfig struct {
+	// Name is used by the proof requests to select the circuit
+	Name string `json:"name"`
+	// Wasm is the path to the circuit.wasm file, used to compute the
+	// witness
+	Wasm string `json:"wasm"`
+	// Zkey is the path to the circuit.zkey file
+	Zkey string `json:"zkey"`
+	// VKey is the path to the snarkjs verification_key.json of the
+	// circuit. If set, the generated proofs are verified before the jobs
+	// are set to done
+	VKey string `json:"vkey"`
+	// Prover is the path to the rapidsnark prover binary. If empty, the
+	// config Prover is used
+	Prover string `json:"prover"`
+	// Meta contains the nMaxVotes, nLevels & nOptions (only set in the
+	// circuits of multi-choice processes) of the circuit, the ZKInputs of
+	// the proof requests must match them
+	Meta types.ZKCircuitMeta `json:"meta"`
+
+	// witnessCalc is the witness calculator of the compiled circuit.wasm
+	witnessCalc *witnesscalc.Calculator
+	// vk is the parsed VKey, nil if VKey is not set
+	vk *verifier.VerificationKey
+}
+
+// circuitsConfig is the content of the circuits config file
+type circuitsConfig struct {
+	// Prover is the default path to the rapidsnark prover binary
+	Prover   string          `json:"prover"`
+	Circuits []circuitConfig `json:"circuits"`
+}
+
+// loadCircuits reads the circuits config file of the given path, and returns
+// the registry of circuits by name. The circuit.wasm of each circuit is
+// compiled, so invalid circuits are detected at startup.
+func loadCircuits(path string) (map[string]*circuitConfig, error) {
+	b, err := ioutil.ReadFile(path) //nolint:gosec
+	if err != nil {
+		return nil, err
+	}
+	var config circuitsConfig
+	if err := json.Unmarshal(b, &config); err != nil {
+		return nil, fmt.Errorf("can not parse circuits config %s: %s", path, err)
+	}
+	if len(config.Circuits) == 0 {
+		return nil, fmt.Errorf("no circuits defined in %s", path)
+	}
+
+	circuits := make(map[string]*circuitConfig)
+	for i := range config.Circuits {
+		cc := config.Circuits[i]
+		if cc.Name == "" {
+			return nil, fmt.Errorf("circuit %d: name not set", i)
+		}
+		if _, ok := circuits[cc.Name]; ok {
+			return nil, fmt.Errorf("circuit %s defined more than once", cc.Name)
+		}
+		if cc.Meta.NMaxVotes <= 0 || cc.Meta.NLevels <= 0 {
+			return nil, fmt.Errorf("circuit %s: invalid meta, nMaxVotes: %d, nLevels: %d",
+				cc.Name, cc.Meta.NMaxVotes, cc.Meta.NLevels)
+		}
+		if cc.Meta.NOptions < 0 || cc.Meta.NOptions == 1 {
+			return nil, fmt.Errorf("circuit %s: invalid meta, nOptions: %d",
+				cc.Name, cc.Meta.NOptions)
+		}
+		if cc.Prover == "" {
+			cc.Prover = config.Prover
+		}
+		for _, f := range []string{cc.Zkey, cc.Prover} {
+			if _, err := os.Stat(f); err != nil {
+				return nil, fmt.Errorf("circuit %s: %s", cc.Name, err)
+			}
+		}
+		wasm, err := ioutil.ReadFile(cc.Wasm)
+		if err != nil {
+			return nil, fmt.Errorf("circuit %s: %s", cc.Name, err)
+		}
+		cc.witnessCalc, err = witnesscalc.New(wasm)
+		if err != nil {
+			return nil, fmt.Errorf("circuit %s: %s", cc.Name, err)
+		}
+		if cc.VKey != "" {
+			cc.vk, err = verifier.LoadVerificationKey(cc.VKey)
+			if err != nil {
+				return nil, fmt.Errorf("circuit %s: %s", cc.Name, err)
+			}
+		}
+		circuits[cc.Name] = &cc
+	}
+	return circuits, nil
+}
+
+// getCircuit returns the circuit of the given name. If the name is empty and
+// there is only one circuit in the registry, that circuit is returned.
+func getCircuit(circuits map[string]*circuitConfig, name string) (*circuitConfig, error) {
+	if name == "" && len(circuits) == 1 {
+		for _, cc := range circuits {
+			return cc, nil
+		}
+	}
+	cc, ok := circuits[name]
+	if !ok {
+		return nil, fmt.Errorf("circuit %q not found", name)
+	}
+	return cc, nil
+}
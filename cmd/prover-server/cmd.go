@@ -2,10 +2,10 @@ package main
 
 import (
 	"encoding/json"
+	"fmt"
 	"io/ioutil"
 	"net/http"
-	"strconv"
-	"sync"
+	"sort"
 
 	"github.com/aragon/zkmultisig-node/types"
 	"github.com/gin-gonic/gin"
@@ -15,21 +15,29 @@ import (
 	"go.vocdoni.io/dvote/log"
 )
 
-var port, dir string
+var port, dir, circuitsPath string
 
 type api struct {
-	r *gin.Engine
-	sync.Mutex
-
-	lastID int
-	db     db.Database
+	r    *gin.Engine
+	jobs *jobQueue
 }
 
 func main() {
 	flag.StringVarP(&port, "port", "p", "9000", "network port for the HTTP API")
 	flag.StringVarP(&dir, "dir", "d", "~/.proverserver", "db & files directory")
+	flag.StringVarP(&circuitsPath, "circuits", "c", "circuits.json",
+		"circuits config file, containing the artifacts & meta of each circuit")
 	flag.Parse()
 
+	circuits, err := loadCircuits(circuitsPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for name, cc := range circuits {
+		log.Infof("circuit %s loaded, nMaxVotes: %d, nLevels: %d",
+			name, cc.Meta.NMaxVotes, cc.Meta.NLevels)
+	}
+
 	opts := db.Options{Path: dir}
 	database, err := pebbledb.New(opts)
 	if err != nil {
@@ -37,13 +45,20 @@ func main() {
 	}
 
 	a := api{}
-	a.db = database
+	a.jobs = newJobQueue(database, circuits)
 	a.r = gin.Default()
-	a.lastID = 0
+
+	// resume the jobs that were running before the last stop
+	if err := a.jobs.recover(); err != nil {
+		log.Fatal(err)
+	}
+	go a.jobs.run()
 
 	a.r.GET("/status", a.getStatus)
+	a.r.GET("/circuits", a.getCircuits)
 	a.r.POST("/proof", a.genProof)
 	a.r.GET("/proof/:id", a.getProof)
+	a.r.GET("/proof/:id/status", a.getJobStatus)
 
 	err = a.r.Run(":" + port)
 	if err != nil {
@@ -51,6 +66,13 @@ func main() {
 	}
 }
 
+type circuitInfo struct {
+	Name      string `json:"name"`
+	NMaxVotes int    `json:"nMaxVotes"`
+	NLevels   int    `json:"nLevels"`
+	NOptions  int    `json:"nOptions,omitempty"`
+}
+
 type errorMsg struct {
 	Message string `json:"message"`
 }
@@ -63,25 +85,46 @@ func returnErr(c *gin.Context, err error) {
 }
 
 func (a *api) getStatus(c *gin.Context) {
-	if !a.isBusy() {
+	queued, err := a.jobs.len()
+	if err != nil {
+		returnErr(c, err)
+		return
+	}
+
+	if a.jobs.isBusy() {
 		c.JSON(http.StatusLocked, gin.H{
 			"status": "prover busy",
+			"queued": queued,
 		})
+		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"status": "ok",
+		"queued": queued,
 	})
 }
 
-func (a *api) genProof(c *gin.Context) {
-	a.lastID++
-
-	if !a.isBusy() {
-		c.JSON(http.StatusLocked, gin.H{
-			"status": "prover busy",
+func (a *api) getCircuits(c *gin.Context) {
+	var circuits []circuitInfo
+	for name, cc := range a.jobs.circuits {
+		circuits = append(circuits, circuitInfo{
+			Name:      name,
+			NMaxVotes: cc.Meta.NMaxVotes,
+			NLevels:   cc.Meta.NLevels,
+			NOptions:  cc.Meta.NOptions,
 		})
 	}
+	sort.Slice(circuits, func(i, j int) bool { return circuits[i].Name < circuits[j].Name })
+	c.JSON(http.StatusOK, circuits)
+}
+
+func (a *api) genProof(c *gin.Context) {
+	cc, err := getCircuit(a.jobs.circuits, c.Query("circuit"))
+	if err != nil {
+		returnErr(c, err)
+		return
+	}
 
 	// get zkinputs.json and store it in disk
 	var zki types.ZKInputs
@@ -89,28 +132,84 @@ func (a *api) genProof(c *gin.Context) {
 		returnErr(c, err)
 		return
 	}
+	if err := zki.CheckDimensions(cc.Meta); err != nil {
+		returnErr(c, fmt.Errorf("zkInputs do not match circuit %s: %s", cc.Name, err))
+		return
+	}
 	file, err := json.MarshalIndent(zki, "", " ")
 	if err != nil {
 		returnErr(c, err)
 		return
 	}
-	err = ioutil.WriteFile("zkinputs"+strconv.Itoa(a.lastID)+".json",
-		file, 0600)
+	id, err := a.jobs.add(cc.Name, file)
 	if err != nil {
 		returnErr(c, err)
 		return
 	}
 
-	go a.genWitnessAndProof(strconv.Itoa(a.lastID))
-
 	// return the id, so the client knows which id to use to
 	// retrieve the proof later
 	c.JSON(http.StatusOK, gin.H{
-		"id": a.lastID,
+		"id": id,
 	})
 }
 
+// getJob returns the job of the id param. If the job does not exist, replies
+// with http.StatusNotFound and returns nil.
+func (a *api) getJob(c *gin.Context) *job {
+	j, err := a.jobs.get(c.Param("id"))
+	if err == db.ErrKeyNotFound {
+		c.JSON(http.StatusNotFound, errorMsg{
+			Message: "job not found",
+		})
+		return nil
+	}
+	if err != nil {
+		returnErr(c, err)
+		return nil
+	}
+	return j
+}
+
+func (a *api) getJobStatus(c *gin.Context) {
+	j := a.getJob(c)
+	if j == nil {
+		return
+	}
+	c.JSON(http.StatusOK, j)
+}
+
 func (a *api) getProof(c *gin.Context) {
-	idStr := c.Param("id")
-	c.File("proof" + idStr + ".json")
+	j := a.getJob(c)
+	if j == nil {
+		return
+	}
+
+	switch j.State {
+	case jobStateDone:
+	case jobStateFailed:
+		c.JSON(http.StatusInternalServerError, errorMsg{
+			Message: "job failed: " + j.Error,
+		})
+		return
+	default:
+		// proof not generated yet
+		c.JSON(http.StatusAccepted, j)
+		return
+	}
+
+	proof, err := ioutil.ReadFile(proofPath(j.ID))
+	if err != nil {
+		returnErr(c, err)
+		return
+	}
+	public, err := ioutil.ReadFile(publicPath(j.ID))
+	if err != nil {
+		returnErr(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"proof":  json.RawMessage(proof),
+		"public": json.RawMessage(public),
+	})
 }
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aragon/zkmultisig-node/types"
+	qt "github.com/frankban/quicktest"
+	"github.com/gin-gonic/gin"
+)
+
+func doGet(c *qt.C, a *api, path string) (int, []byte) {
+	req, err := http.NewRequest("GET", path, nil)
+	c.Assert(err, qt.IsNil)
+	w := httptest.NewRecorder()
+	a.r.ServeHTTP(w, req)
+	body, err := ioutil.ReadAll(w.Body)
+	c.Assert(err, qt.IsNil)
+	return w.Code, body
+}
+
+func TestGetProofHandler(t *testing.T) {
+	c := qt.New(t)
+	setTempDir(c)
+
+	a := &api{r: gin.Default(), jobs: newTestJobQueue(c, c.TempDir())}
+	a.r.GET("/proof/:id", a.getProof)
+	a.r.GET("/proof/:id/status", a.getJobStatus)
+	a.jobs.genProof = func(cc *circuitConfig, id string) error {
+		err := ioutil.WriteFile(proofPath(id), []byte(`{"protocol":"groth16"}`), 0600)
+		if err != nil {
+			return err
+		}
+		return ioutil.WriteFile(publicPath(id), []byte(`["3","123"]`), 0600)
+	}
+
+	id, err := a.jobs.add("test", []byte("{}"))
+	c.Assert(err, qt.IsNil)
+	c.Assert(len(id), qt.Equals, 2*jobIDLen)
+
+	code, _ := doGet(c, a, "/proof/0")
+	c.Assert(code, qt.Equals, http.StatusNotFound)
+
+	// proof not generated yet
+	code, body := doGet(c, a, "/proof/"+id)
+	c.Assert(code, qt.Equals, http.StatusAccepted)
+	var j job
+	c.Assert(json.Unmarshal(body, &j), qt.IsNil)
+	c.Assert(j.State, qt.Equals, jobStateQueued)
+
+	a.jobs.process(id)
+
+	code, body = doGet(c, a, "/proof/"+id+"/status")
+	c.Assert(code, qt.Equals, http.StatusOK)
+	c.Assert(json.Unmarshal(body, &j), qt.IsNil)
+	c.Assert(j.State, qt.Equals, jobStateDone)
+	c.Assert(j.ProvingAt.IsZero(), qt.IsFalse)
+
+	code, body = doGet(c, a, "/proof/"+id)
+	c.Assert(code, qt.Equals, http.StatusOK)
+	c.Assert(string(body), qt.Equals,
+		`{"proof":{"protocol":"groth16"},"public":["3","123"]}`)
+}
+
+func TestGenProofHandler(t *testing.T) {
+	c := qt.New(t)
+	setTempDir(c)
+
+	a := &api{r: gin.Default(), jobs: newTestJobQueue(c, c.TempDir())}
+	a.r.POST("/proof", a.genProof)
+
+	doPost := func(path string, zki *types.ZKInputs) (int, []byte) {
+		j, err := json.Marshal(zki)
+		c.Assert(err, qt.IsNil)
+		req, err := http.NewRequest("POST", path, bytes.NewBuffer(j))
+		c.Assert(err, qt.IsNil)
+		w := httptest.NewRecorder()
+		a.r.ServeHTTP(w, req)
+		body, err := ioutil.ReadAll(w.Body)
+		c.Assert(err, qt.IsNil)
+		return w.Code, body
+	}
+
+	// the only circuit in the registry is used by default
+	code, body := doPost("/proof", types.NewZKInputs(4, 3))
+	c.Assert(code, qt.Equals, http.StatusOK, qt.Commentf("%s", body))
+	code, _ = doPost("/proof?circuit=test", types.NewZKInputs(4, 3))
+	c.Assert(code, qt.Equals, http.StatusOK)
+
+	code, body = doPost("/proof?circuit=other", types.NewZKInputs(4, 3))
+	c.Assert(code, qt.Equals, http.StatusBadRequest)
+	c.Assert(string(body), qt.Equals, `{"message":"circuit \"other\" not found"}`)
+
+	// zkInputs dimensions do not match the circuit
+	code, body = doPost("/proof", types.NewZKInputs(8, 3))
+	c.Assert(code, qt.Equals, http.StatusBadRequest)
+	c.Assert(string(body), qt.Matches, `.*zkInputs do not match circuit test.*`)
+
+	n, err := a.jobs.len()
+	c.Assert(err, qt.IsNil)
+	c.Assert(n, qt.Equals, 2)
+}
+
+func TestLoadCircuits(t *testing.T) {
+	c := qt.New(t)
+	wasm, err := ioutil.ReadFile(testWasmPath)
+	c.Assert(err, qt.IsNil)
+	tmp := c.TempDir()
+	path := func(f string) string { return filepath.Join(tmp, f) }
+
+	c.Assert(ioutil.WriteFile(path("c.wasm"), wasm, 0600), qt.IsNil)
+	for _, f := range []string{"c.zkey", "prover"} {
+		c.Assert(ioutil.WriteFile(path(f), nil, 0600), qt.IsNil)
+	}
+	config := fmt.Sprintf(`{"prover": %[1]q, "circuits": [
+		{"name": "c4", "wasm": %[2]q, "zkey": %[3]q,
+			"meta": {"nMaxVotes": 4, "nLevels": 3}},
+		{"name": "c8", "wasm": %[2]q, "zkey": %[3]q,
+			"meta": {"nMaxVotes": 8, "nLevels": 4}}
+	]}`, path("prover"), path("c.wasm"), path("c.zkey"))
+	c.Assert(ioutil.WriteFile(path("circuits.json"), []byte(config), 0600), qt.IsNil)
+
+	circuits, err := loadCircuits(path("circuits.json"))
+	c.Assert(err, qt.IsNil)
+	c.Assert(len(circuits), qt.Equals, 2)
+	c.Assert(circuits["c8"].Meta, qt.Equals, types.ZKCircuitMeta{NMaxVotes: 8, NLevels: 4})
+	c.Assert(circuits["c8"].Prover, qt.Equals, path("prover"))
+
+	// with more than one circuit, the circuit name is needed
+	_, err = getCircuit(circuits, "")
+	c.Assert(err, qt.Not(qt.IsNil))
+
+	c.Assert(os.Remove(path("c.zkey")), qt.IsNil)
+	_, err = loadCircuits(path("circuits.json"))
+	c.Assert(err, qt.ErrorMatches, "circuit c4: .*c.zkey.*")
+
+	c.Assert(ioutil.WriteFile(path("c.zkey"), nil, 0600), qt.IsNil)
+	c.Assert(ioutil.WriteFile(path("c.wasm"), []byte("not wasm"), 0600), qt.IsNil)
+	_, err = loadCircuits(path("circuits.json"))
+	c.Assert(err, qt.ErrorMatches, "circuit c4: can not compile circuit wasm.*")
+}
@@ -0,0 +1,395 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+
+	"go.vocdoni.io/dvote/db"
+	"go.vocdoni.io/dvote/log"
+)
+
+// jobState determines the current state of a proof generation job
+type jobState string
+
+const (
+	// jobStateQueued indicates that the job is waiting in the queue
+	jobStateQueued jobState = "queued"
+	// jobStateWitness indicates that the witness of the job is being
+	// computed
+	jobStateWitness jobState = "witness"
+	// jobStateProving indicates that the proof of the job is being
+	// generated
+	jobStateProving jobState = "proving"
+	// jobStateDone indicates that the proof of the job has been generated
+	jobStateDone jobState = "done"
+	// jobStateFailed indicates that the job has failed, the error output
+	// is stored in job.Error
+	jobStateFailed jobState = "failed"
+)
+
+// jobIDLen is the number of random bytes of a job id
+const jobIDLen = 16
+
+var (
+	dbPrefixJob   = []byte("job/")
+	dbPrefixQueue = []byte("queue/")
+	dbKeyNextSeq  = []byte("nextSeq")
+)
+
+// job contains the information of a proof generation job
+type job struct {
+	ID    string   `json:"id"`
+	State jobState `json:"state"`
+	Error string   `json:"error,omitempty"`
+	// Circuit is the name of the circuit used to generate the proof
+	Circuit string `json:"circuit"`
+	// QueuedAt is the time when the job was added to the queue
+	QueuedAt time.Time `json:"queuedAt"`
+	// StartedAt is the time when the witness computation started
+	StartedAt time.Time `json:"startedAt,omitempty"`
+	// ProvingAt is the time when the proof generation started
+	ProvingAt time.Time `json:"provingAt,omitempty"`
+	// FinishedAt is the time when the job reached the done or failed state
+	FinishedAt time.Time `json:"finishedAt,omitempty"`
+	// Seq is the position of the job in the queue
+	Seq uint64 `json:"seq"`
+}
+
+// newJobID returns a random hex job id, so the ids of the jobs can not be
+// guessed
+func newJobID() (string, error) {
+	b := make([]byte, jobIDLen)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// jobQueue is a FIFO queue of proof generation jobs persisted in the db, which
+// are processed one by one
+type jobQueue struct {
+	sync.RWMutex
+	db db.Database
+
+	// newJob is used to notify the worker that a new job has been queued
+	newJob chan struct{}
+	// running contains the id of the job that is being processed, empty
+	// if there is no job running
+	running string
+
+	// circuits is the registry of circuits that can be used by the jobs
+	circuits map[string]*circuitConfig
+	// genWitness and genProof are the functions used to compute the
+	// witness and the proof of each job
+	genWitness func(cc *circuitConfig, id string) error
+	genProof   func(cc *circuitConfig, id string) error
+}
+
+func newJobQueue(database db.Database, circuits map[string]*circuitConfig) *jobQueue {
+	return &jobQueue{
+		db:         database,
+		circuits:   circuits,
+		newJob:     make(chan struct{}, 1),
+		genWitness: genWitness,
+		genProof:   genProof,
+	}
+}
+
+func seqToKey(seq uint64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, seq)
+	return append(append([]byte{}, dbPrefixQueue...), b...)
+}
+
+func jobKey(id string) []byte {
+	return append(append([]byte{}, dbPrefixJob...), []byte(id)...)
+}
+
+func (q *jobQueue) getNextSeq(rTx db.ReadTx) (uint64, error) {
+	b, err := rTx.Get(dbKeyNextSeq)
+	if err == db.ErrKeyNotFound {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint64(b), nil
+}
+
+func (q *jobQueue) setJob(wTx db.WriteTx, j *job) error {
+	b, err := json.Marshal(j)
+	if err != nil {
+		return err
+	}
+	return wTx.Set(jobKey(j.ID), b)
+}
+
+func (q *jobQueue) getJob(rTx db.ReadTx, id string) (*job, error) {
+	b, err := rTx.Get(jobKey(id))
+	if err != nil {
+		return nil, err
+	}
+	var j job
+	if err := json.Unmarshal(b, &j); err != nil {
+		return nil, err
+	}
+	return &j, nil
+}
+
+// add stores a new job in the queue for the given circuit name and zkInputs,
+// returning its id. The zkInputs are written in the job's zkInputs file before
+// the job is stored.
+func (q *jobQueue) add(circuit string, zkInputs []byte) (string, error) {
+	q.Lock()
+	defer q.Unlock()
+
+	wTx := q.db.WriteTx()
+	defer wTx.Discard()
+
+	seq, err := q.getNextSeq(wTx)
+	if err != nil {
+		return "", err
+	}
+	b := make([]byte, 8)
+	binary.LittleEndian.PutUint64(b, seq+1)
+	if err := wTx.Set(dbKeyNextSeq, b); err != nil {
+		return "", err
+	}
+
+	id, err := newJobID()
+	if err != nil {
+		return "", err
+	}
+	j := &job{
+		ID:       id,
+		Circuit:  circuit,
+		State:    jobStateQueued,
+		QueuedAt: time.Now(),
+		Seq:      seq,
+	}
+	if err := ioutil.WriteFile(zkInputsPath(j.ID), zkInputs, 0600); err != nil {
+		return "", err
+	}
+	if err := q.setJob(wTx, j); err != nil {
+		return "", err
+	}
+	if err := wTx.Set(seqToKey(seq), []byte(j.ID)); err != nil {
+		return "", err
+	}
+	if err := wTx.Commit(); err != nil {
+		return "", err
+	}
+
+	// notify the worker without blocking
+	select {
+	case q.newJob <- struct{}{}:
+	default:
+	}
+	return j.ID, nil
+}
+
+// get returns the job with the given id
+func (q *jobQueue) get(id string) (*job, error) {
+	q.RLock()
+	defer q.RUnlock()
+
+	rTx := q.db.ReadTx()
+	defer rTx.Discard()
+	return q.getJob(rTx, id)
+}
+
+// next returns the id of the first job in the queue. If the queue is empty,
+// returns an empty string.
+func (q *jobQueue) next() (string, error) {
+	q.RLock()
+	defer q.RUnlock()
+
+	var id string
+	err := q.db.Iterate(dbPrefixQueue, func(_, v []byte) bool {
+		id = string(v)
+		return false
+	})
+	return id, err
+}
+
+// len returns the number of jobs in the queue (including the running one)
+func (q *jobQueue) len() (int, error) {
+	q.RLock()
+	defer q.RUnlock()
+
+	n := 0
+	err := q.db.Iterate(dbPrefixQueue, func(_, _ []byte) bool {
+		n++
+		return true
+	})
+	return n, err
+}
+
+// isBusy returns true if there is a job being processed
+func (q *jobQueue) isBusy() bool {
+	q.RLock()
+	defer q.RUnlock()
+	return q.running != ""
+}
+
+// updateState sets the given state to the job with the given id. If the state
+// is final (done or failed), the job is removed from the queue.
+func (q *jobQueue) updateState(id string, state jobState, errMsg string) error {
+	q.Lock()
+	defer q.Unlock()
+
+	wTx := q.db.WriteTx()
+	defer wTx.Discard()
+
+	j, err := q.getJob(wTx, id)
+	if err != nil {
+		return err
+	}
+	j.State = state
+	j.Error = errMsg
+	switch state {
+	case jobStateQueued:
+		j.StartedAt = time.Time{}
+		j.ProvingAt = time.Time{}
+	case jobStateWitness:
+		j.StartedAt = time.Now()
+	case jobStateProving:
+		j.ProvingAt = time.Now()
+	case jobStateDone, jobStateFailed:
+		j.FinishedAt = time.Now()
+		if err := wTx.Delete(seqToKey(j.Seq)); err != nil {
+			return err
+		}
+	}
+	if err := q.setJob(wTx, j); err != nil {
+		return err
+	}
+	return wTx.Commit()
+}
+
+// recover processes the jobs that were running when the prover-server
+// stopped. As the witness & proof files of those jobs may be incomplete, they
+// are set back to queued to be processed again from the start. Jobs which
+// zkInputs file does not exist can not be resumed, and are marked as failed.
+func (q *jobQueue) recover() error {
+	var ids []string
+	err := q.db.Iterate(dbPrefixQueue, func(_, v []byte) bool {
+		ids = append(ids, string(v))
+		return true
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		j, err := q.get(id)
+		if err != nil {
+			return fmt.Errorf("job %s in queue can not be loaded: %s", id, err)
+		}
+		if j.State != jobStateWitness && j.State != jobStateProving {
+			continue
+		}
+		if _, err := os.Stat(zkInputsPath(id)); err != nil {
+			log.Warnf("job %s was in state %s when the prover-server stopped,"+
+				" and can not be resumed: %s", id, j.State, err)
+			err = q.updateState(id, jobStateFailed,
+				"prover-server stopped, job can not be resumed: "+err.Error())
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		log.Warnf("job %s was in state %s when the prover-server stopped,"+
+			" setting it back to queued", id, j.State)
+		if err := q.updateState(id, jobStateQueued, ""); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// run processes the queued jobs in FIFO order. This method is designed to be
+// called in a goroutine.
+func (q *jobQueue) run() {
+	for {
+		id, err := q.next()
+		if err != nil {
+			log.Error(err)
+		}
+		if id == "" || err != nil {
+			select {
+			case <-q.newJob:
+			case <-time.After(time.Minute):
+			}
+			continue
+		}
+		q.process(id)
+	}
+}
+
+// process computes the witness and the proof of the job with the given id,
+// updating its state
+func (q *jobQueue) process(id string) {
+	q.Lock()
+	q.running = id
+	q.Unlock()
+	defer func() {
+		q.Lock()
+		q.running = ""
+		q.Unlock()
+	}()
+
+	j, err := q.get(id)
+	if err != nil {
+		log.Error(err)
+		return
+	}
+	cc, ok := q.circuits[j.Circuit]
+	if !ok {
+		log.Errorf("job %s: circuit %q not found", id, j.Circuit)
+		err = q.updateState(id, jobStateFailed,
+			fmt.Sprintf("circuit %q not found", j.Circuit))
+		if err != nil {
+			log.Error(err)
+		}
+		return
+	}
+
+	log.Infof("job %s: computing witness (circuit %s)", id, cc.Name)
+	if err := q.updateState(id, jobStateWitness, ""); err != nil {
+		log.Error(err)
+		return
+	}
+	if err := q.genWitness(cc, id); err != nil {
+		log.Errorf("job %s: witness error: %s", id, err)
+		if err := q.updateState(id, jobStateFailed, err.Error()); err != nil {
+			log.Error(err)
+		}
+		return
+	}
+
+	log.Infof("job %s: generating proof", id)
+	if err := q.updateState(id, jobStateProving, ""); err != nil {
+		log.Error(err)
+		return
+	}
+	if err := q.genProof(cc, id); err != nil {
+		log.Errorf("job %s: proof error: %s", id, err)
+		if err := q.updateState(id, jobStateFailed, err.Error()); err != nil {
+			log.Error(err)
+		}
+		return
+	}
+
+	if err := q.updateState(id, jobStateDone, ""); err != nil {
+		log.Error(err)
+	}
+	log.Infof("job %s: done", id)
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/aragon/zkmultisig-node/types"
+	qt "github.com/frankban/quicktest"
+	"go.vocdoni.io/dvote/db"
+	"go.vocdoni.io/dvote/db/pebbledb"
+)
+
+// setTempDir sets the data dir to a temp dir during the test, as the jobs
+// files are stored in the data dir
+func setTempDir(c *qt.C) {
+	origDir := dir
+	dir = c.TempDir()
+	c.Cleanup(func() { dir = origDir })
+}
+
+func newTestJobQueue(c *qt.C, path string) *jobQueue {
+	database, err := pebbledb.New(db.Options{Path: path})
+	c.Assert(err, qt.IsNil)
+	circuits := map[string]*circuitConfig{
+		"test": {Name: "test", Meta: types.ZKCircuitMeta{NMaxVotes: 4, NLevels: 3}},
+	}
+	q := newJobQueue(database, circuits)
+	q.genWitness = func(cc *circuitConfig, id string) error { return nil }
+	q.genProof = func(cc *circuitConfig, id string) error { return nil }
+	return q
+}
+
+func TestJobQueue(t *testing.T) {
+	c := qt.New(t)
+	setTempDir(c)
+
+	q := newTestJobQueue(c, c.TempDir())
+
+	var ids []string
+	for i := 0; i < 3; i++ {
+		id, err := q.add("test", []byte("{}"))
+		c.Assert(err, qt.IsNil)
+		ids = append(ids, id)
+	}
+	n, err := q.len()
+	c.Assert(err, qt.IsNil)
+	c.Assert(n, qt.Equals, 3)
+
+	// jobs are processed in FIFO order
+	var processed []string
+	q.genProof = func(cc *circuitConfig, id string) error {
+		processed = append(processed, id)
+		if id == ids[1] {
+			return fmt.Errorf("rapidsnark error")
+		}
+		return nil
+	}
+	for i := 0; i < 3; i++ {
+		id, err := q.next()
+		c.Assert(err, qt.IsNil)
+		c.Assert(id, qt.Equals, ids[i])
+		q.process(id)
+	}
+	c.Assert(processed, qt.DeepEquals, ids)
+
+	n, err = q.len()
+	c.Assert(err, qt.IsNil)
+	c.Assert(n, qt.Equals, 0)
+	id, err := q.next()
+	c.Assert(err, qt.IsNil)
+	c.Assert(id, qt.Equals, "")
+
+	j, err := q.get(ids[0])
+	c.Assert(err, qt.IsNil)
+	c.Assert(j.State, qt.Equals, jobStateDone)
+	c.Assert(j.StartedAt.IsZero(), qt.IsFalse)
+	c.Assert(j.FinishedAt.IsZero(), qt.IsFalse)
+
+	j, err = q.get(ids[1])
+	c.Assert(err, qt.IsNil)
+	c.Assert(j.State, qt.Equals, jobStateFailed)
+	c.Assert(j.Error, qt.Equals, "rapidsnark error")
+
+	// job with a circuit that is not in the registry
+	id, err = q.add("unknown", []byte("{}"))
+	c.Assert(err, qt.IsNil)
+	q.process(id)
+	j, err = q.get(id)
+	c.Assert(err, qt.IsNil)
+	c.Assert(j.State, qt.Equals, jobStateFailed)
+	c.Assert(j.Error, qt.Equals, `circuit "unknown" not found`)
+}
+
+func TestJobQueueRecover(t *testing.T) {
+	c := qt.New(t)
+	setTempDir(c)
+
+	dbPath := c.TempDir()
+	q := newTestJobQueue(c, dbPath)
+
+	// simulate two jobs that were running when the prover-server stopped
+	id0, err := q.add("test", []byte("{}"))
+	c.Assert(err, qt.IsNil)
+	id1, err := q.add("test", []byte("{}"))
+	c.Assert(err, qt.IsNil)
+	c.Assert(q.updateState(id0, jobStateProving, ""), qt.IsNil)
+	c.Assert(q.updateState(id1, jobStateWitness, ""), qt.IsNil)
+	c.Assert(os.Remove(zkInputsPath(id1)), qt.IsNil)
+	c.Assert(q.db.Close(), qt.IsNil)
+
+	// reopen the db from another working directory: as the jobs files are
+	// stored in the data dir, the job with zkInputs can be resumed, and new
+	// jobs do not reuse the ids of the recovered ones
+	wd, err := os.Getwd()
+	c.Assert(err, qt.IsNil)
+	c.Assert(os.Chdir(c.TempDir()), qt.IsNil)
+	c.Cleanup(func() { _ = os.Chdir(wd) })
+	q = newTestJobQueue(c, dbPath)
+	c.Assert(q.recover(), qt.IsNil)
+
+	j, err := q.get(id0)
+	c.Assert(err, qt.IsNil)
+	c.Assert(j.State, qt.Equals, jobStateQueued)
+	j, err = q.get(id1)
+	c.Assert(err, qt.IsNil)
+	c.Assert(j.State, qt.Equals, jobStateFailed)
+
+	id2, err := q.add("test", []byte("{}"))
+	c.Assert(err, qt.IsNil)
+	c.Assert(id2, qt.Not(qt.Equals), id0)
+	c.Assert(id2, qt.Not(qt.Equals), id1)
+
+	// run the worker, and wait until the queued jobs are done
+	go q.run()
+	for i := 0; i < 100; i++ {
+		n, err := q.len()
+		c.Assert(err, qt.IsNil)
+		if n == 0 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	j, err = q.get(id0)
+	c.Assert(err, qt.IsNil)
+	c.Assert(j.State, qt.Equals, jobStateDone)
+	j, err = q.get(id2)
+	c.Assert(err, qt.IsNil)
+	c.Assert(j.State, qt.Equals, jobStateDone)
+}
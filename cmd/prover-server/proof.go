@@ -1,70 +1,90 @@
 package main
 
 import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
 	"os/exec"
-	"reflect"
-	"time"
+	"path/filepath"
 
+	"github.com/aragon/zkmultisig-node/types"
+	"github.com/aragon/zkmultisig-node/witnesscalc"
 	"go.vocdoni.io/dvote/log"
 )
 
-const mutexLocked = 1
-
-func (a *api) isBusy() bool {
-	state := reflect.ValueOf(a).Elem().FieldByName("state")
-	return state.Int()&mutexLocked == mutexLocked
-}
-
-// busyFor locks the mutex for s seconds. This method is for testing
-// purposes only.
-//nolint:unused
-func (a *api) busyFor(s time.Duration) {
-	a.Lock()
-	time.Sleep(s)
-	a.Unlock()
-}
-
-func genWitness(id string) error {
-	// node ./circuit_js/generate_witness.js circuit.wasm zkinputs.json witness.wtns
-	// TODO user cmd flag for path on generate_witness.js
-	cmd := exec.Command("node", "./circuit_js/generate_witness.js", //nolint:gosec
-		"circuit.wasm", "zkinputs"+id+".json", "witness"+id+".wtns")
-	stdout, err := cmd.Output()
+// the files of each job are stored in the data dir, next to the queue db that
+// references them
+func zkInputsPath(id string) string { return filepath.Join(dir, "zkinputs"+id+".json") }
+func witnessPath(id string) string  { return filepath.Join(dir, "witness"+id+".wtns") }
+func proofPath(id string) string    { return filepath.Join(dir, "proof"+id+".json") }
+func publicPath(id string) string   { return filepath.Join(dir, "public"+id+".json") }
 
+// genWitness computes the witness of the job's zkInputs with the circuit.wasm
+// of the circuit, and writes it in the .wtns format used by the prover
+func genWitness(cc *circuitConfig, id string) error {
+	zkInputs, err := ioutil.ReadFile(zkInputsPath(id))
 	if err != nil {
-		log.Error("genWitness error:", err)
 		return err
 	}
-
-	// Print the output
-	log.Info("genWitness output:", string(stdout))
-	return nil
+	inputs, err := witnesscalc.ParseInputs(zkInputs)
+	if err != nil {
+		return fmt.Errorf("genWitness error: %s", err)
+	}
+	wtns, err := cc.witnessCalc.CalculateWTNS(inputs)
+	if err != nil {
+		return fmt.Errorf("genWitness error: %s", err)
+	}
+	return ioutil.WriteFile(witnessPath(id), wtns, 0600)
 }
 
-func genProof(id string) error {
+// genProof generates the proof of the job's witness with the rapidsnark
+// prover. If the circuit has a verification key, the generated proof is
+// verified.
+func genProof(cc *circuitConfig, id string) error {
 	// ~/bin/prover circuit.zkey witness.wtns proof.json public.json
-	// TODO user cmd flag for path on prover
-	cmd := exec.Command("./prover", "circuit.zkey", "witness"+id+".wtns", //nolint:gosec
-		"proof"+id+".json", "public"+id+".json")
-	stdout, err := cmd.Output()
+	cmd := exec.Command(cc.Prover, cc.Zkey, witnessPath(id), //nolint:gosec
+		proofPath(id), publicPath(id))
+	out, err := cmd.CombinedOutput()
 	if err != nil {
-		log.Error("genWitness error:", err)
-		return err
+		return fmt.Errorf("genProof error: %s, output: %s", err, out)
 	}
 
 	// Print the output
-	log.Info("proof output:", string(stdout))
-	return nil
+	log.Info("proof output:", string(out))
+
+	if cc.vk == nil {
+		return nil
+	}
+	return verifyProof(cc, id)
 }
 
-func (a *api) genWitnessAndProof(id string) {
-	a.Lock()
-	defer a.Unlock()
+// verifyProof checks the job's proof with the verification key of the
+// circuit, and that its public inputs match the job's zkInputs
+func verifyProof(cc *circuitConfig, id string) error {
+	var zki types.ZKInputs
+	if err := readJSONFile(zkInputsPath(id), &zki); err != nil {
+		return err
+	}
+	var zkProof types.ZKProof
+	if err := readJSONFile(proofPath(id), &zkProof.Proof); err != nil {
+		return err
+	}
+	if err := readJSONFile(publicPath(id), &zkProof.PublicInputs); err != nil {
+		return err
+	}
+	if err := cc.vk.Verify(&zki, &zkProof); err != nil {
+		return fmt.Errorf("verifyProof error: %s", err)
+	}
+	return nil
+}
 
-	if err := genWitness(id); err != nil {
-		log.Error(err)
+func readJSONFile(path string, v interface{}) error {
+	b, err := ioutil.ReadFile(path) //nolint:gosec
+	if err != nil {
+		return err
 	}
-	if err := genProof(id); err != nil {
-		log.Error(err)
+	if err := json.Unmarshal(b, v); err != nil {
+		return fmt.Errorf("can not parse %s: %s", path, err)
 	}
+	return nil
 }
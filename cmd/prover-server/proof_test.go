@@ -1,32 +1,80 @@
 package main
 
 import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"os"
 	"testing"
-	"time"
 
+	"github.com/aragon/zkmultisig-node/types"
+	"github.com/aragon/zkmultisig-node/verifier"
+	"github.com/aragon/zkmultisig-node/witnesscalc"
 	qt "github.com/frankban/quicktest"
-	"go.vocdoni.io/dvote/db"
-	"go.vocdoni.io/dvote/db/pebbledb"
 )
 
-func TestPostNewCensusHandler(t *testing.T) {
-	t.Skip()
-	// TODO WIP
+// testWasmPath is a test circuit with an input signal "in" of size 2
+const testWasmPath = "../../witnesscalc/testdata/circuit.wasm"
 
+func TestGenWitness(t *testing.T) {
 	c := qt.New(t)
+	wasm, err := ioutil.ReadFile(testWasmPath)
+	c.Assert(err, qt.IsNil)
+	setTempDir(c)
+
+	calc, err := witnesscalc.New(wasm)
+	c.Assert(err, qt.IsNil)
+	defer calc.Close() //nolint:errcheck
+	cc := &circuitConfig{Name: "test", witnessCalc: calc}
 
-	opts := db.Options{Path: c.TempDir()}
-	database, err := pebbledb.New(opts)
+	c.Assert(ioutil.WriteFile(zkInputsPath("a1"), []byte(`{"in": ["1", "2"]}`), 0600),
+		qt.IsNil)
+	c.Assert(genWitness(cc, "a1"), qt.IsNil)
+	wtns, err := ioutil.ReadFile(witnessPath("a1"))
 	c.Assert(err, qt.IsNil)
+	c.Assert(bytes.HasPrefix(wtns, []byte("wtns")), qt.IsTrue)
 
-	a := api{
-		db:     database,
-		lastID: 0,
-	}
+	// the witness is not written when the inputs do not match the circuit
+	c.Assert(ioutil.WriteFile(zkInputsPath("a2"), []byte(`{"in": ["1"]}`), 0600),
+		qt.IsNil)
+	err = genWitness(cc, "a2")
+	c.Assert(err, qt.ErrorMatches, "genWitness error: signal in expects 2 values, 1 given")
+	_, err = os.Stat(witnessPath("a2"))
+	c.Assert(os.IsNotExist(err), qt.IsTrue)
+}
 
-	c.Assert(a.isBusy(), qt.IsFalse)
-	go a.busyFor(3 * time.Second)
-	time.Sleep(1 * time.Second)
-	c.Assert(a.isBusy(), qt.IsTrue)
-	time.Sleep(2 * time.Second)
+func TestVerifyProof(t *testing.T) {
+	c := qt.New(t)
+	vk, err := verifier.LoadVerificationKey("../../verifier/testdata/verification_key.json")
+	c.Assert(err, qt.IsNil)
+	proof, err := ioutil.ReadFile("../../verifier/testdata/proof.json")
+	c.Assert(err, qt.IsNil)
+	public, err := ioutil.ReadFile("../../verifier/testdata/public.json")
+	c.Assert(err, qt.IsNil)
+	setTempDir(c)
+
+	cc := &circuitConfig{Name: "test", vk: vk}
+	c.Assert(ioutil.WriteFile(proofPath("a1"), proof, 0600), qt.IsNil)
+	c.Assert(ioutil.WriteFile(publicPath("a1"), public, 0600), qt.IsNil)
+
+	// zkInputs matching the public inputs of the testdata proof
+	zki := types.NewZKInputs(4, 3)
+	zki.ChainID = big.NewInt(3)
+	zki.ProcessID = big.NewInt(123)
+	zki.CensusRoot, _ = new(big.Int).SetString(
+		"3997482243935470019154908634129466064231369626981967795243271053776626526277", 10)
+	zki.NVotes = big.NewInt(10)
+	zki.Result = big.NewInt(7)
+	b, err := json.Marshal(zki)
+	c.Assert(err, qt.IsNil)
+	c.Assert(ioutil.WriteFile(zkInputsPath("a1"), b, 0600), qt.IsNil)
+	c.Assert(verifyProof(cc, "a1"), qt.IsNil)
+
+	zki.Result = big.NewInt(8)
+	b, err = json.Marshal(zki)
+	c.Assert(err, qt.IsNil)
+	c.Assert(ioutil.WriteFile(zkInputsPath("a1"), b, 0600), qt.IsNil)
+	c.Assert(verifyProof(cc, "a1"), qt.ErrorMatches,
+		"verifyProof error: .*public input 5 is 7, expected 8")
 }
@@ -1,14 +1,24 @@
 package main
 
 import (
+	"context"
+	"crypto/ecdsa"
 	"database/sql"
+	"fmt"
+	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/aragon/zkmultisig-node/api"
 	"github.com/aragon/zkmultisig-node/censusbuilder"
 	"github.com/aragon/zkmultisig-node/db"
 	"github.com/aragon/zkmultisig-node/eth"
+	"github.com/aragon/zkmultisig-node/proverclient"
+	"github.com/aragon/zkmultisig-node/types"
+	"github.com/aragon/zkmultisig-node/verifier"
 	"github.com/aragon/zkmultisig-node/votesaggregator"
 	"github.com/ethereum/go-ethereum/common"
 	_ "github.com/mattn/go-sqlite3"
@@ -21,9 +31,19 @@ import (
 // Config contains the main configuration parameters of the node
 type Config struct {
 	dir, logLevel, port            string
-	startScanBlock                 uint64
+	startScanBlock, confirmations  uint64
+	blockRange                     uint64
+	pollInterval, blockTime        time.Duration
 	censusBuilder, votesAggregator bool
+	voteReplacement                bool
+	printMigrations                bool
 	contractAddr, ethURL           string
+	networks                       []string
+	proverURLs                     []string
+	proverCircuit                  string
+	verificationKey                string
+	keystore, keystorePass         string
+	nMaxVotes, nLevels, nOptions   int
 }
 
 func main() {
@@ -33,16 +53,58 @@ func main() {
 	if err != nil {
 		panic(err)
 	}
+
+	if len(os.Args) > 1 && os.Args[1] == "replay" {
+		if err := replay(home, os.Args[2:]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	flag.StringVarP(&config.dir, "dir", "d", filepath.Join(home, ".zkmultisig-node"),
 		"storage data directory")
 	flag.StringVarP(&config.logLevel, "logLevel", "l", "info", "log level (info, debug, warn, error)")
 	flag.StringVarP(&config.port, "port", "p", "8080", "network port for the HTTP API")
 	flag.BoolVarP(&config.censusBuilder, "censusbuilder", "c", false, "CensusBuilder active")
 	flag.BoolVarP(&config.votesAggregator, "votesaggregator", "v", false, "VotesAggregator active")
-	flag.StringVar(&config.ethURL, "eth", "", "web3 provider url")
+	flag.BoolVar(&config.voteReplacement, "votereplacement", false,
+		"allow the voters to replace their vote until the process is frozen"+
+			" (the replaced votes are kept in the db)")
+	flag.StringVar(&config.ethURL, "eth", "",
+		"web3 provider url (with an http(s) url new blocks are polled instead of subscribed)")
 	flag.StringVar(&config.contractAddr, "addr", "", "zkMultisig contract address")
 	flag.Uint64Var(&config.startScanBlock, "block", 0,
 		"Start scanning block (usually the block where the zkMultisig contract was deployed)")
+	flag.StringArrayVar(&config.networks, "network", nil,
+		"network to sync, as 'ethurl,addr[,block]' (can be repeated, replaces --eth, --addr"+
+			" and --block)")
+	flag.Uint64Var(&config.confirmations, "confirmations", 6,
+		"number of blocks on top of a block needed to process its events")
+	flag.Uint64Var(&config.blockRange, "blockrange", eth.DefaultBlockRange,
+		"maximum number of blocks of each logs query to the web3 provider")
+	flag.DurationVar(&config.pollInterval, "pollinterval", eth.DefaultPollInterval,
+		"interval between requests of new blocks when the web3 provider url is http(s)")
+	flag.StringArrayVar(&config.proverURLs, "prover", nil,
+		"prover-server url (can be repeated to use a pool of prover-servers)")
+	flag.StringVar(&config.proverCircuit, "circuit", "",
+		"name of the prover-server circuit (not needed if the prover-server has a single circuit)")
+	flag.IntVar(&config.nMaxVotes, "nmaxvotes", 0, "nMaxVotes of the prover circuit")
+	flag.IntVar(&config.nLevels, "nlevels", 0, "nLevels of the prover circuit")
+	flag.IntVar(&config.nOptions, "noptions", 0,
+		"nOptions of the prover circuit, only set for the circuits of multi-choice processes")
+	flag.StringVar(&config.verificationKey, "vkey", "",
+		"snarkjs verification key of the prover circuit, used to verify the zkProofs")
+	flag.DurationVar(&config.blockTime, "blocktime", votesaggregator.DefaultBlockTime,
+		"average time between blocks, used to skip the zkProofs that can not be generated"+
+			" before the end of the results publishing window")
+	flag.StringVar(&config.keystore, "keystore", "",
+		"keystore file of the account that publishes the results in the zkMultisig contract"+
+			" (if not set, the results are not published by the node)")
+	flag.StringVar(&config.keystorePass, "keystorepass", "",
+		"file containing the passphrase of the keystore")
+	flag.BoolVar(&config.printMigrations, "migrations", false,
+		"print the pending db migrations and exit")
 	// TODO add flag for configurable threshold of minimum census size (to prevent small censuses)
 
 	flag.CommandLine.SortFlags = false
@@ -52,8 +114,15 @@ func main() {
 
 	log.Debugf("Config: %#v\n", config)
 
+	if config.printMigrations {
+		if err := printPendingMigrations(config.dir); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
 	var censusBuilder *censusbuilder.CensusBuilder
-	var votesAggregator *votesaggregator.VotesAggregator
+	var networks []api.Network
 	if config.censusBuilder {
 		opts := kvdb.Options{Path: filepath.Join(config.dir, "censusbuilder")}
 		database, err := pebbledb.New(opts)
@@ -68,70 +137,301 @@ func main() {
 	}
 
 	if config.votesAggregator {
+		networkConfigs, err := parseNetworks(config)
+		if err != nil {
+			log.Fatal(err)
+		}
+
 		// prepare DB
-		sqlDB, err := sql.Open("sqlite3", filepath.Join(config.dir, "testdb.sqlite3"))
+		sqlite, err := openSQLite(config.dir)
 		if err != nil {
 			log.Fatal(err)
 		}
-		sqlite := db.NewSQLite(sqlDB)
 		err = sqlite.Migrate()
 		if err != nil {
 			log.Fatal(err)
 		}
+		log.Infof("zkMultisig contract ABI version: %s", eth.ContractABIVersion)
 
-		// TODO give error if config.contractAddr is incorrect
-		contractAddr := common.HexToAddress(config.contractAddr)
-
-		// prepare ethereum client
-		ethC, err := eth.New(eth.Options{
-			EthURL:       config.ethURL,
-			SQLite:       sqlite,
-			ContractAddr: contractAddr,
-		})
-		if err != nil {
-			log.Fatal(err)
+		var key *ecdsa.PrivateKey
+		if config.keystore != "" {
+			key, err = loadKey(config)
+			if err != nil {
+				log.Fatal(err)
+			}
+		} else {
+			log.Warn("keystore flag not set, results will not be published by the node")
 		}
 
-		// TODO check that ethC has access to zkMultisig contract address
+		var ethClients []*eth.Client
+		var submitters []*eth.Submitter
+		// the submitters of the contracts on the same chain share the
+		// nonces of the key
+		nonces := eth.NewNonces()
+		for _, nc := range networkConfigs {
+			ethC, network, err := newNetwork(config, sqlite, nc)
+			if err != nil {
+				log.Fatal(err)
+			}
+			for _, n := range networks {
+				if n.ChainID == network.ChainID && n.ContractAddr == network.ContractAddr {
+					log.Fatalf("contract %s of ChainID=%d configured twice",
+						n.ContractAddr.Hex(), n.ChainID)
+				}
+			}
+			ethClients = append(ethClients, ethC)
+			networks = append(networks, network)
 
-		// set (if not set already) the 'lastSyncBlockNum'
-		// check if lastSyncBlockNum exists in the db
-		lastSyncBlockNum, err := sqlite.GetLastSyncBlockNum()
-		if err != nil && err != db.ErrMetaNotInDB {
-			log.Fatal(err)
+			if key != nil {
+				submitter, err := eth.NewSubmitter(eth.SubmitterOptions{
+					EthURL:       nc.ethURL,
+					SQLite:       sqlite,
+					ContractAddr: nc.contractAddr,
+					Key:          key,
+					Nonces:       nonces,
+				})
+				if err != nil {
+					log.Fatal(err)
+				}
+				log.Infof("[ChainID=%d] results of contract %s published by %s",
+					submitter.ChainID, nc.contractAddr.Hex(), submitter.Address().Hex())
+				submitters = append(submitters, submitter)
+			}
 		}
-		if err == db.ErrMetaNotInDB {
-			// if not in db, check that the flag is not 0, and store it
-			if config.startScanBlock == 0 {
-				log.Fatal("startblock flag can not be 0 to initialize db" +
-					" (to prevent scanning since the genesis)")
+		for _, ethC := range ethClients {
+			// Sync restarts the synchronization on failures, and only
+			// returns when its context is done
+			go func(ethC *eth.Client) {
+				if err := ethC.Sync(context.Background()); err != nil {
+					log.Fatal(err)
+				}
+			}(ethC)
+		}
+		for _, submitter := range submitters {
+			go func(submitter *eth.Submitter) {
+				if err := submitter.Run(context.Background()); err != nil {
+					log.Fatal(err)
+				}
+			}(submitter)
+		}
+
+		if len(config.proverURLs) > 0 {
+			if config.nMaxVotes == 0 || config.nLevels == 0 {
+				log.Fatal("nmaxvotes & nlevels flags are needed to use the prover")
 			}
-			err = sqlite.InitMeta(ethC.ChainID, config.startScanBlock)
+			if config.verificationKey == "" {
+				log.Fatal("vkey flag is needed to use the prover")
+			}
+			vk, err := verifier.LoadVerificationKey(config.verificationKey)
 			if err != nil {
 				log.Fatal(err)
 			}
-			lastSyncBlockNum = config.startScanBlock
+			// the provers are shared by the networks, and the jobs
+			// stored in the db refer to them by their url
+			proverPool := votesaggregator.NewProverPool(0)
+			for _, proverURL := range config.proverURLs {
+				proverClient, err := proverclient.New(proverclient.Options{
+					URL:     proverURL,
+					Circuit: config.proverCircuit,
+				})
+				if err != nil {
+					log.Fatal(err)
+				}
+				proverPool.Add(proverURL, proverClient, vk, types.ZKCircuitMeta{
+					NMaxVotes: config.nMaxVotes,
+					NLevels:   config.nLevels,
+					NOptions:  config.nOptions,
+				})
+			}
+			for i, n := range networks {
+				n.VotesAggregator.SetProverPool(proverPool)
+				n.VotesAggregator.SetChainHead(ethClients[i], config.blockTime)
+				go n.VotesAggregator.SyncProcesses()
+			}
+		} else {
+			log.Warn("prover flag not set, zkProofs will not be generated")
+		}
+	}
+
+	// with a single network, its processes are also served by the routes
+	// without chain
+	var votesAggregator *votesaggregator.VotesAggregator
+	if len(networks) == 1 {
+		votesAggregator = networks[0].VotesAggregator
+	}
+	a, err := api.New(censusBuilder, votesAggregator, networks...)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(networks) == 1 {
+		a.SetEthSyncer(networks[0].EthSyncer)
+	}
+	err = a.Serve(config.port)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// networkConfig contains the configuration of a network synced by the node
+type networkConfig struct {
+	ethURL         string
+	contractAddr   common.Address
+	startScanBlock uint64
+}
+
+// parseNetworks returns the networks of the --network flags, or the network
+// of the --eth, --addr and --block flags if there are none
+func parseNetworks(config Config) ([]networkConfig, error) {
+	if len(config.networks) == 0 {
+		if !common.IsHexAddress(config.contractAddr) {
+			return nil, fmt.Errorf("invalid zkMultisig contract address: %q",
+				config.contractAddr)
+		}
+		return []networkConfig{{
+			ethURL:         config.ethURL,
+			contractAddr:   common.HexToAddress(config.contractAddr),
+			startScanBlock: config.startScanBlock,
+		}}, nil
+	}
+	if config.ethURL != "" || config.contractAddr != "" {
+		return nil, fmt.Errorf("network flag can not be combined with eth and addr flags")
+	}
+
+	var networks []networkConfig
+	for _, network := range config.networks {
+		fields := strings.Split(network, ",")
+		if len(fields) < 2 || len(fields) > 3 { //nolint:gomnd
+			return nil, fmt.Errorf("invalid network %q, expected 'ethurl,addr[,block]'",
+				network)
+		}
+		if !common.IsHexAddress(fields[1]) {
+			return nil, fmt.Errorf("invalid zkMultisig contract address of network %q",
+				network)
+		}
+		nc := networkConfig{
+			ethURL:         fields[0],
+			contractAddr:   common.HexToAddress(fields[1]),
+			startScanBlock: config.startScanBlock,
+		}
+		if len(fields) == 3 { //nolint:gomnd
+			block, err := strconv.ParseUint(fields[2], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid start scanning block of network %q: %s",
+					network, err)
+			}
+			nc.startScanBlock = block
 		}
-		log.Infof("Eth scanning from block: %d", lastSyncBlockNum)
+		networks = append(networks, nc)
+	}
+	return networks, nil
+}
 
-		// prepare VotesAggregator
-		votesAggregator, err = votesaggregator.New(sqlite, ethC.ChainID)
+// newNetwork prepares the synchronization of the given network, returning its
+// eth.Client and its api.Network with the VotesAggregator of its processes
+func newNetwork(config Config, sqlite *db.SQLite, nc networkConfig) (*eth.Client,
+	api.Network, error) {
+	// prepare ethereum client, which scopes the db to the network
+	ethC, err := eth.New(eth.Options{
+		EthURL:        nc.ethURL,
+		SQLite:        sqlite,
+		ContractAddr:  nc.contractAddr,
+		Confirmations: config.confirmations,
+		BlockRange:    config.blockRange,
+		PollInterval:  config.pollInterval,
+	})
+	if err != nil {
+		return nil, api.Network{}, err
+	}
+	netDB := ethC.SQLite()
+
+	// check that the zkMultisig contract is deployed at the given address
+	if err := ethC.CheckContract(context.Background()); err != nil {
+		return nil, api.Network{}, err
+	}
+
+	// set (if not set already) the 'lastSyncBlockNum'
+	// check if lastSyncBlockNum exists in the db
+	lastSyncBlockNum, err := netDB.GetLastSyncBlockNum()
+	if err != nil && err != db.ErrMetaNotInDB {
+		return nil, api.Network{}, err
+	}
+	if err == db.ErrMetaNotInDB {
+		// if not in db, check that the flag is not 0, and store it
+		if nc.startScanBlock == 0 {
+			return nil, api.Network{}, fmt.Errorf("startblock flag can not be 0 to"+
+				" initialize db (to prevent scanning since the genesis) of contract %s",
+				nc.contractAddr.Hex())
+		}
+		// the start scanning block has not been synced yet
+		err = netDB.InitMeta(ethC.ChainID, nc.startScanBlock-1)
 		if err != nil {
-			log.Fatal(err)
+			return nil, api.Network{}, err
 		}
+		lastSyncBlockNum = nc.startScanBlock - 1
+	}
+	log.Infof("[ChainID=%d] Eth scanning contract %s from block: %d", ethC.ChainID,
+		nc.contractAddr.Hex(), lastSyncBlockNum+1)
+
+	// prepare VotesAggregator
+	votesAggregator, err := votesaggregator.New(netDB, ethC.ChainID)
+	if err != nil {
+		return nil, api.Network{}, err
+	}
+	votesAggregator.SetVoteReplacement(config.voteReplacement)
 
-		err = ethC.Sync()
+	return ethC, api.Network{
+		ChainID:         ethC.ChainID,
+		ContractAddr:    nc.contractAddr,
+		VotesAggregator: votesAggregator,
+		EthSyncer:       ethC,
+	}, nil
+}
+
+// loadKey loads the key of the keystore flag, decrypted with the passphrase
+// contained in the keystorepass file
+func loadKey(config Config) (*ecdsa.PrivateKey, error) {
+	var passphrase string
+	if config.keystorePass != "" {
+		b, err := ioutil.ReadFile(config.keystorePass) //nolint:gosec
 		if err != nil {
-			log.Fatal(err)
+			return nil, err
 		}
+		passphrase = strings.TrimRight(string(b), "\r\n")
 	}
+	return eth.LoadKey(config.keystore, passphrase)
+}
 
-	a, err := api.New(censusBuilder, votesAggregator)
+func openSQLite(dir string) (*db.SQLite, error) {
+	sqlDB, err := sql.Open("sqlite3", filepath.Join(dir, "testdb.sqlite3"))
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
-	err = a.Serve(config.port)
+	return db.NewSQLite(sqlDB), nil
+}
+
+// printPendingMigrations prints the migrations that will be applied to the db
+// of the given dir when starting the node
+func printPendingMigrations(dir string) error {
+	sqlite, err := openSQLite(dir)
 	if err != nil {
-		log.Fatal(err)
+		return err
+	}
+	version, err := sqlite.SchemaVersion()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("db schema version: %d (latest: %d)\n", version, db.LatestSchemaVersion())
+	pending, err := sqlite.PendingMigrations()
+	if err != nil {
+		return err
+	}
+	if len(pending) == 0 {
+		fmt.Println("no pending migrations")
+		return nil
+	}
+	fmt.Println("pending migrations:")
+	for _, m := range pending {
+		fmt.Printf("  %d: %s\n", m.Version, m.Description)
 	}
+	return nil
 }
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"github.com/aragon/zkmultisig-node/eth"
+	flag "github.com/spf13/pflag"
+	"go.vocdoni.io/dvote/log"
+)
+
+// replay rebuilds the processes of each network of the db from the contract
+// events stored in it, without accessing the web3 provider
+func replay(home string, args []string) error {
+	flags := flag.NewFlagSet("zkmultisig-node replay", flag.ExitOnError)
+	dir := flags.StringP("dir", "d", filepath.Join(home, ".zkmultisig-node"),
+		"storage data directory")
+	logLevel := flags.StringP("logLevel", "l", "info", "log level (info, debug, warn, error)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	log.Init(*logLevel, "stdout")
+
+	sqlite, err := openSQLite(*dir)
+	if err != nil {
+		return err
+	}
+	if err := sqlite.Migrate(); err != nil {
+		return err
+	}
+	networks, err := sqlite.ReadNetworks()
+	if err != nil {
+		return err
+	}
+	if len(networks) == 0 {
+		return fmt.Errorf("no networks stored in the db")
+	}
+	for _, network := range networks {
+		n, err := eth.Replay(sqlite.Network(network))
+		if errors.Is(err, eth.ErrNoEventsStored) {
+			log.Infof("[ChainID=%d] no events stored for contract %x", network.ChainID,
+				network.ContractAddr)
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("[ChainID=%d] contract %x: %w", network.ChainID,
+				network.ContractAddr, err)
+		}
+		log.Infof("[ChainID=%d] processes of contract %x rebuilt from %d stored events",
+			network.ChainID, network.ContractAddr, n)
+	}
+	return nil
+}
@@ -1,10 +1,13 @@
 package db
 
 import (
+	"bytes"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"math/big"
+	"strings"
 
 	"github.com/aragon/zkmultisig-node/types"
 )
@@ -13,82 +16,143 @@ var (
 	// ErrMetaNotInDB is used to indicate when metadata (which includes
 	// lastSyncBlockNum) is not stored in the db
 	ErrMetaNotInDB = fmt.Errorf("Meta does not exist in the db")
+	// ErrProofNotInDB is used to indicate when the proof (or the proof
+	// generation job id) of a process is not stored in the db
+	ErrProofNotInDB = fmt.Errorf("Proof does not exist in the db")
+	// ErrResultTxNotInDB is used to indicate when no publishResult
+	// transaction of a process is stored in the db
+	ErrResultTxNotInDB = fmt.Errorf("ResultTx does not exist in the db")
+	// ErrResultNotInDB is used to indicate when the published result of a
+	// process is not stored in the db
+	ErrResultNotInDB = fmt.Errorf("Result does not exist in the db")
+	// ErrProcessNotInDB is used to indicate when a process, referenced
+	// by the data being stored, is not stored in the db
+	ErrProcessNotInDB = fmt.Errorf("Process does not exist in the db")
+	// ErrReplayedVote is used to indicate when a vote that has already been
+	// replaced is submitted again, which would override the newer vote
+	ErrReplayedVote = fmt.Errorf("VotePackage has already been replaced")
 )
 
-// SQLite represents the SQLite database
+// topicLen is the length of each topic of the stored event logs
+const topicLen = 32
+
+// DuplicateVoteError is returned when trying to store a vote for a census
+// index that has already voted in the process
+type DuplicateVoteError struct {
+	ProcessID uint64
+	Index     uint64
+}
+
+// Error implements the error interface
+func (e *DuplicateVoteError) Error() string {
+	return fmt.Sprintf("vote with index %d already exists for ProcessID=%d",
+		e.Index, e.ProcessID)
+}
+
+// DefaultNetworkID is the id of the network used by the SQLite returned by
+// NewSQLite. Dbs created before the node supported several networks have
+// their data stored in it.
+const DefaultNetworkID = 1
+
+// processColumns are the columns of the processes table read into a
+// types.Process
+const processColumns = `id, status, censusRoot, censusSize, ethBlockNum,
+	resPubStartBlock, resPubWindow, minParticipation, minPositiveVotes, type,
+	insertedDatetime`
+
+// SQLite represents the SQLite database. Its methods read and store the data
+// of a single network (a zkMultisig contract of a chain), see WithNetwork.
 type SQLite struct {
-	db *sql.DB
+	db      *sql.DB
+	network uint64
 }
 
-// NewSQLite returns a new *SQLite database
+// NewSQLite returns a new *SQLite database, which uses the network with id
+// DefaultNetworkID
 func NewSQLite(db *sql.DB) *SQLite {
 	return &SQLite{
-		db: db,
+		db:      db,
+		network: DefaultNetworkID,
 	}
 }
 
-// Migrate creates the tables needed for the database
-func (r *SQLite) Migrate() error {
-	query := `
-	PRAGMA foreign_keys = ON;
-	`
-	_, err := r.db.Exec(query)
+// WithNetwork returns a copy of the SQLite that reads and stores the data of
+// the network of the given chainID and contractAddr, creating the network if
+// it does not exist yet. A network stored before the node supported several
+// networks (which has no contractAddr) is claimed by the first contractAddr
+// of its chainID.
+func (r *SQLite) WithNetwork(chainID uint64, contractAddr []byte) (*SQLite, error) {
+	if len(contractAddr) == 0 {
+		return nil, fmt.Errorf("WithNetwork error: empty contractAddr")
+	}
+	tx, err := r.db.Begin()
 	if err != nil {
-		return err
+		return nil, err
 	}
+	defer tx.Rollback() //nolint:errcheck
 
-	query = `
-	CREATE TABLE IF NOT EXISTS processes(
-		id INTEGER NOT NULL PRIMARY KEY UNIQUE,
-		status INTEGER NOT NULL,
-		censusRoot BLOB NOT NULL,
-		censusSize INTEGER NOT NULL,
-		ethBlockNum INTEGER NOT NULL,
-		resPubStartBlock INTEGER NOT NULL,
-		resPubWindow INTEGER NOT NULL,
-		minParticipation INTEGER NOT NULL,
-		minPositiveVotes INTEGER NOT NULL,
-		type INTEGER NOT NULL,
-		insertedDatetime DATETIME
-	);
-	`
-	_, err = r.db.Exec(query)
+	var id int64
+	err = tx.QueryRow("SELECT id FROM networks WHERE chainID = ? AND contractAddr = ?",
+		chainID, contractAddr).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		err = tx.QueryRow("SELECT id FROM networks WHERE chainID = ? AND contractAddr = x''",
+			chainID).Scan(&id)
+		if err == nil {
+			_, err = tx.Exec("UPDATE networks SET contractAddr = ? WHERE id = ?",
+				contractAddr, id)
+		}
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		var res sql.Result
+		res, err = tx.Exec("INSERT INTO networks(chainID, contractAddr) values(?, ?)",
+			chainID, contractAddr)
+		if err == nil {
+			id, err = res.LastInsertId()
+		}
+	}
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("WithNetwork error: %s", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
 	}
+	return &SQLite{db: r.db, network: uint64(id)}, nil
+}
 
-	query = `
-	CREATE TABLE IF NOT EXISTS votepackages(
-		indx INTEGER NOT NULL PRIMARY KEY UNIQUE,
-		publicKey BLOB NOT NULL UNIQUE,
-		weight BLOB NOT NULL,
-		merkleproof BLOB NOT NULL UNIQUE,
-		signature BLOB NOT NULL,
-		vote BLOB NOT NULL,
-		insertedDatetime DATETIME,
-		processID INTEGER NOT NULL,
-		FOREIGN KEY(processID) REFERENCES processes(id)
-	);
-	`
-	_, err = r.db.Exec(query)
+// NetworkID returns the id of the network used by the SQLite
+func (r *SQLite) NetworkID() uint64 {
+	return r.network
+}
+
+// ReadNetworks reads all the stored networks, sorted by id
+func (r *SQLite) ReadNetworks() ([]types.Network, error) {
+	rows, err := r.db.Query(`
+	SELECT id, chainID, contractAddr, lastSyncBlockNum FROM networks ORDER BY id
+	`)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	defer rows.Close() //nolint:errcheck
 
-	query = `
-	CREATE TABLE IF NOT EXISTS meta(
-		id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
-		chainID INTEGER NOT NULL,
-		lastSyncBlockNum INTEGER NOT NULL,
-		lastUpdate DATETIME
-	);
-	`
-	_, err = r.db.Exec(query)
-	if err != nil {
-		return err
+	var networks []types.Network
+	for rows.Next() {
+		network := types.Network{}
+		var lastSyncBlockNum sql.NullInt64
+		err = rows.Scan(&network.ID, &network.ChainID, &network.ContractAddr,
+			&lastSyncBlockNum)
+		if err != nil {
+			return nil, err
+		}
+		network.LastSyncBlockNum = uint64(lastSyncBlockNum.Int64)
+		networks = append(networks, network)
 	}
+	return networks, rows.Err()
+}
 
-	return nil
+// Network returns a copy of the SQLite that reads and stores the data of the
+// given stored network
+func (r *SQLite) Network(network types.Network) *SQLite {
+	return &SQLite{db: r.db, network: network.ID}
 }
 
 // StoreProcess stores a new process with the given id, censusRoot and
@@ -97,8 +161,37 @@ func (r *SQLite) Migrate() error {
 func (r *SQLite) StoreProcess(id uint64, censusRoot []byte, censusSize,
 	ethBlockNum, resPubStartBlock, resPubWindow uint64, minParticipation,
 	minPositiveVotes, typ uint8) error {
+	return r.storeProcess("", id, censusRoot, censusSize, ethBlockNum,
+		resPubStartBlock, resPubWindow, minParticipation, minPositiveVotes, typ)
+}
+
+// ReplaceProcess stores the process like StoreProcess, but if the process
+// already exists, its data is replaced keeping its status, votes and proofs.
+// It is used to rebuild the processes when replaying the stored events.
+func (r *SQLite) ReplaceProcess(id uint64, censusRoot []byte, censusSize,
+	ethBlockNum, resPubStartBlock, resPubWindow uint64, minParticipation,
+	minPositiveVotes, typ uint8) error {
+	onConflict := `
+	ON CONFLICT(networkID, id) DO UPDATE SET
+		censusRoot=excluded.censusRoot,
+		censusSize=excluded.censusSize,
+		ethBlockNum=excluded.ethBlockNum,
+		resPubStartBlock=excluded.resPubStartBlock,
+		resPubWindow=excluded.resPubWindow,
+		minParticipation=excluded.minParticipation,
+		minPositiveVotes=excluded.minPositiveVotes,
+		type=excluded.type
+	`
+	return r.storeProcess(onConflict, id, censusRoot, censusSize, ethBlockNum,
+		resPubStartBlock, resPubWindow, minParticipation, minPositiveVotes, typ)
+}
+
+func (r *SQLite) storeProcess(onConflict string, id uint64, censusRoot []byte,
+	censusSize, ethBlockNum, resPubStartBlock, resPubWindow uint64,
+	minParticipation, minPositiveVotes, typ uint8) error {
 	sqlQuery := `
 	INSERT INTO processes(
+		networkID,
 		id,
 		status,
 		censusRoot,
@@ -110,8 +203,8 @@ func (r *SQLite) StoreProcess(id uint64, censusRoot []byte, censusSize,
 		minPositiveVotes,
 		type,
 		insertedDatetime
-	) values(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
-	`
+	) values(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
+	` + onConflict
 
 	stmt, err := r.db.Prepare(sqlQuery)
 	if err != nil {
@@ -119,7 +212,7 @@ func (r *SQLite) StoreProcess(id uint64, censusRoot []byte, censusSize,
 	}
 	defer stmt.Close() //nolint:errcheck
 
-	_, err = stmt.Exec(id, types.ProcessStatusOn, censusRoot, censusSize,
+	_, err = stmt.Exec(r.network, id, types.ProcessStatusOn, censusRoot, censusSize,
 		ethBlockNum, resPubStartBlock, resPubWindow, minParticipation,
 		minPositiveVotes, typ)
 	if err != nil {
@@ -129,10 +222,13 @@ func (r *SQLite) StoreProcess(id uint64, censusRoot []byte, censusSize,
 }
 
 // UpdateProcessStatus sets the given types.ProcessStatus for the given id.
-// This method should only be called when updating from SmartContracts.
+// This method should only be called when updating from SmartContracts. A
+// process set to types.ProcessStatusQuarantined is flagged as quarantined, so
+// its quarantine is kept when its status is rebuilt or reverted.
 func (r *SQLite) UpdateProcessStatus(id uint64, status types.ProcessStatus) error {
 	sqlQuery := `
-	UPDATE processes SET status=? WHERE id=?
+	UPDATE processes SET status=?, quarantined=(quarantined OR ?)
+	WHERE networkID=? AND id=?
 	`
 
 	stmt, err := r.db.Prepare(sqlQuery)
@@ -141,7 +237,8 @@ func (r *SQLite) UpdateProcessStatus(id uint64, status types.ProcessStatus) erro
 	}
 	defer stmt.Close() //nolint:errcheck
 
-	_, err = stmt.Exec(int(status), id)
+	_, err = stmt.Exec(int(status), status == types.ProcessStatusQuarantined,
+		r.network, id)
 	if err != nil {
 		return err
 	}
@@ -150,7 +247,8 @@ func (r *SQLite) UpdateProcessStatus(id uint64, status types.ProcessStatus) erro
 
 // GetProcessStatus returns the stored types.ProcessStatus for the given id
 func (r *SQLite) GetProcessStatus(id uint64) (types.ProcessStatus, error) {
-	row := r.db.QueryRow("SELECT status FROM processes WHERE id = ?", id)
+	row := r.db.QueryRow("SELECT status FROM processes WHERE networkID = ? AND id = ?",
+		r.network, id)
 
 	var status int
 	err := row.Scan(&status)
@@ -165,7 +263,8 @@ func (r *SQLite) GetProcessStatus(id uint64) (types.ProcessStatus, error) {
 
 // ReadProcessByID reads the types.Process by the given id
 func (r *SQLite) ReadProcessByID(id uint64) (*types.Process, error) {
-	row := r.db.QueryRow("SELECT * FROM processes WHERE id = ?", id)
+	row := r.db.QueryRow("SELECT "+processColumns+
+		" FROM processes WHERE networkID = ? AND id = ?", r.network, id)
 
 	var process types.Process
 	err := row.Scan(&process.ID, &process.Status, &process.CensusRoot,
@@ -183,13 +282,13 @@ func (r *SQLite) ReadProcessByID(id uint64) (*types.Process, error) {
 
 // ReadProcesses reads all the stored types.Process
 func (r *SQLite) ReadProcesses() ([]types.Process, error) {
-	sqlQuery := `
-	SELECT * FROM processes	ORDER BY datetime(insertedDatetime) DESC
+	sqlQuery := `SELECT ` + processColumns + ` FROM processes
+	WHERE networkID = ? ORDER BY datetime(insertedDatetime) DESC
 	`
 	// TODO maybe, in all affected methods, order by EthBlockNum (creation)
 	// instead of insertedDatetime.
 
-	rows, err := r.db.Query(sqlQuery)
+	rows, err := r.db.Query(sqlQuery, r.network)
 	if err != nil {
 		return nil, err
 	}
@@ -220,7 +319,7 @@ func (r *SQLite) FrozeProcessesByCurrentBlockNum(currBlockNum uint64) error {
 	sqlQuery := `
 	UPDATE processes
 	SET status = ?
-	WHERE (resPubStartBlock <= ? AND status = ?)
+	WHERE (networkID = ? AND resPubStartBlock <= ? AND status = ?)
 	`
 
 	stmt, err := r.db.Prepare(sqlQuery)
@@ -229,7 +328,7 @@ func (r *SQLite) FrozeProcessesByCurrentBlockNum(currBlockNum uint64) error {
 	}
 	defer stmt.Close() //nolint:errcheck
 
-	_, err = stmt.Exec(types.ProcessStatusFrozen,
+	_, err = stmt.Exec(types.ProcessStatusFrozen, r.network,
 		int(currBlockNum), types.ProcessStatusOn)
 	if err != nil {
 		return err
@@ -242,11 +341,12 @@ func (r *SQLite) FrozeProcessesByCurrentBlockNum(currBlockNum uint64) error {
 func (r *SQLite) ReadProcessesByResPubStartBlock(resPubStartBlock uint64) (
 	[]types.Process, error) {
 	sqlQuery := `
-	SELECT * FROM processes WHERE resPubStartBlock = ?
+	SELECT ` + processColumns + ` FROM processes
+	WHERE networkID = ? AND resPubStartBlock = ?
 	ORDER BY datetime(resPubStartBlock) DESC
 	`
 
-	rows, err := r.db.Query(sqlQuery, resPubStartBlock)
+	rows, err := r.db.Query(sqlQuery, r.network, resPubStartBlock)
 	if err != nil {
 		return nil, err
 	}
@@ -272,11 +372,12 @@ func (r *SQLite) ReadProcessesByResPubStartBlock(resPubStartBlock uint64) (
 // status
 func (r *SQLite) ReadProcessesByStatus(status types.ProcessStatus) ([]types.Process, error) {
 	sqlQuery := `
-	SELECT * FROM processes WHERE status = ?
+	SELECT ` + processColumns + ` FROM processes
+	WHERE networkID = ? AND status = ?
 	ORDER BY datetime(insertedDatetime) DESC
 	`
 
-	rows, err := r.db.Query(sqlQuery, status)
+	rows, err := r.db.Query(sqlQuery, r.network, status)
 	if err != nil {
 		return nil, err
 	}
@@ -298,11 +399,14 @@ func (r *SQLite) ReadProcessesByStatus(status types.ProcessStatus) ([]types.Proc
 	return processes, nil
 }
 
-// StoreVotePackage stores the given types.VotePackage for the given CensusRoot
+// StoreVotePackage stores the given types.VotePackage for the given
+// ProcessID. Returns a *DuplicateVoteError if a vote with the same index has
+// already been stored for the ProcessID.
 func (r *SQLite) StoreVotePackage(processID uint64, vote types.VotePackage) error {
 	// TODO check that processID exists
 	sqlQuery := `
 	INSERT INTO votepackages(
+		networkID,
 		indx,
 		publicKey,
 		weight,
@@ -311,7 +415,7 @@ func (r *SQLite) StoreVotePackage(processID uint64, vote types.VotePackage) erro
 		vote,
 		insertedDatetime,
 		processID
-	) values(?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP, ?)
+	) values(?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP, ?)
 	`
 
 	stmt, err := r.db.Prepare(sqlQuery)
@@ -325,18 +429,157 @@ func (r *SQLite) StoreVotePackage(processID uint64, vote types.VotePackage) erro
 		vote.CensusProof.Weight = big.NewInt(0)
 	}
 
-	_, err = stmt.Exec(vote.CensusProof.Index, vote.CensusProof.PublicKey,
+	_, err = stmt.Exec(r.network, vote.CensusProof.Index, vote.CensusProof.PublicKey,
 		vote.CensusProof.Weight.Bytes(), vote.CensusProof.MerkleProof,
 		vote.Signature[:], vote.Vote, processID)
 	if err != nil {
 		if err.Error() == "FOREIGN KEY constraint failed" {
 			return fmt.Errorf("Can not store VotePackage, ProcessID=%d does not exist", processID)
 		}
+		if strings.HasPrefix(err.Error(), "UNIQUE constraint failed: votepackages.") {
+			return &DuplicateVoteError{ProcessID: processID, Index: vote.CensusProof.Index}
+		}
 		return err
 	}
 	return nil
 }
 
+// ReplaceVotePackage stores the given types.VotePackage for the given
+// ProcessID, replacing the vote with the same index if it has already been
+// stored, which is moved to the vote history. The vote is only replaced while
+// the process is in ProcessStatusOn. Returns a *DuplicateVoteError if the
+// stored vote has the same signature as the given one, and ErrReplayedVote if
+// the given vote is in the vote history of the index, so an older vote can not
+// be resubmitted to override the newer one. As the signed message of a vote
+// has no nonce (it follows the circuit), a voter can not go back to a vote
+// that has been replaced.
+func (r *SQLite) ReplaceVotePackage(processID uint64, vote types.VotePackage) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	// the status is checked in the same transaction, so the vote is not
+	// replaced once the process has been frozen
+	var status int
+	err = tx.QueryRow("SELECT status FROM processes WHERE networkID = ? AND id = ?",
+		r.network, processID).Scan(&status)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("Can not store VotePackage, ProcessID=%d does not exist", processID)
+	}
+	if err != nil {
+		return err
+	}
+	if types.ProcessStatus(status) != types.ProcessStatusOn {
+		return fmt.Errorf("Can not replace VotePackage, ProcessID=%d is not accepting votes",
+			processID)
+	}
+
+	var storedSig []byte
+	err = tx.QueryRow(`
+	SELECT signature FROM votepackages WHERE networkID = ? AND processID = ? AND indx = ?
+	`, r.network, processID, vote.CensusProof.Index).Scan(&storedSig)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	if err == nil {
+		if bytes.Equal(storedSig, vote.Signature[:]) {
+			return &DuplicateVoteError{ProcessID: processID, Index: vote.CensusProof.Index}
+		}
+		var replayed bool
+		err = tx.QueryRow(`
+		SELECT EXISTS(SELECT 1 FROM votehistory
+		WHERE networkID = ? AND processID = ? AND indx = ? AND signature = ?)
+		`, r.network, processID, vote.CensusProof.Index, vote.Signature[:]).
+			Scan(&replayed)
+		if err != nil {
+			return err
+		}
+		if replayed {
+			return fmt.Errorf("%w (ProcessID=%d, index %d)", ErrReplayedVote,
+				processID, vote.CensusProof.Index)
+		}
+		_, err = tx.Exec(`
+		INSERT INTO votehistory(networkID, processID, indx, publicKey, weight,
+			merkleproof, signature, vote, insertedDatetime, replacedDatetime)
+		SELECT networkID, processID, indx, publicKey, weight, merkleproof,
+			signature, vote, insertedDatetime, CURRENT_TIMESTAMP FROM votepackages
+		WHERE networkID = ? AND processID = ? AND indx = ?
+		`, r.network, processID, vote.CensusProof.Index)
+		if err != nil {
+			return err
+		}
+	}
+
+	if vote.CensusProof.Weight == nil {
+		// no weight defined, use 0
+		vote.CensusProof.Weight = big.NewInt(0)
+	}
+	_, err = tx.Exec(`
+	INSERT OR REPLACE INTO votepackages(
+		networkID,
+		indx,
+		publicKey,
+		weight,
+		merkleproof,
+		signature,
+		vote,
+		insertedDatetime,
+		processID
+	) values(?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP, ?)
+	`, r.network, vote.CensusProof.Index, vote.CensusProof.PublicKey,
+		vote.CensusProof.Weight.Bytes(), vote.CensusProof.MerkleProof,
+		vote.Signature[:], vote.Vote, processID)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ReadVoteHistory reads the types.VotePackage of the given index that have
+// been replaced in the given ProcessID, sorted from the oldest to the newest
+func (r *SQLite) ReadVoteHistory(processID, index uint64) ([]types.VotePackage, error) {
+	sqlQuery := `
+	SELECT signature, indx, publicKey, weight, merkleproof, vote FROM votehistory
+	WHERE networkID = ? AND processID = ? AND indx = ?
+	ORDER BY id ASC
+	`
+
+	rows, err := r.db.Query(sqlQuery, r.network, processID, index)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close() //nolint:errcheck
+
+	var votes []types.VotePackage
+	var weightBytes []byte
+	for rows.Next() {
+		vote := types.VotePackage{}
+		var sigBytes []byte
+		err = rows.Scan(&sigBytes, &vote.CensusProof.Index,
+			&vote.CensusProof.PublicKey, &weightBytes,
+			&vote.CensusProof.MerkleProof, &vote.Vote)
+		if err != nil {
+			return nil, err
+		}
+		vote.CensusProof.Weight = new(big.Int).SetBytes(weightBytes)
+		copy(vote.Signature[:], sigBytes)
+		votes = append(votes, vote)
+	}
+	return votes, nil
+}
+
+// CountVotePackages returns the number of stored types.VotePackage for the
+// given ProcessID
+func (r *SQLite) CountVotePackages(processID uint64) (int, error) {
+	var count int
+	err := r.db.QueryRow(`
+	SELECT COUNT(*) FROM votepackages WHERE networkID = ? AND processID = ?
+	`, r.network, processID).Scan(&count)
+	return count, err
+}
+
 // ReadVotePackagesByProcessID reads all the stored types.VotePackage for the
 // given ProcessID. VotePackages returned are sorted by index parameter, from
 // smaller to bigger.
@@ -344,11 +587,11 @@ func (r *SQLite) ReadVotePackagesByProcessID(processID uint64) ([]types.VotePack
 	// TODO add pagination
 	sqlQuery := `
 	SELECT signature, indx, publicKey, weight, merkleproof, vote FROM votepackages
-	WHERE processID = ?
+	WHERE networkID = ? AND processID = ?
 	ORDER BY indx ASC
 	`
 
-	rows, err := r.db.Query(sqlQuery, processID)
+	rows, err := r.db.Query(sqlQuery, r.network, processID)
 	if err != nil {
 		return nil, err
 	}
@@ -372,14 +615,510 @@ func (r *SQLite) ReadVotePackagesByProcessID(processID uint64) ([]types.VotePack
 	return votes, nil
 }
 
-// InitMeta initializes the meta table with the given chainID
+// StoreProofID stores the id of the proof generation job that the given
+// prover returned for the given processID
+func (r *SQLite) StoreProofID(processID uint64, proofID, prover string) error {
+	sqlQuery := `
+	INSERT INTO proofs(
+		networkID,
+		processID,
+		proofID,
+		prover,
+		insertedDatetime,
+		updatedDatetime
+	) values(?, ?, ?, ?, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+	ON CONFLICT(networkID, processID) DO UPDATE SET
+		proofID=excluded.proofID,
+		prover=excluded.prover,
+		updatedDatetime=CURRENT_TIMESTAMP
+	`
+
+	stmt, err := r.db.Prepare(sqlQuery)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close() //nolint:errcheck
+
+	_, err = stmt.Exec(r.network, processID, proofID, prover)
+	if err != nil {
+		if err.Error() == "FOREIGN KEY constraint failed" {
+			return fmt.Errorf("Can not store ProofID, ProcessID=%d does not exist", processID)
+		}
+		return err
+	}
+	return nil
+}
+
+// ReadProofID returns the stored proof generation job id for the given
+// processID. If no proof has been requested for the processID, returns
+// ErrProofNotInDB.
+func (r *SQLite) ReadProofID(processID uint64) (string, error) {
+	row := r.db.QueryRow("SELECT proofID FROM proofs WHERE networkID = ? AND processID = ?",
+		r.network, processID)
+
+	var proofID sql.NullString
+	err := row.Scan(&proofID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", ErrProofNotInDB
+		}
+		return "", err
+	}
+	if !proofID.Valid {
+		return "", ErrProofNotInDB
+	}
+	return proofID.String, nil
+}
+
+// ReadProofProver returns the prover of the stored proof generation job for
+// the given processID, which is empty if it was not stored. If no proof has
+// been requested for the processID, returns ErrProofNotInDB.
+func (r *SQLite) ReadProofProver(processID uint64) (string, error) {
+	row := r.db.QueryRow(`
+	SELECT proofID, prover FROM proofs WHERE networkID = ? AND processID = ?
+	`, r.network, processID)
+
+	var proofID, prover sql.NullString
+	err := row.Scan(&proofID, &prover)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", ErrProofNotInDB
+		}
+		return "", err
+	}
+	if !proofID.Valid {
+		return "", ErrProofNotInDB
+	}
+	return prover.String, nil
+}
+
+// ReleaseProofID removes the stored proof generation job for the given
+// processID, without counting it as a failed attempt, so the proof is
+// requested again
+func (r *SQLite) ReleaseProofID(processID uint64) error {
+	_, err := r.db.Exec(`
+	UPDATE proofs SET proofID=NULL, prover=NULL, updatedDatetime=CURRENT_TIMESTAMP
+	WHERE networkID = ? AND processID = ?
+	`, r.network, processID)
+	return err
+}
+
+// StoreProof stores the given generated types.ZKProof for the given
+// processID, cleaning the last stored error
+func (r *SQLite) StoreProof(processID uint64, zkProof *types.ZKProof) error {
+	proofBytes, err := json.Marshal(zkProof.Proof)
+	if err != nil {
+		return err
+	}
+	publicInputsBytes, err := json.Marshal(zkProof.PublicInputs)
+	if err != nil {
+		return err
+	}
+
+	sqlQuery := `
+	INSERT INTO proofs(
+		networkID,
+		processID,
+		proof,
+		publicInputs,
+		insertedDatetime,
+		updatedDatetime
+	) values(?, ?, ?, ?, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+	ON CONFLICT(networkID, processID) DO UPDATE SET
+		proof=excluded.proof,
+		publicInputs=excluded.publicInputs,
+		lastError=NULL,
+		updatedDatetime=CURRENT_TIMESTAMP
+	`
+
+	stmt, err := r.db.Prepare(sqlQuery)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close() //nolint:errcheck
+
+	_, err = stmt.Exec(r.network, processID, proofBytes, publicInputsBytes)
+	if err != nil {
+		if err.Error() == "FOREIGN KEY constraint failed" {
+			return fmt.Errorf("Can not store Proof, ProcessID=%d does not exist", processID)
+		}
+		return err
+	}
+
+	// if the result was already published, check it against the proof
+	result, err := r.ReadResult(processID)
+	if err == ErrResultNotInDB {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	_, err = r.db.Exec("UPDATE results SET mismatch=? WHERE networkID=? AND processID=?",
+		!result.MatchesProof(zkProof), r.network, processID)
+	return err
+}
+
+// ReadProof returns the stored types.ZKProof for the given processID. If the
+// proof has not been generated yet, returns ErrProofNotInDB.
+func (r *SQLite) ReadProof(processID uint64) (*types.ZKProof, error) {
+	row := r.db.QueryRow(`
+	SELECT proof, publicInputs FROM proofs WHERE networkID = ? AND processID = ?
+	`, r.network, processID)
+
+	var proofBytes, publicInputsBytes []byte
+	err := row.Scan(&proofBytes, &publicInputsBytes)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrProofNotInDB
+		}
+		return nil, err
+	}
+	if proofBytes == nil {
+		return nil, ErrProofNotInDB
+	}
+
+	var zkProof types.ZKProof
+	if err := json.Unmarshal(proofBytes, &zkProof.Proof); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(publicInputsBytes, &zkProof.PublicInputs); err != nil {
+		return nil, err
+	}
+	return &zkProof, nil
+}
+
+// StoreProofError stores the given error as the last error of the proof
+// generation of the given processID, and increments its number of attempts.
+// Returns the number of failed attempts for the processID.
+func (r *SQLite) StoreProofError(processID uint64, proofErr error) (int, error) {
+	sqlQuery := `
+	INSERT INTO proofs(
+		networkID,
+		processID,
+		attempts,
+		lastError,
+		insertedDatetime,
+		updatedDatetime
+	) values(?, ?, 1, ?, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+	ON CONFLICT(networkID, processID) DO UPDATE SET
+		proofID=NULL,
+		prover=NULL,
+		attempts=attempts+1,
+		lastError=excluded.lastError,
+		updatedDatetime=CURRENT_TIMESTAMP
+	`
+
+	stmt, err := r.db.Prepare(sqlQuery)
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close() //nolint:errcheck
+
+	_, err = stmt.Exec(r.network, processID, proofErr.Error())
+	if err != nil {
+		if err.Error() == "FOREIGN KEY constraint failed" {
+			return 0, fmt.Errorf("Can not store ProofError, ProcessID=%d does not exist",
+				processID)
+		}
+		return 0, err
+	}
+
+	row := r.db.QueryRow("SELECT attempts FROM proofs WHERE networkID = ? AND processID = ?",
+		r.network, processID)
+	var attempts int
+	if err := row.Scan(&attempts); err != nil {
+		return 0, err
+	}
+	return attempts, nil
+}
+
+// ReadProofError returns the number of failed attempts and the last error
+// stored for the proof generation of the given processID
+func (r *SQLite) ReadProofError(processID uint64) (int, string, error) {
+	row := r.db.QueryRow(`
+	SELECT attempts, lastError FROM proofs WHERE networkID = ? AND processID = ?
+	`, r.network, processID)
+
+	var attempts int
+	var lastError sql.NullString
+	err := row.Scan(&attempts, &lastError)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, "", ErrProofNotInDB
+		}
+		return 0, "", err
+	}
+	return attempts, lastError.String, nil
+}
+
+// StoreResultTx stores the given publishResult transactions sent for the
+// given processID, which must have a stored proof
+func (r *SQLite) StoreResultTx(processID uint64, resultTx *types.ResultTx) error {
+	resultTxBytes, err := json.Marshal(resultTx)
+	if err != nil {
+		return err
+	}
+	res, err := r.db.Exec(`
+	UPDATE proofs SET resultTx=?, updatedDatetime=CURRENT_TIMESTAMP
+	WHERE networkID = ? AND processID = ? AND proof IS NOT NULL
+	`, resultTxBytes, r.network, processID)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return fmt.Errorf("%w: Can not store ResultTx, ProcessID=%d",
+			ErrProofNotInDB, processID)
+	}
+	return nil
+}
+
+// ReadResultTx returns the stored publishResult transactions of the given
+// processID. If no transaction has been sent for the processID, or the last
+// ones have been reverted, returns ErrResultTxNotInDB.
+func (r *SQLite) ReadResultTx(processID uint64) (*types.ResultTx, error) {
+	row := r.db.QueryRow(`
+	SELECT resultTx FROM proofs WHERE networkID = ? AND processID = ?
+	`, r.network, processID)
+
+	var resultTxBytes []byte
+	err := row.Scan(&resultTxBytes)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrResultTxNotInDB
+		}
+		return nil, err
+	}
+	if resultTxBytes == nil {
+		return nil, ErrResultTxNotInDB
+	}
+	var resultTx types.ResultTx
+	if err := json.Unmarshal(resultTxBytes, &resultTx); err != nil {
+		return nil, err
+	}
+	return &resultTx, nil
+}
+
+// StoreResultTxError removes the stored publishResult transactions of the
+// given processID, storing the given error as the last error of the
+// publication, and increments its number of attempts. Returns the number of
+// failed attempts for the processID.
+func (r *SQLite) StoreResultTxError(processID uint64, txErr error) (int, error) {
+	res, err := r.db.Exec(`
+	UPDATE proofs SET resultTx=NULL, resultTxAttempts=resultTxAttempts+1,
+		resultTxError=?, updatedDatetime=CURRENT_TIMESTAMP
+	WHERE networkID = ? AND processID = ?
+	`, txErr.Error(), r.network, processID)
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	if n == 0 {
+		return 0, fmt.Errorf("%w: Can not store ResultTxError, ProcessID=%d",
+			ErrProofNotInDB, processID)
+	}
+	attempts, _, err := r.ReadResultTxError(processID)
+	return attempts, err
+}
+
+// ReadResultTxError returns the number of failed attempts and the last error
+// stored for the publication of the result of the given processID
+func (r *SQLite) ReadResultTxError(processID uint64) (int, string, error) {
+	row := r.db.QueryRow(`
+	SELECT resultTxAttempts, resultTxError FROM proofs WHERE networkID = ? AND processID = ?
+	`, r.network, processID)
+
+	var attempts int
+	var lastError sql.NullString
+	err := row.Scan(&attempts, &lastError)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, "", ErrProofNotInDB
+		}
+		return 0, "", err
+	}
+	return attempts, lastError.String, nil
+}
+
+// StoreResult stores the given result published in the SmartContract,
+// replacing the previous one of the process if any, and sets the process
+// status to types.ProcessStatusResultsPublished if the process is not closed
+// nor quarantined. The result.Mismatch is set if this node has generated the proof of the
+// process and its public inputs do not match the result. Returns
+// ErrProcessNotInDB if the process does not exist in the db.
+func (r *SQLite) StoreResult(result *types.ProcessResult) error {
+	zkProof, err := r.ReadProof(result.ProcessID)
+	if err != nil && err != ErrProofNotInDB {
+		return err
+	}
+	result.Mismatch = zkProof != nil && !result.MatchesProof(zkProof)
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	_, err = tx.Exec(`
+	INSERT OR REPLACE INTO results(
+		networkID,
+		processID,
+		publisher,
+		receiptsRoot,
+		result,
+		nVotes,
+		ethBlockNum,
+		mismatch,
+		insertedDatetime
+	) values(?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
+	`, r.network, result.ProcessID, []byte(result.Publisher), []byte(result.ReceiptsRoot),
+		result.Result, result.NVotes, int(result.EthBlockNum), result.Mismatch)
+	if err != nil {
+		if err.Error() == "FOREIGN KEY constraint failed" {
+			return fmt.Errorf("%w: Can not store Result, ProcessID=%d",
+				ErrProcessNotInDB, result.ProcessID)
+		}
+		return err
+	}
+	_, err = tx.Exec(`
+	UPDATE processes SET status=? WHERE networkID=? AND id=? AND status NOT IN (?, ?, ?)
+	`, types.ProcessStatusResultsPublished, r.network, result.ProcessID,
+		types.ProcessStatusClosedSuccess, types.ProcessStatusClosedFail,
+		types.ProcessStatusQuarantined)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ReadResult returns the published result of the given processID. If the
+// result has not been published, returns ErrResultNotInDB.
+func (r *SQLite) ReadResult(processID uint64) (*types.ProcessResult, error) {
+	row := r.db.QueryRow(`
+	SELECT processID, publisher, receiptsRoot, result, nVotes, ethBlockNum,
+		mismatch, insertedDatetime FROM results WHERE networkID = ? AND processID = ?
+	`, r.network, processID)
+
+	var result types.ProcessResult
+	err := row.Scan(&result.ProcessID, &result.Publisher, &result.ReceiptsRoot,
+		&result.Result, &result.NVotes, &result.EthBlockNum, &result.Mismatch,
+		&result.InsertedDatetime)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrResultNotInDB
+		}
+		return nil, err
+	}
+	return &result, nil
+}
+
+// CloseProcess stores the closure of the given processID in the SmartContract
+// by the given caller, and sets the process status to
+// types.ProcessStatusClosedSuccess or types.ProcessStatusClosedFail depending
+// on the given success. Returns ErrProcessNotInDB if the process does not
+// exist in the db.
+func (r *SQLite) CloseProcess(processID uint64, caller []byte, success bool,
+	ethBlockNum uint64) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	_, err = tx.Exec(`
+	INSERT OR REPLACE INTO closures(
+		networkID,
+		processID,
+		caller,
+		success,
+		ethBlockNum,
+		insertedDatetime
+	) values(?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
+	`, r.network, processID, caller, success, int(ethBlockNum))
+	if err != nil {
+		if err.Error() == "FOREIGN KEY constraint failed" {
+			return fmt.Errorf("%w: Can not close process, ProcessID=%d",
+				ErrProcessNotInDB, processID)
+		}
+		return err
+	}
+	status := types.ProcessStatusClosedFail
+	if success {
+		status = types.ProcessStatusClosedSuccess
+	}
+	_, err = tx.Exec("UPDATE processes SET status=? WHERE networkID=? AND id=?", status,
+		r.network, processID)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ClearProcessResults deletes the stored results and closures of all the
+// processes, which are rebuilt when replaying the stored events
+func (r *SQLite) ClearProcessResults() error {
+	_, err := r.db.Exec(`
+	DELETE FROM results WHERE networkID = ?; DELETE FROM closures WHERE networkID = ?;
+	`, r.network, r.network)
+	if err != nil {
+		return fmt.Errorf("ClearProcessResults error: %s", err)
+	}
+	return nil
+}
+
+// RebuildProcessStatuses sets the status of all the processes from their
+// stored closures, results and proofs, considering the given block number as
+// the current one to determine if they are frozen. The processes whose proof
+// generation has not finished are set to types.ProcessStatusFrozen, so the
+// VotesAggregator resumes it, and the quarantined processes that are not
+// closed are kept quarantined.
+func (r *SQLite) RebuildProcessStatuses(currBlockNum uint64) error {
+	_, err := r.db.Exec(`
+	UPDATE processes SET status = CASE
+		WHEN id IN (SELECT processID FROM closures
+			WHERE networkID = processes.networkID AND success) THEN ?
+		WHEN id IN (SELECT processID FROM closures
+			WHERE networkID = processes.networkID) THEN ?
+		WHEN quarantined THEN ?
+		WHEN id IN (SELECT processID FROM results
+			WHERE networkID = processes.networkID) THEN ?
+		WHEN resPubStartBlock > ? THEN ?
+		WHEN id IN (SELECT processID FROM proofs
+			WHERE networkID = processes.networkID AND proof IS NOT NULL) THEN ?
+		ELSE ? END
+	WHERE networkID = ?
+	`, types.ProcessStatusClosedSuccess, types.ProcessStatusClosedFail,
+		types.ProcessStatusQuarantined, types.ProcessStatusResultsPublished,
+		int(currBlockNum), types.ProcessStatusOn, types.ProcessStatusProofGenerated,
+		types.ProcessStatusFrozen, r.network)
+	if err != nil {
+		return fmt.Errorf("RebuildProcessStatuses error: %s", err)
+	}
+	return nil
+}
+
+// InitMeta initializes the lastSyncBlockNum of the network with the given
+// chainID. Returns an error if the network is already initialized or belongs
+// to another chainID.
 func (r *SQLite) InitMeta(chainID, lastSyncBlockNum uint64) error {
 	sqlQuery := `
-	INSERT INTO meta(
+	INSERT INTO networks(
+		id,
 		chainID,
+		contractAddr,
 		lastSyncBlockNum,
 		lastUpdate
-	) values(?, ?, CURRENT_TIMESTAMP)
+	) values(?, ?, x'', ?, CURRENT_TIMESTAMP)
+	ON CONFLICT(id) DO UPDATE SET
+		lastSyncBlockNum=excluded.lastSyncBlockNum,
+		lastUpdate=CURRENT_TIMESTAMP
+	WHERE lastSyncBlockNum IS NULL AND chainID=excluded.chainID
 	`
 
 	stmt, err := r.db.Prepare(sqlQuery)
@@ -388,18 +1127,25 @@ func (r *SQLite) InitMeta(chainID, lastSyncBlockNum uint64) error {
 	}
 	defer stmt.Close() //nolint:errcheck
 
-	_, err = stmt.Exec(chainID, lastSyncBlockNum)
+	res, err := stmt.Exec(r.network, chainID, lastSyncBlockNum)
+	if err != nil {
+		return fmt.Errorf("InitMeta error: %s", err)
+	}
+	n, err := res.RowsAffected()
 	if err != nil {
 		return fmt.Errorf("InitMeta error: %s", err)
 	}
+	if n == 0 {
+		return fmt.Errorf("InitMeta error: network %d already initialized or"+
+			" not of ChainID=%d", r.network, chainID)
+	}
 	return nil
 }
 
-// UpdateLastSyncBlockNum stores the given lastSyncBlockNum into the meta
-// unique row
+// UpdateLastSyncBlockNum stores the given lastSyncBlockNum of the network
 func (r *SQLite) UpdateLastSyncBlockNum(lastSyncBlockNum uint64) error {
 	sqlQuery := `
-	UPDATE meta SET lastSyncBlockNum=? WHERE id=?
+	UPDATE networks SET lastSyncBlockNum=?, lastUpdate=CURRENT_TIMESTAMP WHERE id=?
 	`
 
 	stmt, err := r.db.Prepare(sqlQuery)
@@ -408,18 +1154,19 @@ func (r *SQLite) UpdateLastSyncBlockNum(lastSyncBlockNum uint64) error {
 	}
 	defer stmt.Close() //nolint:errcheck
 
-	_, err = stmt.Exec(int(lastSyncBlockNum), 1)
+	_, err = stmt.Exec(int(lastSyncBlockNum), r.network)
 	if err != nil {
 		return fmt.Errorf("UpdateLastSyncBlockNum error: %s", err)
 	}
 	return nil
 }
 
-// GetLastSyncBlockNum gets the lastSyncBlockNum from the meta unique row
+// GetLastSyncBlockNum gets the lastSyncBlockNum of the network. Returns
+// ErrMetaNotInDB if it has not been initialized with InitMeta.
 func (r *SQLite) GetLastSyncBlockNum() (uint64, error) {
-	row := r.db.QueryRow("SELECT lastSyncBlockNum FROM meta WHERE id = 1")
+	row := r.db.QueryRow("SELECT lastSyncBlockNum FROM networks WHERE id = ?", r.network)
 
-	var lastSyncBlockNum int
+	var lastSyncBlockNum sql.NullInt64
 	err := row.Scan(&lastSyncBlockNum)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -427,7 +1174,225 @@ func (r *SQLite) GetLastSyncBlockNum() (uint64, error) {
 		}
 		return 0, err
 	}
-	return uint64(lastSyncBlockNum), nil
+	if !lastSyncBlockNum.Valid {
+		return 0, ErrMetaNotInDB
+	}
+	return uint64(lastSyncBlockNum.Int64), nil
+}
+
+// StoreBlockHash stores the hash of the given synced block number, replacing
+// the previous one if the block number was already stored
+func (r *SQLite) StoreBlockHash(blockNum uint64, hash []byte) error {
+	sqlQuery := `
+	INSERT OR REPLACE INTO blocks(
+		networkID,
+		number,
+		hash,
+		insertedDatetime
+	) values(?, ?, ?, CURRENT_TIMESTAMP)
+	`
+
+	stmt, err := r.db.Prepare(sqlQuery)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close() //nolint:errcheck
+
+	_, err = stmt.Exec(r.network, int(blockNum), hash)
+	if err != nil {
+		return fmt.Errorf("StoreBlockHash error: %s", err)
+	}
+	return nil
+}
+
+// ReadLastBlocks reads the given number of stored blocks with the highest
+// block numbers, sorted from bigger to smaller block number
+func (r *SQLite) ReadLastBlocks(limit int) ([]types.EthBlock, error) {
+	sqlQuery := `
+	SELECT number, hash FROM blocks WHERE networkID = ?
+	ORDER BY number DESC LIMIT ?
+	`
+
+	rows, err := r.db.Query(sqlQuery, r.network, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close() //nolint:errcheck
+
+	var blocks []types.EthBlock
+	for rows.Next() {
+		block := types.EthBlock{}
+		err = rows.Scan(&block.Number, &block.Hash)
+		if err != nil {
+			return nil, err
+		}
+		blocks = append(blocks, block)
+	}
+	return blocks, nil
+}
+
+// DeleteBlocksBefore deletes the stored blocks with a block number smaller
+// than the given one
+func (r *SQLite) DeleteBlocksBefore(blockNum uint64) error {
+	_, err := r.db.Exec("DELETE FROM blocks WHERE networkID = ? AND number < ?",
+		r.network, int(blockNum))
+	if err != nil {
+		return fmt.Errorf("DeleteBlocksBefore error: %s", err)
+	}
+	return nil
+}
+
+// StoreEvent stores the given raw event log of the SmartContract, replacing
+// the previous one if an event with the same block number and log index was
+// already stored
+func (r *SQLite) StoreEvent(event types.EthEvent) error {
+	topics := []byte{}
+	for _, topic := range event.Topics {
+		if len(topic) != topicLen {
+			return fmt.Errorf("StoreEvent error: invalid topic length (%d)", len(topic))
+		}
+		topics = append(topics, topic...)
+	}
+	if event.Data == nil {
+		event.Data = []byte{}
+	}
+
+	sqlQuery := `
+	INSERT OR REPLACE INTO events(
+		networkID,
+		blockNum,
+		blockHash,
+		txHash,
+		logIndex,
+		address,
+		topics,
+		data,
+		insertedDatetime
+	) values(?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
+	`
+
+	stmt, err := r.db.Prepare(sqlQuery)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close() //nolint:errcheck
+
+	_, err = stmt.Exec(r.network, int(event.BlockNum), event.BlockHash, event.TxHash,
+		int(event.LogIndex), event.Address, topics, event.Data)
+	if err != nil {
+		return fmt.Errorf("StoreEvent error: %s", err)
+	}
+	return nil
+}
+
+// ReadEvents reads all the stored raw event logs, sorted by block number and
+// log index
+func (r *SQLite) ReadEvents() ([]types.EthEvent, error) {
+	sqlQuery := `
+	SELECT blockNum, blockHash, txHash, logIndex, address, topics, data
+	FROM events WHERE networkID = ? ORDER BY blockNum, logIndex
+	`
+
+	rows, err := r.db.Query(sqlQuery, r.network)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close() //nolint:errcheck
+
+	var events []types.EthEvent
+	for rows.Next() {
+		event := types.EthEvent{}
+		var topics []byte
+		err = rows.Scan(&event.BlockNum, &event.BlockHash, &event.TxHash,
+			&event.LogIndex, &event.Address, &topics, &event.Data)
+		if err != nil {
+			return nil, err
+		}
+		for i := 0; i+topicLen <= len(topics); i += topicLen {
+			event.Topics = append(event.Topics, topics[i:i+topicLen])
+		}
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
+
+// RevertToBlock reverts the db to the state it had when the given block
+// number was the last synced block, which is used to handle chain reorgs. In
+// a single transaction, it deletes the processes created after the given block
+// (with their votes, proofs, results and closures, as the processes may not
+// exist in the new chain), deletes the results and closures published after
+// the given block reverting the status of their processes (keeping the
+// quarantined ones quarantined), reverts FrozeProcessesByCurrentBlockNum for
+// the processes whose ResPubStartBlock is after the given block, which are set
+// back to ProcessStatusOn deleting their proofs (as their votes are not final
+// anymore), deletes the stored events and blocks after the given block, and
+// sets the lastSyncBlockNum to the given block.
+func (r *SQLite) RevertToBlock(blockNum uint64) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	n, b := r.network, int(blockNum)
+	// the statuses reached by a process since it has been frozen
+	unfrozen := []interface{}{types.ProcessStatusFrozen, types.ProcessStatusProofGenerating,
+		types.ProcessStatusProofGenerated, types.ProcessStatusProofFailed,
+		types.ProcessStatusProofExpired, types.ProcessStatusPublishFailed}
+	queries := []struct {
+		query string
+		args  []interface{}
+	}{
+		{`DELETE FROM votepackages WHERE networkID = ? AND processID IN
+			(SELECT id FROM processes WHERE networkID = ? AND ethBlockNum > ?)`,
+			[]interface{}{n, n, b}},
+		{`DELETE FROM votehistory WHERE networkID = ? AND processID IN
+			(SELECT id FROM processes WHERE networkID = ? AND ethBlockNum > ?)`,
+			[]interface{}{n, n, b}},
+		{`DELETE FROM proofs WHERE networkID = ? AND processID IN
+			(SELECT id FROM processes WHERE networkID = ? AND ethBlockNum > ?)`,
+			[]interface{}{n, n, b}},
+		{`DELETE FROM results WHERE networkID = ? AND ethBlockNum > ?`,
+			[]interface{}{n, b}},
+		{`DELETE FROM closures WHERE networkID = ? AND ethBlockNum > ?`,
+			[]interface{}{n, b}},
+		{`DELETE FROM processes WHERE networkID = ? AND ethBlockNum > ?`,
+			[]interface{}{n, b}},
+		// the processes that are not closed anymore go back to the
+		// status given by their quarantine, remaining result or proof
+		{`UPDATE processes SET status = CASE
+			WHEN quarantined THEN ?
+			WHEN id IN (SELECT processID FROM results WHERE networkID = ?) THEN ?
+			WHEN id IN (SELECT processID FROM proofs
+				WHERE networkID = ? AND proof IS NOT NULL) THEN ?
+			ELSE ? END
+			WHERE networkID = ? AND status IN (?, ?, ?) AND
+				id NOT IN (SELECT processID FROM closures WHERE networkID = ?)`,
+			[]interface{}{types.ProcessStatusQuarantined,
+				n, types.ProcessStatusResultsPublished,
+				n, types.ProcessStatusProofGenerated, types.ProcessStatusFrozen,
+				n, types.ProcessStatusResultsPublished, types.ProcessStatusClosedSuccess,
+				types.ProcessStatusClosedFail, n}},
+		{`DELETE FROM proofs WHERE networkID = ? AND processID IN
+			(SELECT id FROM processes WHERE networkID = ? AND
+				resPubStartBlock > ? AND status IN (?, ?, ?, ?, ?, ?))`,
+			append([]interface{}{n, n, b}, unfrozen...)},
+		{`UPDATE processes SET status = ?
+			WHERE networkID = ? AND resPubStartBlock > ? AND status IN (?, ?, ?, ?, ?, ?)`,
+			append([]interface{}{types.ProcessStatusOn, n, b}, unfrozen...)},
+		{`DELETE FROM events WHERE networkID = ? AND blockNum > ?`,
+			[]interface{}{n, b}},
+		{`DELETE FROM blocks WHERE networkID = ? AND number > ?`,
+			[]interface{}{n, b}},
+		{`UPDATE networks SET lastSyncBlockNum=? WHERE id=?`,
+			[]interface{}{b, n}},
+	}
+	for _, q := range queries {
+		if _, err := tx.Exec(q.query, q.args...); err != nil {
+			return fmt.Errorf("RevertToBlock error: %s", err)
+		}
+	}
+	return tx.Commit()
 }
 
 // func (r *SQLite) ReadVotePackagesByCensusRoot(processID uint64) ([]types.VotePackage, error) {
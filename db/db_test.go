@@ -2,6 +2,8 @@ package db
 
 import (
 	"database/sql"
+	"errors"
+	"fmt"
 	"math/big"
 	"path/filepath"
 	"strconv"
@@ -47,7 +49,7 @@ func TestStoreProcess(t *testing.T) {
 		ethBlockNum, resPubStartBlock, resPubWindow, minParticipation,
 		minPositiveVotes, typ)
 	c.Assert(err, qt.Not(qt.IsNil))
-	c.Assert(err.Error(), qt.Equals, "UNIQUE constraint failed: processes.id")
+	c.Assert(err.Error(), qt.Equals, "UNIQUE constraint failed: processes.networkID, processes.id")
 
 	// try to store the a different processID, but the same censusRoot,
 	// expecting no error
@@ -301,12 +303,161 @@ func TestStoreAndReadVotes(t *testing.T) {
 	// try to store a vote with already stored index
 	err = sqlite.StoreVotePackage(processID, votesAdded[0])
 	c.Assert(err, qt.Not(qt.IsNil))
-	c.Assert(err.Error(), qt.Equals, "UNIQUE constraint failed: votepackages.indx")
+	var dupErr *DuplicateVoteError
+	c.Assert(errors.As(err, &dupErr), qt.IsTrue)
+	c.Assert(*dupErr, qt.Equals, DuplicateVoteError{ProcessID: processID, Index: 0})
 
 	// read the stored votes
 	votes, err := sqlite.ReadVotePackagesByProcessID(processID)
 	c.Assert(err, qt.IsNil)
 	c.Assert(len(votes), qt.Equals, nVotes)
+	count, err := sqlite.CountVotePackages(processID)
+	c.Assert(err, qt.IsNil)
+	c.Assert(count, qt.Equals, nVotes)
+}
+
+func testVotePackage(index uint64) types.VotePackage {
+	voteBytes := []byte("test")
+	sk := babyjub.NewRandPrivKey()
+	sig := sk.SignPoseidon(arbo.BytesToBigInt(voteBytes))
+	return types.VotePackage{
+		Signature: sig.Compress(),
+		CensusProof: types.CensusProof{
+			Index:       index,
+			PublicKey:   sk.Public(),
+			Weight:      big.NewInt(1),
+			MerkleProof: []byte("test" + strconv.Itoa(int(index))),
+		},
+		Vote: voteBytes,
+	}
+}
+
+func TestStoreVotePackageMultipleProcesses(t *testing.T) {
+	c := qt.New(t)
+
+	db, err := sql.Open("sqlite3", filepath.Join(c.TempDir(), "testdb.sqlite3"))
+	c.Assert(err, qt.IsNil)
+
+	sqlite := NewSQLite(db)
+
+	err = sqlite.Migrate()
+	c.Assert(err, qt.IsNil)
+
+	// two processes using the same census
+	for _, processID := range []uint64{1, 2} {
+		err = sqlite.StoreProcess(processID, []byte("censusRoot"), 100, 10, 20,
+			20, 60, 20, 1)
+		c.Assert(err, qt.IsNil)
+	}
+
+	// the same census member votes in both processes
+	vote := testVotePackage(3)
+	c.Assert(sqlite.StoreVotePackage(1, vote), qt.IsNil)
+	c.Assert(sqlite.StoreVotePackage(2, vote), qt.IsNil)
+
+	// but can not vote twice in the same process
+	err = sqlite.StoreVotePackage(2, vote)
+	var dupErr *DuplicateVoteError
+	c.Assert(errors.As(err, &dupErr), qt.IsTrue)
+	c.Assert(*dupErr, qt.Equals, DuplicateVoteError{ProcessID: 2, Index: 3})
+
+	for _, processID := range []uint64{1, 2} {
+		votes, err := sqlite.ReadVotePackagesByProcessID(processID)
+		c.Assert(err, qt.IsNil)
+		c.Assert(len(votes), qt.Equals, 1)
+		c.Assert(votes[0].CensusProof.Index, qt.Equals, uint64(3))
+	}
+}
+
+func TestReplaceVotePackage(t *testing.T) {
+	c := qt.New(t)
+
+	db, err := sql.Open("sqlite3", filepath.Join(c.TempDir(), "testdb.sqlite3"))
+	c.Assert(err, qt.IsNil)
+
+	sqlite := NewSQLite(db)
+
+	err = sqlite.Migrate()
+	c.Assert(err, qt.IsNil)
+
+	err = sqlite.StoreProcess(1, []byte("censusRoot"), 100, 10, 20, 20, 60, 20, 1)
+	c.Assert(err, qt.IsNil)
+
+	err = sqlite.ReplaceVotePackage(2, testVotePackage(3))
+	c.Assert(err, qt.ErrorMatches, "Can not store VotePackage, ProcessID=2 does not exist")
+
+	// without a stored vote, the vote is stored
+	first := testVotePackage(3)
+	c.Assert(sqlite.ReplaceVotePackage(1, first), qt.IsNil)
+	history, err := sqlite.ReadVoteHistory(1, 3)
+	c.Assert(err, qt.IsNil)
+	c.Assert(len(history), qt.Equals, 0)
+
+	// the same vote is not stored twice
+	err = sqlite.ReplaceVotePackage(1, first)
+	var dupErr *DuplicateVoteError
+	c.Assert(errors.As(err, &dupErr), qt.IsTrue)
+
+	second := testVotePackage(3)
+	c.Assert(sqlite.ReplaceVotePackage(1, second), qt.IsNil)
+	third := testVotePackage(3)
+	c.Assert(sqlite.ReplaceVotePackage(1, third), qt.IsNil)
+
+	votes, err := sqlite.ReadVotePackagesByProcessID(1)
+	c.Assert(err, qt.IsNil)
+	c.Assert(len(votes), qt.Equals, 1)
+	c.Assert(votes[0].Signature, qt.Equals, third.Signature)
+	count, err := sqlite.CountVotePackages(1)
+	c.Assert(err, qt.IsNil)
+	c.Assert(count, qt.Equals, 1)
+
+	// the replaced votes are kept, from the oldest to the newest
+	history, err = sqlite.ReadVoteHistory(1, 3)
+	c.Assert(err, qt.IsNil)
+	c.Assert(len(history), qt.Equals, 2)
+	c.Assert(history[0].Signature, qt.Equals, first.Signature)
+	c.Assert(history[1].Signature, qt.Equals, second.Signature)
+	c.Assert(history[1].CensusProof.Weight.String(), qt.Equals, "1")
+
+	// a replaced vote can not be resubmitted to override the newer one
+	err = sqlite.ReplaceVotePackage(1, first)
+	c.Assert(errors.Is(err, ErrReplayedVote), qt.IsTrue)
+	err = sqlite.ReplaceVotePackage(1, second)
+	c.Assert(errors.Is(err, ErrReplayedVote), qt.IsTrue)
+	votes, err = sqlite.ReadVotePackagesByProcessID(1)
+	c.Assert(err, qt.IsNil)
+	c.Assert(votes[0].Signature, qt.Equals, third.Signature)
+	history, err = sqlite.ReadVoteHistory(1, 3)
+	c.Assert(err, qt.IsNil)
+	c.Assert(len(history), qt.Equals, 2)
+
+	// the signatures are deterministic and the signed message has no nonce,
+	// so a voter can not go back to a vote that has been replaced (A->B->A)
+	sk := babyjub.NewRandPrivKey()
+	signedVote := func(vote string) types.VotePackage {
+		vp := testVotePackage(4)
+		vp.CensusProof.PublicKey = sk.Public()
+		vp.Vote = []byte(vote)
+		vp.Signature = sk.SignPoseidon(arbo.BytesToBigInt(vp.Vote)).Compress()
+		return vp
+	}
+	c.Assert(sqlite.ReplaceVotePackage(1, signedVote("A")), qt.IsNil)
+	c.Assert(sqlite.ReplaceVotePackage(1, signedVote("B")), qt.IsNil)
+	err = sqlite.ReplaceVotePackage(1, signedVote("A"))
+	c.Assert(errors.Is(err, ErrReplayedVote), qt.IsTrue)
+	votes, err = sqlite.ReadVotePackagesByProcessID(1)
+	c.Assert(err, qt.IsNil)
+	c.Assert(string(votes[1].Vote), qt.Equals, "B")
+
+	// once the process is frozen, the vote can not be replaced
+	err = sqlite.UpdateProcessStatus(1, types.ProcessStatusFrozen)
+	c.Assert(err, qt.IsNil)
+	err = sqlite.ReplaceVotePackage(1, testVotePackage(3))
+	c.Assert(err, qt.ErrorMatches,
+		"Can not replace VotePackage, ProcessID=1 is not accepting votes")
+	history, err = sqlite.ReadVoteHistory(1, 3)
+	c.Assert(err, qt.IsNil)
+	c.Assert(len(history), qt.Equals, 2)
 }
 
 func TestFrozeProcessesByCurrentBlockNum(t *testing.T) {
@@ -388,3 +539,660 @@ func TestMetaTable(t *testing.T) {
 	c.Assert(err, qt.IsNil)
 	c.Assert(b, qt.Equals, uint64(1234))
 }
+
+func TestNetworks(t *testing.T) {
+	c := qt.New(t)
+
+	db, err := sql.Open("sqlite3", filepath.Join(c.TempDir(), "testdb.sqlite3"))
+	c.Assert(err, qt.IsNil)
+
+	sqlite := NewSQLite(db)
+
+	err = sqlite.Migrate()
+	c.Assert(err, qt.IsNil)
+
+	_, err = sqlite.WithNetwork(1, nil)
+	c.Assert(err, qt.ErrorMatches, "WithNetwork error: empty contractAddr")
+
+	sqlite1, err := sqlite.WithNetwork(1, []byte("addr"))
+	c.Assert(err, qt.IsNil)
+	sqlite2, err := sqlite.WithNetwork(2, []byte("addr"))
+	c.Assert(err, qt.IsNil)
+	c.Assert(sqlite2.NetworkID(), qt.Not(qt.Equals), sqlite1.NetworkID())
+	// the network is reused once created
+	sqlite1b, err := sqlite.WithNetwork(1, []byte("addr"))
+	c.Assert(err, qt.IsNil)
+	c.Assert(sqlite1b.NetworkID(), qt.Equals, sqlite1.NetworkID())
+
+	c.Assert(sqlite1.InitMeta(1, 10), qt.IsNil)
+	c.Assert(sqlite2.InitMeta(2, 20), qt.IsNil)
+	err = sqlite1.InitMeta(1, 10)
+	c.Assert(err, qt.ErrorMatches, "InitMeta error: network 1 already initialized.*")
+
+	// the same process id is stored in both networks
+	err = sqlite1.StoreProcess(1, []byte("censusRoot1"), 100, 11, 30, 20, 60, 20, 1)
+	c.Assert(err, qt.IsNil)
+	err = sqlite2.StoreProcess(1, []byte("censusRoot2"), 100, 21, 30, 20, 60, 20, 1)
+	c.Assert(err, qt.IsNil)
+	c.Assert(sqlite1.StoreVotePackage(1, testVotePackage(0)), qt.IsNil)
+	c.Assert(sqlite2.StoreVotePackage(1, testVotePackage(0)), qt.IsNil)
+	c.Assert(sqlite2.StoreVotePackage(1, testVotePackage(1)), qt.IsNil)
+	err = sqlite1.CloseProcess(1, []byte("caller"), true, 12)
+	c.Assert(err, qt.IsNil)
+
+	process, err := sqlite1.ReadProcessByID(1)
+	c.Assert(err, qt.IsNil)
+	c.Assert(process.CensusRoot, qt.DeepEquals, []byte("censusRoot1"))
+	c.Assert(process.Status, qt.Equals, types.ProcessStatusClosedSuccess)
+	process, err = sqlite2.ReadProcessByID(1)
+	c.Assert(err, qt.IsNil)
+	c.Assert(process.CensusRoot, qt.DeepEquals, []byte("censusRoot2"))
+	c.Assert(process.Status, qt.Equals, types.ProcessStatusOn)
+	votes, err := sqlite1.ReadVotePackagesByProcessID(1)
+	c.Assert(err, qt.IsNil)
+	c.Assert(len(votes), qt.Equals, 1)
+	votes, err = sqlite2.ReadVotePackagesByProcessID(1)
+	c.Assert(err, qt.IsNil)
+	c.Assert(len(votes), qt.Equals, 2)
+
+	// reverting a network does not affect the others
+	c.Assert(sqlite2.RevertToBlock(20), qt.IsNil)
+	_, err = sqlite2.ReadProcessByID(1)
+	c.Assert(err, qt.Not(qt.IsNil))
+	_, err = sqlite1.ReadProcessByID(1)
+	c.Assert(err, qt.IsNil)
+	lastSyncBlockNum, err := sqlite1.GetLastSyncBlockNum()
+	c.Assert(err, qt.IsNil)
+	c.Assert(lastSyncBlockNum, qt.Equals, uint64(10))
+
+	networks, err := sqlite.ReadNetworks()
+	c.Assert(err, qt.IsNil)
+	c.Assert(networks, qt.DeepEquals, []types.Network{
+		{ID: 1, ChainID: 1, ContractAddr: []byte("addr"), LastSyncBlockNum: 10},
+		{ID: 2, ChainID: 2, ContractAddr: []byte("addr"), LastSyncBlockNum: 20},
+	})
+	c.Assert(sqlite.Network(networks[1]).NetworkID(), qt.Equals, uint64(2))
+}
+
+func TestProofs(t *testing.T) {
+	c := qt.New(t)
+
+	db, err := sql.Open("sqlite3", filepath.Join(c.TempDir(), "testdb.sqlite3"))
+	c.Assert(err, qt.IsNil)
+
+	sqlite := NewSQLite(db)
+
+	err = sqlite.Migrate()
+	c.Assert(err, qt.IsNil)
+
+	processID := uint64(123)
+
+	// expect error when storing the proofID, as processID does not exist yet
+	err = sqlite.StoreProofID(processID, "1", "prover1")
+	c.Assert(err, qt.Not(qt.IsNil))
+	c.Assert(err.Error(), qt.Equals, "Can not store ProofID, ProcessID=123 does not exist")
+
+	err = sqlite.StoreProcess(processID, []byte("censusRoot"), 100, 10, 20,
+		20, 60, 20, 1)
+	c.Assert(err, qt.IsNil)
+
+	_, err = sqlite.ReadProofID(processID)
+	c.Assert(err, qt.Equals, ErrProofNotInDB)
+
+	err = sqlite.StoreProofID(processID, "1", "prover1")
+	c.Assert(err, qt.IsNil)
+	proofID, err := sqlite.ReadProofID(processID)
+	c.Assert(err, qt.IsNil)
+	c.Assert(proofID, qt.Equals, "1")
+	prover, err := sqlite.ReadProofProver(processID)
+	c.Assert(err, qt.IsNil)
+	c.Assert(prover, qt.Equals, "prover1")
+
+	// the job is released to be requested again, without counting an
+	// attempt
+	c.Assert(sqlite.ReleaseProofID(processID), qt.IsNil)
+	_, err = sqlite.ReadProofID(processID)
+	c.Assert(err, qt.Equals, ErrProofNotInDB)
+	_, err = sqlite.ReadProofProver(processID)
+	c.Assert(err, qt.Equals, ErrProofNotInDB)
+	attempts, _, err := sqlite.ReadProofError(processID)
+	c.Assert(err, qt.IsNil)
+	c.Assert(attempts, qt.Equals, 0)
+	err = sqlite.StoreProofID(processID, "1", "prover1")
+	c.Assert(err, qt.IsNil)
+
+	// proof generation fails, and the proofID is removed
+	attempts, err = sqlite.StoreProofError(processID, fmt.Errorf("test error"))
+	c.Assert(err, qt.IsNil)
+	c.Assert(attempts, qt.Equals, 1)
+	_, err = sqlite.ReadProofID(processID)
+	c.Assert(err, qt.Equals, ErrProofNotInDB)
+
+	err = sqlite.StoreProofID(processID, "2", "prover2")
+	c.Assert(err, qt.IsNil)
+
+	_, err = sqlite.ReadProof(processID)
+	c.Assert(err, qt.Equals, ErrProofNotInDB)
+
+	zkProof := &types.ZKProof{
+		Proof: types.Groth16Proof{
+			A:        []string{"1", "2", "1"},
+			B:        [][]string{{"1", "2"}, {"3", "4"}, {"1", "0"}},
+			C:        []string{"5", "6", "1"},
+			Protocol: "groth16",
+		},
+		PublicInputs: []string{"3", "123"},
+	}
+	err = sqlite.StoreProof(processID, zkProof)
+	c.Assert(err, qt.IsNil)
+
+	zkProof2, err := sqlite.ReadProof(processID)
+	c.Assert(err, qt.IsNil)
+	c.Assert(zkProof2, qt.DeepEquals, zkProof)
+
+	attempts, lastErr, err := sqlite.ReadProofError(processID)
+	c.Assert(err, qt.IsNil)
+	c.Assert(attempts, qt.Equals, 1)
+	c.Assert(lastErr, qt.Equals, "")
+}
+
+func testZKProof(publicInputs ...string) *types.ZKProof {
+	return &types.ZKProof{
+		Proof: types.Groth16Proof{
+			A:        []string{"1", "2", "1"},
+			B:        [][]string{{"1", "2"}, {"3", "4"}, {"1", "0"}},
+			C:        []string{"5", "6", "1"},
+			Protocol: "groth16",
+		},
+		PublicInputs: publicInputs,
+	}
+}
+
+func TestResultTx(t *testing.T) {
+	c := qt.New(t)
+
+	db, err := sql.Open("sqlite3", filepath.Join(c.TempDir(), "testdb.sqlite3"))
+	c.Assert(err, qt.IsNil)
+
+	sqlite := NewSQLite(db)
+
+	err = sqlite.Migrate()
+	c.Assert(err, qt.IsNil)
+
+	err = sqlite.StoreProcess(1, []byte("censusRoot"), 100, 10, 20, 20, 60, 20, 1)
+	c.Assert(err, qt.IsNil)
+	_, err = sqlite.ReadResultTx(1)
+	c.Assert(err, qt.Equals, ErrResultTxNotInDB)
+
+	// the transactions can only be stored once the proof is generated
+	resultTx := &types.ResultTx{
+		Nonce:     3,
+		GasLimit:  100000,
+		GasTipCap: big.NewInt(1),
+		GasFeeCap: big.NewInt(10),
+		Hashes:    []types.ByteArray{[]byte("hash1")},
+		SentBlock: 25,
+	}
+	err = sqlite.StoreResultTx(1, resultTx)
+	c.Assert(errors.Is(err, ErrProofNotInDB), qt.IsTrue)
+	c.Assert(sqlite.StoreProofID(1, "1", "prover1"), qt.IsNil)
+	err = sqlite.StoreResultTx(1, resultTx)
+	c.Assert(errors.Is(err, ErrProofNotInDB), qt.IsTrue)
+
+	c.Assert(sqlite.StoreProof(1, testZKProof("3", "1")), qt.IsNil)
+	c.Assert(sqlite.StoreResultTx(1, resultTx), qt.IsNil)
+	resultTx.Hashes = append(resultTx.Hashes, []byte("hash2"))
+	c.Assert(sqlite.StoreResultTx(1, resultTx), qt.IsNil)
+	resultTx2, err := sqlite.ReadResultTx(1)
+	c.Assert(err, qt.IsNil)
+	c.Assert(resultTx2.Nonce, qt.Equals, uint64(3))
+	c.Assert(resultTx2.GasLimit, qt.Equals, uint64(100000))
+	c.Assert(resultTx2.GasPrice, qt.IsNil)
+	c.Assert(resultTx2.GasTipCap.String(), qt.Equals, "1")
+	c.Assert(resultTx2.GasFeeCap.String(), qt.Equals, "10")
+	c.Assert(resultTx2.Hashes, qt.DeepEquals, resultTx.Hashes)
+	c.Assert(resultTx2.SentBlock, qt.Equals, uint64(25))
+
+	// a reverted transaction is removed, counting the failed attempts
+	attempts, err := sqlite.StoreResultTxError(1, fmt.Errorf("reverted"))
+	c.Assert(err, qt.IsNil)
+	c.Assert(attempts, qt.Equals, 1)
+	_, err = sqlite.ReadResultTx(1)
+	c.Assert(err, qt.Equals, ErrResultTxNotInDB)
+	attempts, err = sqlite.StoreResultTxError(1, fmt.Errorf("reverted again"))
+	c.Assert(err, qt.IsNil)
+	c.Assert(attempts, qt.Equals, 2)
+	attempts, lastErr, err := sqlite.ReadResultTxError(1)
+	c.Assert(err, qt.IsNil)
+	c.Assert(attempts, qt.Equals, 2)
+	c.Assert(lastErr, qt.Equals, "reverted again")
+
+	_, err = sqlite.StoreResultTxError(2, fmt.Errorf("reverted"))
+	c.Assert(errors.Is(err, ErrProofNotInDB), qt.IsTrue)
+}
+
+func TestResults(t *testing.T) {
+	c := qt.New(t)
+
+	db, err := sql.Open("sqlite3", filepath.Join(c.TempDir(), "testdb.sqlite3"))
+	c.Assert(err, qt.IsNil)
+
+	sqlite := NewSQLite(db)
+
+	err = sqlite.Migrate()
+	c.Assert(err, qt.IsNil)
+
+	result := &types.ProcessResult{
+		ProcessID:    1,
+		Publisher:    []byte("publisher"),
+		ReceiptsRoot: arbo.BigIntToBytes(32, big.NewInt(1234)),
+		Result:       7,
+		NVotes:       10,
+		EthBlockNum:  30,
+	}
+	err = sqlite.StoreResult(result)
+	c.Assert(errors.Is(err, ErrProcessNotInDB), qt.IsTrue)
+	err = sqlite.CloseProcess(1, []byte("caller"), true, 40)
+	c.Assert(errors.Is(err, ErrProcessNotInDB), qt.IsTrue)
+
+	for id := uint64(1); id <= 3; id++ {
+		err = sqlite.StoreProcess(id, []byte("censusRoot"), 100, 10, 20, 20, 60, 20, 1)
+		c.Assert(err, qt.IsNil)
+	}
+	_, err = sqlite.ReadResult(1)
+	c.Assert(err, qt.Equals, ErrResultNotInDB)
+
+	// process 1 result matches the proof generated by the node
+	err = sqlite.StoreProof(1, testZKProof("3", "1", "1", "1234", "10", "7", "0"))
+	c.Assert(err, qt.IsNil)
+	err = sqlite.StoreResult(result)
+	c.Assert(err, qt.IsNil)
+	c.Assert(result.Mismatch, qt.IsFalse)
+	result2, err := sqlite.ReadResult(1)
+	c.Assert(err, qt.IsNil)
+	c.Assert(result2.Publisher, qt.DeepEquals, result.Publisher)
+	c.Assert(result2.ReceiptsRoot, qt.DeepEquals, result.ReceiptsRoot)
+	c.Assert(result2.Result, qt.Equals, uint64(7))
+	c.Assert(result2.NVotes, qt.Equals, uint64(10))
+	c.Assert(result2.EthBlockNum, qt.Equals, uint64(30))
+	c.Assert(result2.Mismatch, qt.IsFalse)
+	status, err := sqlite.GetProcessStatus(1)
+	c.Assert(err, qt.IsNil)
+	c.Assert(status, qt.Equals, types.ProcessStatusResultsPublished)
+
+	// process 2 result differs from the proof generated by the node
+	result.ProcessID = 2
+	err = sqlite.StoreProof(2, testZKProof("3", "2", "1", "1234", "10", "6", "0"))
+	c.Assert(err, qt.IsNil)
+	err = sqlite.StoreResult(result)
+	c.Assert(err, qt.IsNil)
+	c.Assert(result.Mismatch, qt.IsTrue)
+	result2, err = sqlite.ReadResult(2)
+	c.Assert(err, qt.IsNil)
+	c.Assert(result2.Mismatch, qt.IsTrue)
+
+	// process 3 result is checked once the node generates its proof
+	result.ProcessID = 3
+	err = sqlite.StoreResult(result)
+	c.Assert(err, qt.IsNil)
+	c.Assert(result.Mismatch, qt.IsFalse)
+	err = sqlite.StoreProof(3, testZKProof("3", "3", "1", "1234", "9", "7", "0"))
+	c.Assert(err, qt.IsNil)
+	result2, err = sqlite.ReadResult(3)
+	c.Assert(err, qt.IsNil)
+	c.Assert(result2.Mismatch, qt.IsTrue)
+
+	err = sqlite.CloseProcess(1, []byte("caller"), true, 40)
+	c.Assert(err, qt.IsNil)
+	err = sqlite.CloseProcess(2, []byte("caller"), false, 40)
+	c.Assert(err, qt.IsNil)
+	status, err = sqlite.GetProcessStatus(1)
+	c.Assert(err, qt.IsNil)
+	c.Assert(status, qt.Equals, types.ProcessStatusClosedSuccess)
+	status, err = sqlite.GetProcessStatus(2)
+	c.Assert(err, qt.IsNil)
+	c.Assert(status, qt.Equals, types.ProcessStatusClosedFail)
+
+	// a result stored after the closure does not change the status
+	result.ProcessID = 1
+	err = sqlite.StoreResult(result)
+	c.Assert(err, qt.IsNil)
+	status, err = sqlite.GetProcessStatus(1)
+	c.Assert(err, qt.IsNil)
+	c.Assert(status, qt.Equals, types.ProcessStatusClosedSuccess)
+}
+
+func TestBlocks(t *testing.T) {
+	c := qt.New(t)
+
+	db, err := sql.Open("sqlite3", filepath.Join(c.TempDir(), "testdb.sqlite3"))
+	c.Assert(err, qt.IsNil)
+
+	sqlite := NewSQLite(db)
+
+	err = sqlite.Migrate()
+	c.Assert(err, qt.IsNil)
+
+	blocks, err := sqlite.ReadLastBlocks(10)
+	c.Assert(err, qt.IsNil)
+	c.Assert(len(blocks), qt.Equals, 0)
+
+	for i := uint64(1); i <= 5; i++ {
+		err = sqlite.StoreBlockHash(i, []byte("hash"+strconv.Itoa(int(i))))
+		c.Assert(err, qt.IsNil)
+	}
+	// store again a block number, replacing its hash
+	err = sqlite.StoreBlockHash(5, []byte("hash5b"))
+	c.Assert(err, qt.IsNil)
+
+	blocks, err = sqlite.ReadLastBlocks(2)
+	c.Assert(err, qt.IsNil)
+	c.Assert(blocks, qt.DeepEquals, []types.EthBlock{
+		{Number: 5, Hash: []byte("hash5b")},
+		{Number: 4, Hash: []byte("hash4")},
+	})
+
+	err = sqlite.DeleteBlocksBefore(3)
+	c.Assert(err, qt.IsNil)
+	blocks, err = sqlite.ReadLastBlocks(10)
+	c.Assert(err, qt.IsNil)
+	c.Assert(len(blocks), qt.Equals, 3)
+	c.Assert(blocks[2].Number, qt.Equals, uint64(3))
+}
+
+func TestRevertToBlock(t *testing.T) {
+	c := qt.New(t)
+
+	db, err := sql.Open("sqlite3", filepath.Join(c.TempDir(), "testdb.sqlite3"))
+	c.Assert(err, qt.IsNil)
+
+	sqlite := NewSQLite(db)
+
+	err = sqlite.Migrate()
+	c.Assert(err, qt.IsNil)
+	err = sqlite.InitMeta(3, 30)
+	c.Assert(err, qt.IsNil)
+
+	// processes created at blocks 10 and 20, with resPubStartBlock 15 and 25
+	err = sqlite.StoreProcess(1, []byte("censusRoot"), 100, 10, 15, 20, 60, 20, 1)
+	c.Assert(err, qt.IsNil)
+	err = sqlite.StoreProcess(2, []byte("censusRoot"), 100, 20, 25, 20, 60, 20, 1)
+	c.Assert(err, qt.IsNil)
+	c.Assert(sqlite.StoreVotePackage(2, testVotePackage(1)), qt.IsNil)
+	c.Assert(sqlite.ReplaceVotePackage(2, testVotePackage(1)), qt.IsNil)
+	c.Assert(sqlite.StoreProofID(2, "1", "prover1"), qt.IsNil)
+	err = sqlite.FrozeProcessesByCurrentBlockNum(30)
+	c.Assert(err, qt.IsNil)
+	for _, blockNum := range []uint64{10, 20, 30} {
+		err = sqlite.StoreBlockHash(blockNum, []byte("hash"))
+		c.Assert(err, qt.IsNil)
+	}
+
+	err = sqlite.RevertToBlock(12)
+	c.Assert(err, qt.IsNil)
+
+	// process 2 is removed, together with its votes, vote history and proof
+	processes, err := sqlite.ReadProcesses()
+	c.Assert(err, qt.IsNil)
+	c.Assert(len(processes), qt.Equals, 1)
+	c.Assert(processes[0].ID, qt.Equals, uint64(1))
+	votes, err := sqlite.ReadVotePackagesByProcessID(2)
+	c.Assert(err, qt.IsNil)
+	c.Assert(len(votes), qt.Equals, 0)
+	history, err := sqlite.ReadVoteHistory(2, 1)
+	c.Assert(err, qt.IsNil)
+	c.Assert(len(history), qt.Equals, 0)
+	_, err = sqlite.ReadProofID(2)
+	c.Assert(err, qt.Equals, ErrProofNotInDB)
+
+	// process 1 is not frozen anymore, as block 15 has not been reached
+	c.Assert(processes[0].Status, qt.Equals, types.ProcessStatusOn)
+
+	blocks, err := sqlite.ReadLastBlocks(10)
+	c.Assert(err, qt.IsNil)
+	c.Assert(len(blocks), qt.Equals, 1)
+	c.Assert(blocks[0].Number, qt.Equals, uint64(10))
+	lastSyncBlockNum, err := sqlite.GetLastSyncBlockNum()
+	c.Assert(err, qt.IsNil)
+	c.Assert(lastSyncBlockNum, qt.Equals, uint64(12))
+}
+
+func TestRevertToBlockProofs(t *testing.T) {
+	c := qt.New(t)
+
+	db, err := sql.Open("sqlite3", filepath.Join(c.TempDir(), "testdb.sqlite3"))
+	c.Assert(err, qt.IsNil)
+
+	sqlite := NewSQLite(db)
+
+	err = sqlite.Migrate()
+	c.Assert(err, qt.IsNil)
+	err = sqlite.InitMeta(3, 30)
+	c.Assert(err, qt.IsNil)
+
+	// processes created at block 10 with resPubStartBlock 20, in each of
+	// the statuses reached after being frozen, and a process with
+	// resPubStartBlock 15
+	statuses := []types.ProcessStatus{types.ProcessStatusFrozen,
+		types.ProcessStatusProofGenerating, types.ProcessStatusProofGenerated,
+		types.ProcessStatusProofFailed, types.ProcessStatusProofExpired,
+		types.ProcessStatusPublishFailed}
+	for i, status := range statuses {
+		id := uint64(i + 1)
+		err = sqlite.StoreProcess(id, []byte("censusRoot"), 100, 10, 20, 20, 60, 20, 1)
+		c.Assert(err, qt.IsNil)
+		c.Assert(sqlite.StoreProofID(id, "1", "prover1"), qt.IsNil)
+		c.Assert(sqlite.UpdateProcessStatus(id, status), qt.IsNil)
+	}
+	err = sqlite.StoreProcess(7, []byte("censusRoot"), 100, 10, 15, 20, 60, 20, 1)
+	c.Assert(err, qt.IsNil)
+	c.Assert(sqlite.StoreProof(7, testZKProof("3", "7")), qt.IsNil)
+	c.Assert(sqlite.UpdateProcessStatus(7, types.ProcessStatusProofGenerated), qt.IsNil)
+
+	err = sqlite.RevertToBlock(18)
+	c.Assert(err, qt.IsNil)
+
+	// the processes whose freezing block has been reverted accept votes
+	// again, and their proofs, built over a not final vote set, are deleted
+	for i := range statuses {
+		id := uint64(i + 1)
+		process, err := sqlite.ReadProcessByID(id)
+		c.Assert(err, qt.IsNil)
+		c.Assert(process.Status, qt.Equals, types.ProcessStatusOn)
+		_, err = sqlite.ReadProofID(id)
+		c.Assert(err, qt.Equals, ErrProofNotInDB)
+	}
+	process, err := sqlite.ReadProcessByID(7)
+	c.Assert(err, qt.IsNil)
+	c.Assert(process.Status, qt.Equals, types.ProcessStatusProofGenerated)
+	_, err = sqlite.ReadProof(7)
+	c.Assert(err, qt.IsNil)
+}
+
+func TestRevertToBlockResults(t *testing.T) {
+	c := qt.New(t)
+
+	db, err := sql.Open("sqlite3", filepath.Join(c.TempDir(), "testdb.sqlite3"))
+	c.Assert(err, qt.IsNil)
+
+	sqlite := NewSQLite(db)
+
+	err = sqlite.Migrate()
+	c.Assert(err, qt.IsNil)
+	err = sqlite.InitMeta(3, 50)
+	c.Assert(err, qt.IsNil)
+
+	for id := uint64(1); id <= 4; id++ {
+		err = sqlite.StoreProcess(id, []byte("censusRoot"), 100, 10, 15, 20, 60, 20, 1)
+		c.Assert(err, qt.IsNil)
+	}
+	err = sqlite.UpdateProcessStatus(4, types.ProcessStatusQuarantined)
+	c.Assert(err, qt.IsNil)
+	err = sqlite.FrozeProcessesByCurrentBlockNum(20)
+	c.Assert(err, qt.IsNil)
+	// process 1: result published at block 20, closed at block 40
+	// process 2: proof generated, result published and closed at block 40
+	// process 3: result published at block 30
+	// process 4: quarantined, result published and closed at block 40
+	result := &types.ProcessResult{ProcessID: 1, Publisher: []byte("publisher"),
+		ReceiptsRoot: []byte("root"), Result: 7, NVotes: 10, EthBlockNum: 20}
+	c.Assert(sqlite.StoreResult(result), qt.IsNil)
+	c.Assert(sqlite.CloseProcess(1, []byte("caller"), true, 40), qt.IsNil)
+	c.Assert(sqlite.StoreProof(2, testZKProof("3", "2")), qt.IsNil)
+	result.ProcessID, result.EthBlockNum = 2, 40
+	c.Assert(sqlite.StoreResult(result), qt.IsNil)
+	c.Assert(sqlite.CloseProcess(2, []byte("caller"), false, 40), qt.IsNil)
+	result.ProcessID, result.EthBlockNum = 3, 30
+	c.Assert(sqlite.StoreResult(result), qt.IsNil)
+	result.ProcessID, result.EthBlockNum = 4, 40
+	c.Assert(sqlite.StoreResult(result), qt.IsNil)
+	c.Assert(sqlite.CloseProcess(4, []byte("caller"), true, 40), qt.IsNil)
+
+	err = sqlite.RevertToBlock(35)
+	c.Assert(err, qt.IsNil)
+
+	// the quarantined process is kept quarantined once its closure is reverted
+	expected := map[uint64]types.ProcessStatus{
+		1: types.ProcessStatusResultsPublished,
+		2: types.ProcessStatusProofGenerated,
+		3: types.ProcessStatusResultsPublished,
+		4: types.ProcessStatusQuarantined,
+	}
+	for id, expectedStatus := range expected {
+		status, err := sqlite.GetProcessStatus(id)
+		c.Assert(err, qt.IsNil)
+		c.Assert(status, qt.Equals, expectedStatus, qt.Commentf("process %d", id))
+	}
+	_, err = sqlite.ReadResult(2)
+	c.Assert(err, qt.Equals, ErrResultNotInDB)
+
+	err = sqlite.RevertToBlock(25)
+	c.Assert(err, qt.IsNil)
+	status, err := sqlite.GetProcessStatus(3)
+	c.Assert(err, qt.IsNil)
+	c.Assert(status, qt.Equals, types.ProcessStatusFrozen)
+}
+
+func TestEvents(t *testing.T) {
+	c := qt.New(t)
+
+	db, err := sql.Open("sqlite3", filepath.Join(c.TempDir(), "testdb.sqlite3"))
+	c.Assert(err, qt.IsNil)
+
+	sqlite := NewSQLite(db)
+
+	err = sqlite.Migrate()
+	c.Assert(err, qt.IsNil)
+	err = sqlite.InitMeta(3, 30)
+	c.Assert(err, qt.IsNil)
+
+	topic := make([]byte, topicLen)
+	topic[0] = 1
+	event := types.EthEvent{
+		BlockNum:  20,
+		BlockHash: []byte("blockhash"),
+		TxHash:    []byte("txhash"),
+		LogIndex:  1,
+		Address:   []byte("address"),
+		Topics:    [][]byte{topic, topic},
+		Data:      []byte("data"),
+	}
+	c.Assert(sqlite.StoreEvent(event), qt.IsNil)
+	event2 := event
+	event2.BlockNum, event2.LogIndex, event2.Topics, event2.Data = 10, 3, nil, nil
+	c.Assert(sqlite.StoreEvent(event2), qt.IsNil)
+	event3 := event
+	event3.LogIndex = 0
+	c.Assert(sqlite.StoreEvent(event3), qt.IsNil)
+	// storing an event again replaces it
+	c.Assert(sqlite.StoreEvent(event3), qt.IsNil)
+
+	event3.Topics = [][]byte{[]byte("short")}
+	err = sqlite.StoreEvent(event3)
+	c.Assert(err, qt.ErrorMatches, `StoreEvent error: invalid topic length \(5\)`)
+
+	events, err := sqlite.ReadEvents()
+	c.Assert(err, qt.IsNil)
+	c.Assert(len(events), qt.Equals, 3)
+	c.Assert(events[0].BlockNum, qt.Equals, uint64(10))
+	c.Assert(len(events[0].Topics), qt.Equals, 0)
+	c.Assert(events[0].Data, qt.DeepEquals, []byte{})
+	c.Assert(events[1].LogIndex, qt.Equals, uint64(0))
+	c.Assert(events[2], qt.DeepEquals, event)
+
+	err = sqlite.RevertToBlock(15)
+	c.Assert(err, qt.IsNil)
+	events, err = sqlite.ReadEvents()
+	c.Assert(err, qt.IsNil)
+	c.Assert(len(events), qt.Equals, 1)
+	c.Assert(events[0].BlockNum, qt.Equals, uint64(10))
+}
+
+func TestRebuildProcesses(t *testing.T) {
+	c := qt.New(t)
+
+	db, err := sql.Open("sqlite3", filepath.Join(c.TempDir(), "testdb.sqlite3"))
+	c.Assert(err, qt.IsNil)
+
+	sqlite := NewSQLite(db)
+
+	err = sqlite.Migrate()
+	c.Assert(err, qt.IsNil)
+
+	// process 1: closed; 2: result published; 3: proof generated; 4:
+	// proof being generated; 5: not frozen yet
+	for id := uint64(1); id <= 5; id++ {
+		resPubStartBlock := uint64(15)
+		if id == 5 {
+			resPubStartBlock = 25
+		}
+		err = sqlite.StoreProcess(id, []byte("censusRoot"), 100, 10,
+			resPubStartBlock, 20, 60, 20, 1)
+		c.Assert(err, qt.IsNil)
+	}
+	c.Assert(sqlite.StoreVotePackage(1, testVotePackage(1)), qt.IsNil)
+	result := &types.ProcessResult{ProcessID: 1, Publisher: []byte("publisher"),
+		ReceiptsRoot: []byte("root"), Result: 7, NVotes: 10, EthBlockNum: 16}
+	c.Assert(sqlite.StoreResult(result), qt.IsNil)
+	c.Assert(sqlite.CloseProcess(1, []byte("caller"), false, 18), qt.IsNil)
+	result.ProcessID = 2
+	c.Assert(sqlite.StoreResult(result), qt.IsNil)
+	c.Assert(sqlite.StoreProof(3, testZKProof("3", "3")), qt.IsNil)
+	c.Assert(sqlite.StoreProofID(4, "1", "prover1"), qt.IsNil)
+
+	// the process data is replaced, keeping its votes
+	err = sqlite.StoreProcess(1, []byte("censusRoot2"), 200, 11, 15, 20, 60, 20, 1)
+	c.Assert(err, qt.ErrorMatches, "UNIQUE constraint failed: processes.networkID, processes.id")
+	err = sqlite.ReplaceProcess(1, []byte("censusRoot2"), 200, 11, 15, 20, 60, 20, 1)
+	c.Assert(err, qt.IsNil)
+	process, err := sqlite.ReadProcessByID(1)
+	c.Assert(err, qt.IsNil)
+	c.Assert(process.CensusRoot, qt.DeepEquals, []byte("censusRoot2"))
+	c.Assert(process.CensusSize, qt.Equals, uint64(200))
+	c.Assert(process.EthBlockNum, qt.Equals, uint64(11))
+	c.Assert(process.Status, qt.Equals, types.ProcessStatusClosedFail)
+	votes, err := sqlite.ReadVotePackagesByProcessID(1)
+	c.Assert(err, qt.IsNil)
+	c.Assert(len(votes), qt.Equals, 1)
+
+	err = sqlite.RebuildProcessStatuses(20)
+	c.Assert(err, qt.IsNil)
+	expected := []types.ProcessStatus{types.ProcessStatusClosedFail,
+		types.ProcessStatusResultsPublished, types.ProcessStatusProofGenerated,
+		types.ProcessStatusFrozen, types.ProcessStatusOn}
+	for i, expectedStatus := range expected {
+		status, err := sqlite.GetProcessStatus(uint64(i + 1))
+		c.Assert(err, qt.IsNil)
+		c.Assert(status, qt.Equals, expectedStatus, qt.Commentf("process %d", i+1))
+	}
+
+	err = sqlite.ClearProcessResults()
+	c.Assert(err, qt.IsNil)
+	_, err = sqlite.ReadResult(2)
+	c.Assert(err, qt.Equals, ErrResultNotInDB)
+	err = sqlite.RebuildProcessStatuses(20)
+	c.Assert(err, qt.IsNil)
+	status, err := sqlite.GetProcessStatus(1)
+	c.Assert(err, qt.IsNil)
+	c.Assert(status, qt.Equals, types.ProcessStatusFrozen)
+}
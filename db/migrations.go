@@ -0,0 +1,511 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// ErrSchemaTooNew is returned when the db has applied migrations that are not
+// known by the binary, which means that the db was used by a newer version of
+// the node
+var ErrSchemaTooNew = fmt.Errorf("db schema version is newer than the supported by this binary")
+
+// Migration is an up-migration of the db schema. Migrations are applied in
+// order, and each one is identified by its Version number.
+type Migration struct {
+	Version     int
+	Description string
+	Query       string
+}
+
+// migrations contains the list of migrations of the db schema. Versions start
+// at 1 and are consecutive, and once released a migration must not be
+// modified, new schema changes must be appended as new migrations.
+var migrations = []Migration{
+	{
+		Version:     1,
+		Description: "create processes, votepackages and meta tables",
+		Query: `
+	CREATE TABLE IF NOT EXISTS processes(
+		id INTEGER NOT NULL PRIMARY KEY UNIQUE,
+		status INTEGER NOT NULL,
+		censusRoot BLOB NOT NULL,
+		censusSize INTEGER NOT NULL,
+		ethBlockNum INTEGER NOT NULL,
+		resPubStartBlock INTEGER NOT NULL,
+		resPubWindow INTEGER NOT NULL,
+		minParticipation INTEGER NOT NULL,
+		minPositiveVotes INTEGER NOT NULL,
+		type INTEGER NOT NULL,
+		insertedDatetime DATETIME
+	);
+	CREATE TABLE IF NOT EXISTS votepackages(
+		indx INTEGER NOT NULL PRIMARY KEY UNIQUE,
+		publicKey BLOB NOT NULL UNIQUE,
+		weight BLOB NOT NULL,
+		merkleproof BLOB NOT NULL UNIQUE,
+		signature BLOB NOT NULL,
+		vote BLOB NOT NULL,
+		insertedDatetime DATETIME,
+		processID INTEGER NOT NULL,
+		FOREIGN KEY(processID) REFERENCES processes(id)
+	);
+	CREATE TABLE IF NOT EXISTS meta(
+		id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+		chainID INTEGER NOT NULL,
+		lastSyncBlockNum INTEGER NOT NULL,
+		lastUpdate DATETIME
+	);
+	`,
+	},
+	{
+		Version:     2,
+		Description: "create proofs table with the zkProofs of the processes",
+		Query: `
+	CREATE TABLE proofs(
+		processID INTEGER NOT NULL PRIMARY KEY UNIQUE,
+		proofID TEXT,
+		proof BLOB,
+		publicInputs BLOB,
+		attempts INTEGER NOT NULL DEFAULT 0,
+		lastError TEXT,
+		insertedDatetime DATETIME,
+		updatedDatetime DATETIME,
+		FOREIGN KEY(processID) REFERENCES processes(id)
+	);
+	`,
+	},
+	{
+		// a census member can vote in different processes that use the
+		// same census
+		Version:     3,
+		Description: "make votepackages unique by (processID, indx)",
+		Query: `
+	CREATE TABLE votepackages_new(
+		indx INTEGER NOT NULL,
+		publicKey BLOB NOT NULL,
+		weight BLOB NOT NULL,
+		merkleproof BLOB NOT NULL,
+		signature BLOB NOT NULL,
+		vote BLOB NOT NULL,
+		insertedDatetime DATETIME,
+		processID INTEGER NOT NULL,
+		PRIMARY KEY(processID, indx),
+		FOREIGN KEY(processID) REFERENCES processes(id)
+	);
+	INSERT INTO votepackages_new(indx, publicKey, weight, merkleproof,
+		signature, vote, insertedDatetime, processID)
+	SELECT indx, publicKey, weight, merkleproof, signature, vote,
+		insertedDatetime, processID FROM votepackages;
+	DROP TABLE votepackages;
+	ALTER TABLE votepackages_new RENAME TO votepackages;
+	`,
+	},
+	{
+		Version:     4,
+		Description: "create blocks table with the hashes of the synced blocks",
+		Query: `
+	CREATE TABLE blocks(
+		number INTEGER NOT NULL PRIMARY KEY UNIQUE,
+		hash BLOB NOT NULL,
+		insertedDatetime DATETIME
+	);
+	`,
+	},
+	{
+		Version:     5,
+		Description: "create results and closures tables of the processes",
+		Query: `
+	CREATE TABLE results(
+		processID INTEGER NOT NULL PRIMARY KEY UNIQUE,
+		publisher BLOB NOT NULL,
+		receiptsRoot BLOB NOT NULL,
+		result INTEGER NOT NULL,
+		nVotes INTEGER NOT NULL,
+		ethBlockNum INTEGER NOT NULL,
+		mismatch BOOLEAN NOT NULL DEFAULT 0,
+		insertedDatetime DATETIME,
+		FOREIGN KEY(processID) REFERENCES processes(id)
+	);
+	CREATE TABLE closures(
+		processID INTEGER NOT NULL PRIMARY KEY UNIQUE,
+		caller BLOB NOT NULL,
+		success BOOLEAN NOT NULL,
+		ethBlockNum INTEGER NOT NULL,
+		insertedDatetime DATETIME,
+		FOREIGN KEY(processID) REFERENCES processes(id)
+	);
+	`,
+	},
+	{
+		Version:     6,
+		Description: "create events table with the raw contract event logs",
+		Query: `
+	CREATE TABLE events(
+		blockNum INTEGER NOT NULL,
+		blockHash BLOB NOT NULL,
+		txHash BLOB NOT NULL,
+		logIndex INTEGER NOT NULL,
+		address BLOB NOT NULL,
+		topics BLOB NOT NULL,
+		data BLOB NOT NULL,
+		insertedDatetime DATETIME,
+		PRIMARY KEY(blockNum, logIndex)
+	);
+	`,
+	},
+	{
+		// the meta row becomes the network with id 1, which the first
+		// SQLite.WithNetwork of its chainID claims, and the data stored
+		// so far is assigned to it
+		Version:     7,
+		Description: "namespace the processes and synced blocks by network",
+		Query: `
+	CREATE TABLE networks(
+		id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+		chainID INTEGER NOT NULL,
+		contractAddr BLOB NOT NULL,
+		lastSyncBlockNum INTEGER,
+		lastUpdate DATETIME,
+		UNIQUE(chainID, contractAddr)
+	);
+	INSERT INTO networks(id, chainID, contractAddr, lastSyncBlockNum, lastUpdate)
+	SELECT id, chainID, x'', lastSyncBlockNum, lastUpdate FROM meta WHERE id = 1;
+	DROP TABLE meta;
+
+	ALTER TABLE processes RENAME TO processes_old;
+	ALTER TABLE votepackages RENAME TO votepackages_old;
+	ALTER TABLE proofs RENAME TO proofs_old;
+	ALTER TABLE results RENAME TO results_old;
+	ALTER TABLE closures RENAME TO closures_old;
+	ALTER TABLE blocks RENAME TO blocks_old;
+	ALTER TABLE events RENAME TO events_old;
+
+	CREATE TABLE processes(
+		networkID INTEGER NOT NULL,
+		id INTEGER NOT NULL,
+		status INTEGER NOT NULL,
+		censusRoot BLOB NOT NULL,
+		censusSize INTEGER NOT NULL,
+		ethBlockNum INTEGER NOT NULL,
+		resPubStartBlock INTEGER NOT NULL,
+		resPubWindow INTEGER NOT NULL,
+		minParticipation INTEGER NOT NULL,
+		minPositiveVotes INTEGER NOT NULL,
+		type INTEGER NOT NULL,
+		insertedDatetime DATETIME,
+		PRIMARY KEY(networkID, id)
+	);
+	CREATE TABLE votepackages(
+		networkID INTEGER NOT NULL,
+		processID INTEGER NOT NULL,
+		indx INTEGER NOT NULL,
+		publicKey BLOB NOT NULL,
+		weight BLOB NOT NULL,
+		merkleproof BLOB NOT NULL,
+		signature BLOB NOT NULL,
+		vote BLOB NOT NULL,
+		insertedDatetime DATETIME,
+		PRIMARY KEY(networkID, processID, indx),
+		FOREIGN KEY(networkID, processID) REFERENCES processes(networkID, id)
+	);
+	CREATE TABLE proofs(
+		networkID INTEGER NOT NULL,
+		processID INTEGER NOT NULL,
+		proofID TEXT,
+		proof BLOB,
+		publicInputs BLOB,
+		attempts INTEGER NOT NULL DEFAULT 0,
+		lastError TEXT,
+		insertedDatetime DATETIME,
+		updatedDatetime DATETIME,
+		PRIMARY KEY(networkID, processID),
+		FOREIGN KEY(networkID, processID) REFERENCES processes(networkID, id)
+	);
+	CREATE TABLE results(
+		networkID INTEGER NOT NULL,
+		processID INTEGER NOT NULL,
+		publisher BLOB NOT NULL,
+		receiptsRoot BLOB NOT NULL,
+		result INTEGER NOT NULL,
+		nVotes INTEGER NOT NULL,
+		ethBlockNum INTEGER NOT NULL,
+		mismatch BOOLEAN NOT NULL DEFAULT 0,
+		insertedDatetime DATETIME,
+		PRIMARY KEY(networkID, processID),
+		FOREIGN KEY(networkID, processID) REFERENCES processes(networkID, id)
+	);
+	CREATE TABLE closures(
+		networkID INTEGER NOT NULL,
+		processID INTEGER NOT NULL,
+		caller BLOB NOT NULL,
+		success BOOLEAN NOT NULL,
+		ethBlockNum INTEGER NOT NULL,
+		insertedDatetime DATETIME,
+		PRIMARY KEY(networkID, processID),
+		FOREIGN KEY(networkID, processID) REFERENCES processes(networkID, id)
+	);
+	CREATE TABLE blocks(
+		networkID INTEGER NOT NULL,
+		number INTEGER NOT NULL,
+		hash BLOB NOT NULL,
+		insertedDatetime DATETIME,
+		PRIMARY KEY(networkID, number)
+	);
+	CREATE TABLE events(
+		networkID INTEGER NOT NULL,
+		blockNum INTEGER NOT NULL,
+		blockHash BLOB NOT NULL,
+		txHash BLOB NOT NULL,
+		logIndex INTEGER NOT NULL,
+		address BLOB NOT NULL,
+		topics BLOB NOT NULL,
+		data BLOB NOT NULL,
+		insertedDatetime DATETIME,
+		PRIMARY KEY(networkID, blockNum, logIndex)
+	);
+
+	INSERT INTO processes(networkID, id, status, censusRoot, censusSize,
+		ethBlockNum, resPubStartBlock, resPubWindow, minParticipation,
+		minPositiveVotes, type, insertedDatetime)
+	SELECT 1, id, status, censusRoot, censusSize, ethBlockNum,
+		resPubStartBlock, resPubWindow, minParticipation, minPositiveVotes,
+		type, insertedDatetime FROM processes_old;
+	INSERT INTO votepackages(networkID, processID, indx, publicKey, weight,
+		merkleproof, signature, vote, insertedDatetime)
+	SELECT 1, processID, indx, publicKey, weight, merkleproof, signature,
+		vote, insertedDatetime FROM votepackages_old;
+	INSERT INTO proofs(networkID, processID, proofID, proof, publicInputs,
+		attempts, lastError, insertedDatetime, updatedDatetime)
+	SELECT 1, processID, proofID, proof, publicInputs, attempts, lastError,
+		insertedDatetime, updatedDatetime FROM proofs_old;
+	INSERT INTO results(networkID, processID, publisher, receiptsRoot, result,
+		nVotes, ethBlockNum, mismatch, insertedDatetime)
+	SELECT 1, processID, publisher, receiptsRoot, result, nVotes, ethBlockNum,
+		mismatch, insertedDatetime FROM results_old;
+	INSERT INTO closures(networkID, processID, caller, success, ethBlockNum,
+		insertedDatetime)
+	SELECT 1, processID, caller, success, ethBlockNum, insertedDatetime
+		FROM closures_old;
+	INSERT INTO blocks(networkID, number, hash, insertedDatetime)
+	SELECT 1, number, hash, insertedDatetime FROM blocks_old;
+	INSERT INTO events(networkID, blockNum, blockHash, txHash, logIndex,
+		address, topics, data, insertedDatetime)
+	SELECT 1, blockNum, blockHash, txHash, logIndex, address, topics, data,
+		insertedDatetime FROM events_old;
+
+	DROP TABLE votepackages_old;
+	DROP TABLE proofs_old;
+	DROP TABLE results_old;
+	DROP TABLE closures_old;
+	DROP TABLE processes_old;
+	DROP TABLE blocks_old;
+	DROP TABLE events_old;
+	`,
+	},
+	{
+		// the jobs sent to a prover that is no longer available are
+		// reassigned to another prover of the pool
+		Version:     8,
+		Description: "store the prover of the proof generation jobs",
+		Query: `
+	ALTER TABLE proofs ADD COLUMN prover TEXT;
+	`,
+	},
+	{
+		// the votes replaced by a newer vote of the same census index
+		// are kept for audit
+		Version:     9,
+		Description: "create votehistory table with the replaced votes",
+		Query: `
+	CREATE TABLE votehistory(
+		id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+		networkID INTEGER NOT NULL,
+		processID INTEGER NOT NULL,
+		indx INTEGER NOT NULL,
+		publicKey BLOB NOT NULL,
+		weight BLOB NOT NULL,
+		merkleproof BLOB NOT NULL,
+		signature BLOB NOT NULL,
+		vote BLOB NOT NULL,
+		insertedDatetime DATETIME,
+		replacedDatetime DATETIME,
+		FOREIGN KEY(networkID, processID) REFERENCES processes(networkID, id)
+	);
+	CREATE INDEX votehistory_vote ON votehistory(networkID, processID, indx);
+	`,
+	},
+	{
+		// the transactions that publish the result of a process are
+		// resumed after a restart, and the reverted ones are retried
+		Version:     10,
+		Description: "store the publishResult transactions of the proofs",
+		Query: `
+	ALTER TABLE proofs ADD COLUMN resultTx BLOB;
+	ALTER TABLE proofs ADD COLUMN resultTxAttempts INTEGER NOT NULL DEFAULT 0;
+	ALTER TABLE proofs ADD COLUMN resultTxError TEXT;
+	`,
+	},
+	{
+		// the status of a quarantined process is overwritten when it is
+		// closed, and the quarantine is restored if its closure is reverted
+		Version:     11,
+		Description: "flag the quarantined processes",
+		Query: `
+	ALTER TABLE processes ADD COLUMN quarantined BOOLEAN NOT NULL DEFAULT FALSE;
+	UPDATE processes SET quarantined = TRUE WHERE status = 8;
+	`,
+	},
+}
+
+// LatestSchemaVersion returns the db schema version supported by the binary
+func LatestSchemaVersion() int {
+	return migrations[len(migrations)-1].Version
+}
+
+// Migrate applies the pending migrations to the db, each one in its own
+// transaction. Returns ErrSchemaTooNew if the db schema version is newer than
+// the latest known migration.
+func (r *SQLite) Migrate() error {
+	_, err := r.db.Exec("PRAGMA foreign_keys = ON;")
+	if err != nil {
+		return err
+	}
+
+	pending, err := r.PendingMigrations()
+	if err != nil {
+		return err
+	}
+	if err := r.initSchemaVersion(); err != nil {
+		return err
+	}
+	for _, m := range pending {
+		if err := r.applyMigration(m); err != nil {
+			return fmt.Errorf("migration %d (%s) error: %s", m.Version, m.Description, err)
+		}
+	}
+	return nil
+}
+
+// SchemaVersion returns the version of the last migration applied to the db
+func (r *SQLite) SchemaVersion() (int, error) {
+	exists, err := r.tableExists("schema_version")
+	if err != nil {
+		return 0, err
+	}
+	if !exists {
+		return r.legacySchemaVersion()
+	}
+	var version int
+	err = r.db.QueryRow("SELECT COALESCE(MAX(version), 0) FROM schema_version").
+		Scan(&version)
+	if err != nil {
+		return 0, err
+	}
+	return version, nil
+}
+
+// PendingMigrations returns the migrations that have not been applied yet to
+// the db, without applying them
+func (r *SQLite) PendingMigrations() ([]Migration, error) {
+	version, err := r.SchemaVersion()
+	if err != nil {
+		return nil, err
+	}
+	if version > LatestSchemaVersion() {
+		return nil, fmt.Errorf("%w (%d > %d)", ErrSchemaTooNew, version,
+			LatestSchemaVersion())
+	}
+	return migrations[version:], nil
+}
+
+// legacySchemaVersion returns the schema version of a db that does not have
+// the schema_version table. Such dbs were created by the previous Migrate,
+// which created the tables of the first migration.
+func (r *SQLite) legacySchemaVersion() (int, error) {
+	exists, err := r.tableExists("processes")
+	if err != nil {
+		return 0, err
+	}
+	if exists {
+		return 1, nil
+	}
+	return 0, nil
+}
+
+// initSchemaVersion creates the schema_version table if it does not exist yet,
+// storing on it the migrations already applied to a legacy db
+func (r *SQLite) initSchemaVersion() error {
+	exists, err := r.tableExists("schema_version")
+	if err != nil || exists {
+		return err
+	}
+	version, err := r.legacySchemaVersion()
+	if err != nil {
+		return err
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	_, err = tx.Exec(`
+	CREATE TABLE schema_version(
+		version INTEGER NOT NULL PRIMARY KEY UNIQUE,
+		description TEXT,
+		appliedDatetime DATETIME
+	);
+	`)
+	if err != nil {
+		return err
+	}
+	for _, m := range migrations[:version] {
+		if err := insertSchemaVersion(tx, m); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// applyMigration applies the given migration and stores its version in a
+// single transaction, so a failing migration does not leave the db in an
+// intermediate state
+func (r *SQLite) applyMigration(m Migration) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	if _, err := tx.Exec(m.Query); err != nil {
+		return err
+	}
+	if err := insertSchemaVersion(tx, m); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func insertSchemaVersion(tx *sql.Tx, m Migration) error {
+	_, err := tx.Exec(`
+	INSERT INTO schema_version(
+		version,
+		description,
+		appliedDatetime
+	) values(?, ?, CURRENT_TIMESTAMP)
+	`, m.Version, m.Description)
+	return err
+}
+
+func (r *SQLite) tableExists(name string) (bool, error) {
+	var count int
+	err := r.db.QueryRow(
+		"SELECT COUNT(*) FROM sqlite_master WHERE type='table' AND name=?", name).
+		Scan(&count)
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
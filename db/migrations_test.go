@@ -0,0 +1,300 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/aragon/zkmultisig-node/types"
+	qt "github.com/frankban/quicktest"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestMigrationsVersions(t *testing.T) {
+	c := qt.New(t)
+
+	for i, m := range migrations {
+		c.Assert(m.Version, qt.Equals, i+1)
+		c.Assert(m.Description, qt.Not(qt.Equals), "")
+	}
+	c.Assert(LatestSchemaVersion(), qt.Equals, len(migrations))
+}
+
+func TestMigrate(t *testing.T) {
+	c := qt.New(t)
+
+	db, err := sql.Open("sqlite3", filepath.Join(c.TempDir(), "testdb.sqlite3"))
+	c.Assert(err, qt.IsNil)
+
+	sqlite := NewSQLite(db)
+
+	version, err := sqlite.SchemaVersion()
+	c.Assert(err, qt.IsNil)
+	c.Assert(version, qt.Equals, 0)
+	pending, err := sqlite.PendingMigrations()
+	c.Assert(err, qt.IsNil)
+	c.Assert(len(pending), qt.Equals, len(migrations))
+
+	err = sqlite.Migrate()
+	c.Assert(err, qt.IsNil)
+	version, err = sqlite.SchemaVersion()
+	c.Assert(err, qt.IsNil)
+	c.Assert(version, qt.Equals, LatestSchemaVersion())
+	pending, err = sqlite.PendingMigrations()
+	c.Assert(err, qt.IsNil)
+	c.Assert(len(pending), qt.Equals, 0)
+
+	// migrating again is a no-op
+	err = sqlite.Migrate()
+	c.Assert(err, qt.IsNil)
+	var count int
+	err = db.QueryRow("SELECT COUNT(*) FROM schema_version").Scan(&count)
+	c.Assert(err, qt.IsNil)
+	c.Assert(count, qt.Equals, len(migrations))
+}
+
+// baselineSchema contains the tables created by the Migrate previous to the
+// schema versioning
+const baselineSchema = `
+	CREATE TABLE IF NOT EXISTS processes(
+		id INTEGER NOT NULL PRIMARY KEY UNIQUE,
+		status INTEGER NOT NULL,
+		censusRoot BLOB NOT NULL,
+		censusSize INTEGER NOT NULL,
+		ethBlockNum INTEGER NOT NULL,
+		resPubStartBlock INTEGER NOT NULL,
+		resPubWindow INTEGER NOT NULL,
+		minParticipation INTEGER NOT NULL,
+		minPositiveVotes INTEGER NOT NULL,
+		type INTEGER NOT NULL,
+		insertedDatetime DATETIME
+	);
+	CREATE TABLE IF NOT EXISTS votepackages(
+		indx INTEGER NOT NULL PRIMARY KEY UNIQUE,
+		publicKey BLOB NOT NULL UNIQUE,
+		weight BLOB NOT NULL,
+		merkleproof BLOB NOT NULL UNIQUE,
+		signature BLOB NOT NULL,
+		vote BLOB NOT NULL,
+		insertedDatetime DATETIME,
+		processID INTEGER NOT NULL,
+		FOREIGN KEY(processID) REFERENCES processes(id)
+	);
+	CREATE TABLE IF NOT EXISTS meta(
+		id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+		chainID INTEGER NOT NULL,
+		lastSyncBlockNum INTEGER NOT NULL,
+		lastUpdate DATETIME
+	);
+`
+
+func TestMigrateLegacyDB(t *testing.T) {
+	c := qt.New(t)
+
+	db, err := sql.Open("sqlite3", filepath.Join(c.TempDir(), "testdb.sqlite3"))
+	c.Assert(err, qt.IsNil)
+
+	sqlite := NewSQLite(db)
+
+	// db created before the schema versioning, containing votes
+	_, err = db.Exec(baselineSchema)
+	c.Assert(err, qt.IsNil)
+	version, err := sqlite.SchemaVersion()
+	c.Assert(err, qt.IsNil)
+	c.Assert(version, qt.Equals, 1)
+
+	_, err = db.Exec(`INSERT INTO meta(chainID, lastSyncBlockNum, lastUpdate)
+		values(5, 100, CURRENT_TIMESTAMP)`)
+	c.Assert(err, qt.IsNil)
+	for _, processID := range []uint64{1, 2} {
+		_, err = db.Exec(`INSERT INTO processes(id, status, censusRoot, censusSize,
+			ethBlockNum, resPubStartBlock, resPubWindow, minParticipation,
+			minPositiveVotes, type, insertedDatetime)
+			values(?, ?, ?, 100, 10, 20, 20, 60, 20, 1, CURRENT_TIMESTAMP)`,
+			processID, types.ProcessStatusOn, []byte("censusRoot"))
+		c.Assert(err, qt.IsNil)
+	}
+	var votesAdded []types.VotePackage
+	for i := 0; i < 5; i++ {
+		vote := testVotePackage(uint64(i))
+		c.Assert(storeLegacyVotePackage(db, 1, vote), qt.IsNil)
+		votesAdded = append(votesAdded, vote)
+	}
+	// with the legacy schema the index is unique across processes
+	err = storeLegacyVotePackage(db, 2, votesAdded[0])
+	c.Assert(err, qt.Not(qt.IsNil))
+
+	pending, err := sqlite.PendingMigrations()
+	c.Assert(err, qt.IsNil)
+	c.Assert(pending, qt.DeepEquals, migrations[1:])
+
+	err = sqlite.Migrate()
+	c.Assert(err, qt.IsNil)
+	version, err = sqlite.SchemaVersion()
+	c.Assert(err, qt.IsNil)
+	c.Assert(version, qt.Equals, LatestSchemaVersion())
+	var count int
+	err = db.QueryRow("SELECT COUNT(*) FROM schema_version").Scan(&count)
+	c.Assert(err, qt.IsNil)
+	c.Assert(count, qt.Equals, len(migrations))
+
+	// the data is kept after the migration, assigned to the first network
+	sqlite, err = sqlite.WithNetwork(5, []byte("contract address"))
+	c.Assert(err, qt.IsNil)
+	lastSyncBlockNum, err := sqlite.GetLastSyncBlockNum()
+	c.Assert(err, qt.IsNil)
+	c.Assert(lastSyncBlockNum, qt.Equals, uint64(100))
+	processes, err := sqlite.ReadProcesses()
+	c.Assert(err, qt.IsNil)
+	c.Assert(len(processes), qt.Equals, 2)
+	votes, err := sqlite.ReadVotePackagesByProcessID(1)
+	c.Assert(err, qt.IsNil)
+	c.Assert(len(votes), qt.Equals, len(votesAdded))
+	for i := range votes {
+		c.Assert(votes[i].CensusProof.Index, qt.Equals, votesAdded[i].CensusProof.Index)
+		c.Assert(votes[i].Signature, qt.DeepEquals, votesAdded[i].Signature)
+		c.Assert(votes[i].CensusProof.MerkleProof, qt.DeepEquals,
+			votesAdded[i].CensusProof.MerkleProof)
+	}
+
+	// and the index can now be used in another process
+	c.Assert(sqlite.StoreVotePackage(2, votesAdded[0]), qt.IsNil)
+}
+
+// storeLegacyVotePackage stores the given vote with the schema of the
+// migrations previous to the networks
+func storeLegacyVotePackage(db *sql.DB, processID uint64, vote types.VotePackage) error {
+	_, err := db.Exec(`INSERT INTO votepackages(indx, publicKey, weight,
+		merkleproof, signature, vote, processID) values(?, ?, ?, ?, ?, ?, ?)`,
+		vote.CensusProof.Index, vote.CensusProof.PublicKey,
+		vote.CensusProof.Weight.Bytes(), vote.CensusProof.MerkleProof,
+		vote.Signature[:], vote.Vote, processID)
+	return err
+}
+
+func TestMigrateNetworks(t *testing.T) {
+	c := qt.New(t)
+
+	db, err := sql.Open("sqlite3", filepath.Join(c.TempDir(), "testdb.sqlite3"))
+	c.Assert(err, qt.IsNil)
+
+	sqlite := NewSQLite(db)
+
+	// db synced by a node that only supported a single network
+	origMigrations := migrations
+	defer func() { migrations = origMigrations }()
+	migrations = migrations[:6]
+	c.Assert(sqlite.Migrate(), qt.IsNil)
+	migrations = origMigrations
+
+	_, err = db.Exec(`INSERT INTO meta(chainID, lastSyncBlockNum) values(5, 100)`)
+	c.Assert(err, qt.IsNil)
+	_, err = db.Exec(`INSERT INTO processes(id, status, censusRoot, censusSize,
+		ethBlockNum, resPubStartBlock, resPubWindow, minParticipation,
+		minPositiveVotes, type, insertedDatetime)
+		values(1, ?, ?, 100, 10, 200, 20, 60, 20, 1, CURRENT_TIMESTAMP)`,
+		types.ProcessStatusOn, []byte("censusRoot"))
+	c.Assert(err, qt.IsNil)
+	vote := testVotePackage(0)
+	c.Assert(storeLegacyVotePackage(db, 1, vote), qt.IsNil)
+	_, err = db.Exec(`INSERT INTO blocks(number, hash) values(100, ?)`, []byte("hash"))
+	c.Assert(err, qt.IsNil)
+
+	c.Assert(sqlite.Migrate(), qt.IsNil)
+	exists, err := sqlite.tableExists("meta")
+	c.Assert(err, qt.IsNil)
+	c.Assert(exists, qt.IsFalse)
+
+	// the first contract of the chainID claims the migrated data
+	addr := []byte("contract address 1")
+	sqlite1, err := sqlite.WithNetwork(5, addr)
+	c.Assert(err, qt.IsNil)
+	c.Assert(sqlite1.NetworkID(), qt.Equals, uint64(DefaultNetworkID))
+	lastSyncBlockNum, err := sqlite1.GetLastSyncBlockNum()
+	c.Assert(err, qt.IsNil)
+	c.Assert(lastSyncBlockNum, qt.Equals, uint64(100))
+	process, err := sqlite1.ReadProcessByID(1)
+	c.Assert(err, qt.IsNil)
+	c.Assert(process.ResPubStartBlock, qt.Equals, uint64(200))
+	votes, err := sqlite1.ReadVotePackagesByProcessID(1)
+	c.Assert(err, qt.IsNil)
+	c.Assert(len(votes), qt.Equals, 1)
+	c.Assert(votes[0].Signature, qt.DeepEquals, vote.Signature)
+	blocks, err := sqlite1.ReadLastBlocks(10)
+	c.Assert(err, qt.IsNil)
+	c.Assert(blocks, qt.DeepEquals, []types.EthBlock{{Number: 100, Hash: []byte("hash")}})
+
+	networks, err := sqlite.ReadNetworks()
+	c.Assert(err, qt.IsNil)
+	c.Assert(networks, qt.DeepEquals, []types.Network{
+		{ID: 1, ChainID: 5, ContractAddr: addr, LastSyncBlockNum: 100}})
+
+	// another contract of the chainID gets a new network
+	sqlite2, err := sqlite.WithNetwork(5, []byte("contract address 2"))
+	c.Assert(err, qt.IsNil)
+	c.Assert(sqlite2.NetworkID(), qt.Equals, uint64(2))
+	_, err = sqlite2.GetLastSyncBlockNum()
+	c.Assert(err, qt.Equals, ErrMetaNotInDB)
+	_, err = sqlite2.ReadProcessByID(1)
+	c.Assert(err, qt.ErrorMatches, "Process ID:1, does not exist in the db")
+}
+
+func TestMigrateNewerDB(t *testing.T) {
+	c := qt.New(t)
+
+	db, err := sql.Open("sqlite3", filepath.Join(c.TempDir(), "testdb.sqlite3"))
+	c.Assert(err, qt.IsNil)
+
+	sqlite := NewSQLite(db)
+
+	err = sqlite.Migrate()
+	c.Assert(err, qt.IsNil)
+
+	// db migrated by a newer binary
+	_, err = db.Exec("INSERT INTO schema_version(version, description) values(?, ?)",
+		LatestSchemaVersion()+1, "future migration")
+	c.Assert(err, qt.IsNil)
+
+	err = sqlite.Migrate()
+	c.Assert(errors.Is(err, ErrSchemaTooNew), qt.IsTrue)
+	_, err = sqlite.PendingMigrations()
+	c.Assert(errors.Is(err, ErrSchemaTooNew), qt.IsTrue)
+}
+
+func TestMigrateRollback(t *testing.T) {
+	c := qt.New(t)
+
+	db, err := sql.Open("sqlite3", filepath.Join(c.TempDir(), "testdb.sqlite3"))
+	c.Assert(err, qt.IsNil)
+
+	sqlite := NewSQLite(db)
+
+	err = sqlite.Migrate()
+	c.Assert(err, qt.IsNil)
+
+	// a migration that fails after a first successful statement
+	origMigrations := migrations
+	defer func() { migrations = origMigrations }()
+	migrations = append(migrations[:len(migrations):len(migrations)], Migration{
+		Version:     len(migrations) + 1,
+		Description: "failing migration",
+		Query: `
+		CREATE TABLE test(id INTEGER);
+		INSERT INTO unexistingtable(id) values(1);
+		`,
+	})
+
+	err = sqlite.Migrate()
+	c.Assert(err, qt.ErrorMatches,
+		fmt.Sprintf("migration %d \\(failing migration\\) error: .*", len(migrations)))
+
+	// the migration has been rolled back
+	version, err := sqlite.SchemaVersion()
+	c.Assert(err, qt.IsNil)
+	c.Assert(version, qt.Equals, len(origMigrations))
+	exists, err := sqlite.tableExists("test")
+	c.Assert(err, qt.IsNil)
+	c.Assert(exists, qt.IsFalse)
+}
@@ -0,0 +1,171 @@
+package eth
+
+import (
+	_ "embed" // used to embed the contract ABI
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/vocdoni/arbo"
+)
+
+// ContractABIVersion is the version of the zkMultisig contract ABI used to
+// decode the contract event logs and to call the contract methods, which is
+// shipped with the node in abi/zkmultisig.<version>.json. The v2 ABI adds the
+// processes and publishResult methods to the events of the v1 ABI, so the
+// events of the v1 contracts are decoded with it.
+const ContractABIVersion = "v2"
+
+// names of the zkMultisig contract events
+const (
+	eventProcessCreatedName  = "EventProcessCreated"
+	eventResultPublishedName = "EventResultPublished"
+	eventProcessClosedName   = "EventProcessClosed"
+)
+
+// names of the zkMultisig contract methods used by the node: the processes
+// view function returns the parameters of a process, and publishResult
+// publishes the result of a process together with its zkProof
+const (
+	methodProcessesName     = "processes"
+	methodPublishResultName = "publishResult"
+)
+
+//go:embed abi/zkmultisig.v2.json
+var contractABIJSON string
+
+// contractABI is the parsed zkMultisig contract ABI
+var contractABI = mustParseABI(contractABIJSON)
+
+func mustParseABI(s string) abi.ABI {
+	a, err := abi.JSON(strings.NewReader(s))
+	if err != nil {
+		panic(fmt.Errorf("can not parse the zkMultisig contract ABI: %s", err))
+	}
+	for _, name := range []string{eventProcessCreatedName, eventResultPublishedName,
+		eventProcessClosedName} {
+		if _, ok := a.Events[name]; !ok {
+			panic(fmt.Errorf("zkMultisig contract ABI does not contain %s", name))
+		}
+	}
+	for _, name := range []string{methodProcessesName, methodPublishResultName} {
+		if _, ok := a.Methods[name]; !ok {
+			panic(fmt.Errorf("zkMultisig contract ABI does not contain %s", name))
+		}
+	}
+	return a
+}
+
+// decodeEventLog decodes the arguments of the given event log, checking that
+// its first topic is the signature of the contract event with the given name
+func decodeEventLog(name string, eventLog types.Log) (*eventDecoder, error) {
+	event := contractABI.Events[name]
+	if len(eventLog.Topics) == 0 || eventLog.Topics[0] != event.ID {
+		return nil, fmt.Errorf("event log is not a %s event", name)
+	}
+	args := make(map[string]interface{})
+	if err := event.Inputs.UnpackIntoMap(args, eventLog.Data); err != nil {
+		return nil, err
+	}
+	var indexed abi.Arguments
+	for _, arg := range event.Inputs {
+		if arg.Indexed {
+			indexed = append(indexed, arg)
+		}
+	}
+	if err := abi.ParseTopicsIntoMap(args, indexed, eventLog.Topics[1:]); err != nil {
+		return nil, err
+	}
+	return &eventDecoder{args: args}, nil
+}
+
+// eventDecoder reads the typed arguments of a decoded event log. The first
+// error found is kept in err, and the following reads return zero values.
+type eventDecoder struct {
+	args map[string]interface{}
+	err  error
+}
+
+func (d *eventDecoder) arg(name string) interface{} {
+	if d.err != nil {
+		return nil
+	}
+	v, ok := d.args[name]
+	if !ok {
+		d.err = fmt.Errorf("event argument %s not found", name)
+		return nil
+	}
+	return v
+}
+
+func (d *eventDecoder) typeErr(name, typ string) {
+	if d.err == nil {
+		d.err = fmt.Errorf("event argument %s is not of type %s", name, typ)
+	}
+}
+
+func (d *eventDecoder) address(name string) common.Address {
+	v, ok := d.arg(name).(common.Address)
+	if !ok {
+		d.typeErr(name, "address")
+	}
+	return v
+}
+
+func (d *eventDecoder) bool(name string) bool {
+	v, ok := d.arg(name).(bool)
+	if !ok {
+		d.typeErr(name, "bool")
+	}
+	return v
+}
+
+func (d *eventDecoder) uint8(name string) uint8 {
+	v, ok := d.arg(name).(uint8)
+	if !ok {
+		d.typeErr(name, "uint8")
+	}
+	return v
+}
+
+// uint64 reads an uint64 argument, or an uint256 argument which value fits in
+// an uint64
+func (d *eventDecoder) uint64(name string) uint64 {
+	switch v := d.arg(name).(type) {
+	case uint64:
+		return v
+	case *big.Int:
+		if v.IsUint64() {
+			return v.Uint64()
+		}
+		if d.err == nil {
+			d.err = fmt.Errorf("event argument %s (%s) overflows uint64", name, v)
+		}
+	default:
+		d.typeErr(name, "uint64")
+	}
+	return 0
+}
+
+// bytes32 reads an uint256 argument as 32 big-endian bytes
+func (d *eventDecoder) bytes32(name string) [32]byte {
+	var b [32]byte
+	v, ok := d.arg(name).(*big.Int)
+	if !ok || v.Sign() < 0 {
+		d.typeErr(name, "uint256")
+		return b
+	}
+	v.FillBytes(b[:])
+	return b
+}
+
+// bytes32LE reads an uint256 argument as 32 little-endian bytes
+func (d *eventDecoder) bytes32LE(name string) [32]byte {
+	var b [32]byte
+	be := d.bytes32(name)
+	copy(b[:], arbo.SwapEndianness(be[:]))
+	return b
+}
@@ -0,0 +1,42 @@
+package eth
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// testEventLog returns an event log of the contract event with the given name
+// and data
+func testEventLog(name string, data []byte) types.Log {
+	return types.Log{
+		Topics: []common.Hash{contractABI.Events[name].ID},
+		Data:   data,
+	}
+}
+
+// newProcessEventLog returns a newProcess event log for the given processID
+// and resPubStartBlock
+func newProcessEventLog(processID, resPubStartBlock uint64) types.Log {
+	l, err := packEventLog(eventProcessCreatedName,
+		common.Address{}, new(big.Int).SetUint64(processID), big.NewInt(0),
+		big.NewInt(1), uint64(100), resPubStartBlock, uint64(10), uint8(20),
+		uint8(60), uint8(1))
+	if err != nil {
+		panic(err)
+	}
+	return l
+}
+
+// resultPublishedEventLog returns a resultPublished event log for the given
+// processID, result and nVotes
+func resultPublishedEventLog(processID, result, nVotes uint64) types.Log {
+	l, err := packEventLog(eventResultPublishedName,
+		common.Address{}, new(big.Int).SetUint64(processID), big.NewInt(1234),
+		result, nVotes)
+	if err != nil {
+		panic(err)
+	}
+	return l
+}
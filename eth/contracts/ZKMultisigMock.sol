@@ -0,0 +1,90 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+pragma solidity ^0.8.0;
+
+// ZKMultisigMock is a minimal mock of the zkMultisig contract used by the eth
+// package tests. Each method emits the corresponding zkMultisig contract event
+// with the given values, so the tests can drive the node through the same
+// event logs that the real contract emits. The created processes are stored
+// and returned by the processes view function, and setCensusSize allows to
+// make them differ from the emitted events. The zkProof (a, b, c) of
+// publishResult is not verified.
+//
+// ZKMultisigMock.abi and ZKMultisigMock.bin are compiled from this file with
+// solc 0.8.21, targeting the london EVM supported by the simulated backend,
+// and the Go bindings in zkmultisigmock.go are generated from them with abigen
+// (see contracts.go).
+contract ZKMultisigMock {
+    struct Process {
+        address creator;
+        uint256 transactionHash;
+        uint256 censusRoot;
+        uint64 censusSize;
+        uint64 resPubStartBlock;
+        uint64 resPubWindow;
+        uint8 minParticipation;
+        uint8 minPositiveVotes;
+        uint8 typ;
+    }
+
+    mapping(uint256 => Process) public processes;
+
+    event EventProcessCreated(
+        address creator,
+        uint256 id,
+        uint256 transactionHash,
+        uint256 censusRoot,
+        uint64 censusSize,
+        uint64 resPubStartBlock,
+        uint64 resPubWindow,
+        uint8 minParticipation,
+        uint8 minPositiveVotes,
+        uint8 typ
+    );
+    event EventResultPublished(
+        address publisher,
+        uint256 id,
+        uint256 receiptsRoot,
+        uint64 result,
+        uint64 nVotes
+    );
+    event EventProcessClosed(address caller, uint256 id, bool success);
+
+    function createProcess(
+        uint256 id,
+        uint256 transactionHash,
+        uint256 censusRoot,
+        uint64 censusSize,
+        uint64 resPubStartBlock,
+        uint64 resPubWindow,
+        uint8 minParticipation,
+        uint8 minPositiveVotes,
+        uint8 typ
+    ) external {
+        processes[id] = Process(msg.sender, transactionHash, censusRoot,
+            censusSize, resPubStartBlock, resPubWindow, minParticipation,
+            minPositiveVotes, typ);
+        emit EventProcessCreated(msg.sender, id, transactionHash, censusRoot,
+            censusSize, resPubStartBlock, resPubWindow, minParticipation,
+            minPositiveVotes, typ);
+    }
+
+    function publishResult(
+        uint256 id,
+        uint256[2] calldata a,
+        uint256[2][2] calldata b,
+        uint256[2] calldata c,
+        uint256 receiptsRoot,
+        uint64 result,
+        uint64 nVotes
+    ) external {
+        emit EventResultPublished(msg.sender, id, receiptsRoot, result, nVotes);
+    }
+
+    function closeProcess(uint256 id, bool success) external {
+        emit EventProcessClosed(msg.sender, id, success);
+    }
+
+    function setCensusSize(uint256 id, uint64 censusSize) external {
+        processes[id].censusSize = censusSize;
+    }
+}
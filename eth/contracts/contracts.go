@@ -0,0 +1,9 @@
+// Package contracts contains the Go bindings of the zkMultisig contract, used
+// by the eth package to call its view functions and to publish the results,
+// and of the mock of the zkMultisig contract used to test the eth package
+// against a simulated blockchain.
+package contracts
+
+//go:generate abigen --abi ../abi/zkmultisig.v2.json --pkg contracts --type ZKMultisig --out zkmultisig.go
+//go:generate solc --evm-version london --abi --bin --overwrite -o . ZKMultisigMock.sol
+//go:generate abigen --abi ZKMultisigMock.abi --bin ZKMultisigMock.bin --pkg contracts --type ZKMultisigMock --out zkmultisigmock.go
@@ -1,14 +1,22 @@
 package eth
 
 import (
+	"bytes"
 	"context"
-	"encoding/binary"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"math/big"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/aragon/zkmultisig-node/db"
+	"github.com/aragon/zkmultisig-node/eth/contracts"
+	ztypes "github.com/aragon/zkmultisig-node/types"
 	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/ethclient"
@@ -17,16 +25,53 @@ import (
 )
 
 const (
-	// eventNewProcessLen defines the length of an event log of newProcess
-	eventNewProcessLen = 320 // = 32*10
-	// eventResultPublishedLen defines the length of an event log of
-	// resultPublished
-	eventResultPublishedLen = 160 // = 32*5
-	// eventProcessClosedLen defines the length of an event log of
-	// processClosed
-	eventProcessClosedLen = 96 // = 32*3
+	// blocksToKeep is the number of synced block hashes kept in the db,
+	// which determines the maximum depth of the chain reorgs that can be
+	// handled
+	blocksToKeep = 256
+	// DefaultBlockRange is the default maximum number of blocks queried in
+	// each FilterLogs request
+	DefaultBlockRange = 2000
+	// blockRangeGrowAfter is the number of consecutive completed ranges
+	// after which a reduced block range is doubled
+	blockRangeGrowAfter = 10
+	// DefaultPollInterval is the default interval between the requests of
+	// the head block in polling mode
+	DefaultPollInterval = 5 * time.Second
+	// minRetryDelay and maxRetryDelay bound the exponential backoff used
+	// to restart the synchronization after a failure
+	minRetryDelay = time.Second
+	maxRetryDelay = time.Minute
 )
 
+// rangeLimitErrs contains substrings of the errors returned by the web3
+// providers when the FilterLogs block range or its number of results exceed
+// their limits
+var rangeLimitErrs = []string{
+	"block range",
+	"range is too large",
+	"range too large",
+	"more than",
+	"too many",
+	"limit exceeded",
+	"exceed maximum",
+	"response size",
+}
+
+// ErrReorgTooDeep is returned when none of the stored blocks is in the
+// canonical chain, so the common ancestor with the synced chain can not be
+// found
+var ErrReorgTooDeep = fmt.Errorf("chain reorg is deeper than the stored blocks")
+
+// ErrNoContractCode is returned by CheckContract when there is no contract
+// deployed at the contract address
+var ErrNoContractCode = fmt.Errorf("no contract code at the zkMultisig contract address")
+
+// errContractCall is returned when a call to the view functions of the
+// contract fails, which stops the synchronization of the events so they are
+// processed again once the web3 provider answers
+var errContractCall = fmt.Errorf("contract call error")
+
 // ClientInterf defines the interface that synchronizes with the Ethereum
 // blockchain to obtain the processes data
 type ClientInterf interface {
@@ -36,20 +81,71 @@ type ClientInterf interface {
 	Start(fromBlock uint64) error
 }
 
+// Backend defines the methods of the Ethereum node client used by the Client
+// to read the blockchain and call the contract view functions, which are
+// implemented by *ethclient.Client
+type Backend interface {
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+	FilterLogs(ctx context.Context, q ethereum.FilterQuery) ([]types.Log, error)
+	SubscribeNewHead(ctx context.Context, ch chan<- *types.Header) (
+		ethereum.Subscription, error)
+	CodeAt(ctx context.Context, contract common.Address, blockNumber *big.Int) (
+		[]byte, error)
+	CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) (
+		[]byte, error)
+}
+
 // Client implements the ClientInterf that reads data from the Ethereum
 // blockchain
 type Client struct {
-	client       *ethclient.Client
-	db           *db.SQLite
-	contractAddr common.Address
-	ChainID      uint64
+	backend       Backend
+	db            *db.SQLite
+	contractAddr  common.Address
+	confirmations uint64
+	// maxBlockRange is the maximum number of blocks queried in each
+	// FilterLogs request, and blockRange the current one, which is reduced
+	// when the provider rejects a request
+	maxBlockRange uint64
+	blockRange    uint64
+	// rangesOK is the number of consecutive completed ranges since the
+	// last block range change
+	rangesOK int
+	// polling is set when the web3 provider is accessed through http,
+	// which does not support subscriptions, so the head block is
+	// requested every pollInterval
+	polling      bool
+	pollInterval time.Duration
+	// dial opens a new connection with the web3 provider, which replaces
+	// the backend when the synchronization is restarted after a failure
+	dial          func() (Backend, error)
+	minRetryDelay time.Duration
+	maxRetryDelay time.Duration
+	// replaying is set when the events are replayed from the db, see
+	// Replay
+	replaying bool
+	statusMu  sync.RWMutex
+	status    SyncStatus
+	ChainID   uint64
 }
 
 // Options is used to pass the parameters to load a new Client
 type Options struct {
-	EthURL       string
+	EthURL string
+	// SQLite is scoped by New to the network of the ChainID of the web3
+	// provider and the ContractAddr, see db.SQLite.WithNetwork
 	SQLite       *db.SQLite
 	ContractAddr common.Address
+	// Confirmations is the number of blocks on top of a block needed to
+	// process its events. Processes are frozen at the current block
+	// number without waiting for confirmations.
+	Confirmations uint64
+	// BlockRange is the maximum number of blocks queried in each FilterLogs
+	// request. If not set, DefaultBlockRange is used.
+	BlockRange uint64
+	// PollInterval is the interval between the requests of the head block
+	// when the EthURL is an http(s) url, which does not support
+	// subscriptions. If not set, DefaultPollInterval is used.
+	PollInterval time.Duration
 }
 
 // New loads a new Client
@@ -66,155 +162,431 @@ func New(opts Options) (*Client, error) {
 		return nil, err
 	}
 
+	opts.SQLite, err = opts.SQLite.WithNetwork(chainID.Uint64(), opts.ContractAddr.Bytes())
+	if err != nil {
+		return nil, err
+	}
+
+	c := newClient(client, chainID.Uint64(), opts)
+	c.dial = func() (Backend, error) {
+		return ethclient.Dial(opts.EthURL)
+	}
+	return c, nil
+}
+
+func newClient(backend Backend, chainID uint64, opts Options) *Client {
+	blockRange := opts.BlockRange
+	if blockRange == 0 {
+		blockRange = DefaultBlockRange
+	}
+	pollInterval := opts.PollInterval
+	if pollInterval == 0 {
+		pollInterval = DefaultPollInterval
+	}
+	polling := isHTTPURL(opts.EthURL)
 	return &Client{
-		client:       client,
-		db:           opts.SQLite,
-		contractAddr: opts.ContractAddr,
-		ChainID:      chainID.Uint64(),
-	}, nil
+		backend:       backend,
+		db:            opts.SQLite,
+		contractAddr:  opts.ContractAddr,
+		confirmations: opts.Confirmations,
+		maxBlockRange: blockRange,
+		blockRange:    blockRange,
+		polling:       polling,
+		pollInterval:  pollInterval,
+		minRetryDelay: minRetryDelay,
+		maxRetryDelay: maxRetryDelay,
+		status:        SyncStatus{Polling: polling},
+		ChainID:       chainID,
+	}
 }
 
-// Sync synchronizes the blocknums and events since the last synced block to
-// the current one, and then live syncs the new ones
-func (c *Client) Sync() error {
-	// TODO WARNING:
-	// Probably the logic will need to be changed to support reorgs of
-	// chain. Maybe wait to sync blocks until some new blocks after the
-	// block have been created.
-
-	// get lastSyncBlockNum from db
-	lastSyncBlockNum, err := c.db.GetLastSyncBlockNum()
+// SQLite returns the db of the Client, which is scoped to its network
+func (c *Client) SQLite() *db.SQLite {
+	return c.db
+}
+
+// CheckContract checks that there is a contract deployed at the contract
+// address, returning ErrNoContractCode otherwise
+func (c *Client) CheckContract(ctx context.Context) error {
+	code, err := c.backend.CodeAt(ctx, c.contractAddr, nil)
 	if err != nil {
 		return err
 	}
+	if len(code) == 0 {
+		return fmt.Errorf("%w: %s", ErrNoContractCode, c.contractAddr.Hex())
+	}
+	return nil
+}
 
-	// start live sync events (before synchronizing the history)
-	go c.syncEventsLive() // nolint:errcheck
+// isHTTPURL returns true if the given web3 provider url uses the http or https
+// scheme
+func isHTTPURL(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	return u.Scheme == "http" || u.Scheme == "https"
+}
+
+// Sync synchronizes the blocknums and events since the last synced block to
+// the current one, and then live syncs the new ones. When the synchronization
+// fails (eg. the web3 provider connection is lost), it is restarted with an
+// exponential backoff, reconnecting to the web3 provider and backfilling the
+// blocks missed meanwhile. Sync only returns when the given ctx is done.
+func (c *Client) Sync(ctx context.Context) error {
+	if c.polling {
+		log.Infof("web3 provider accessed through http, polling new blocks every %s",
+			c.pollInterval)
+	}
+	delay := c.minRetryDelay
+	for {
+		start := time.Now()
+		err := c.sync(ctx)
+		if ctx.Err() != nil {
+			c.setConnected(false)
+			return ctx.Err()
+		}
+		c.setSyncError(err, true)
+		// a sync that has been running for a while is not considered
+		// part of the previous failures
+		if time.Since(start) > c.maxRetryDelay {
+			delay = c.minRetryDelay
+		}
+		log.Warnf("eth sync error: %s, restarting in %s", err, delay)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+		delay *= 2
+		if delay > c.maxRetryDelay {
+			delay = c.maxRetryDelay
+		}
+		c.addReconnection()
+		if err := c.reconnect(); err != nil {
+			c.setSyncError(err, true)
+			log.Warnf("eth reconnection error: %s", err)
+		}
+	}
+}
 
+// sync synchronizes the blocks since the last synced block until the current
+// one, and then the new blocks until an error happens
+func (c *Client) sync(ctx context.Context) error {
 	// sync from lastSyncBlockNum until the current blocknum
-	err = c.syncHistory(lastSyncBlockNum)
-	if err != nil {
+	if err := c.syncHistory(); err != nil {
 		return err
 	}
 
 	// live sync blocks
-	err = c.syncBlocksLive()
-	if err != nil {
-		return err
+	if c.polling {
+		return c.syncBlocksPolling(ctx)
 	}
-	return nil
+	return c.syncBlocksLive(ctx)
 }
 
-// syncBlocksLive synchronizes live the ethereum blocks
-func (c *Client) syncBlocksLive() error {
-	// sync to new blocks
-	headers := make(chan *types.Header)
-	sub, err := c.client.SubscribeNewHead(context.Background(), headers)
+// reconnect replaces the backend by a new connection with the web3 provider,
+// closing the previous one
+func (c *Client) reconnect() error {
+	if c.dial == nil {
+		return nil
+	}
+	backend, err := c.dial()
 	if err != nil {
-		log.Error(err)
 		return err
 	}
+	if closer, ok := c.backend.(interface{ Close() }); ok {
+		closer.Close()
+	}
+	c.backend = backend
+	return nil
+}
+
+// syncBlocksPolling synchronizes the ethereum blocks by requesting the head
+// block every pollInterval. The http connections are stateless, so the
+// errors are stored in the status and the polling continues until the given
+// ctx is done.
+func (c *Client) syncBlocksPolling(ctx context.Context) error {
+	ticker := time.NewTicker(c.pollInterval)
+	defer ticker.Stop()
 
+	var lastHead common.Hash
 	for {
 		select {
-		case err := <-sub.Err():
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+		head, err := c.pollHead(lastHead)
+		if err != nil {
 			log.Error(err)
-		case header := <-headers:
-			log.Debugf("new eth block received: %d", header.Number.Uint64())
-			// store in db lastSyncBlockNum
-			err = c.db.UpdateLastSyncBlockNum(header.Number.Uint64())
-			if err != nil {
-				log.Error(err)
-			}
+			c.setSyncError(err, true)
+			continue
 		}
+		c.setConnected(true)
+		lastHead = head
 	}
 }
 
-// syncEventsLive synchronizes live from the zkmultisig contract events
-func (c *Client) syncEventsLive() error {
-	query := ethereum.FilterQuery{
-		Addresses: []common.Address{c.contractAddr},
+// pollHead requests the head block, and if it is different than the given
+// lastHead, synchronizes it. Returns the hash of the synchronized head block.
+func (c *Client) pollHead(lastHead common.Hash) (common.Hash, error) {
+	header, err := c.backend.HeaderByNumber(context.Background(), nil)
+	if err != nil {
+		return lastHead, err
+	}
+	if header.Hash() == lastHead {
+		return lastHead, nil
 	}
+	log.Debugf("new eth block polled: %d", header.Number.Uint64())
+	if err := c.syncHead(header); err != nil {
+		return lastHead, err
+	}
+	return header.Hash(), nil
+}
 
-	logs := make(chan types.Log)
-	sub, err := c.client.SubscribeFilterLogs(context.Background(), query, logs)
+// syncBlocksLive synchronizes live the ethereum blocks, until the
+// subscription fails or the given ctx is done
+func (c *Client) syncBlocksLive(ctx context.Context) error {
+	// sync to new blocks
+	headers := make(chan *types.Header)
+	sub, err := c.backend.SubscribeNewHead(ctx, headers)
 	if err != nil {
-		log.Error(err)
-		return err
+		return fmt.Errorf("new heads subscription error: %w", err)
 	}
+	defer sub.Unsubscribe()
+	c.setConnected(true)
 
 	for {
 		select {
+		case <-ctx.Done():
+			return ctx.Err()
 		case err := <-sub.Err():
-			log.Error(err)
-		case vLog := <-logs:
-			err = c.processEventLog(vLog)
-			if err != nil {
+			if err == nil {
+				err = fmt.Errorf("closed")
+			}
+			return fmt.Errorf("new heads subscription error: %w", err)
+		case header := <-headers:
+			log.Debugf("new eth block received: %d", header.Number.Uint64())
+			// the blocks not synced due to the error are synced with
+			// the next head block
+			if err := c.syncHead(header); err != nil {
 				log.Error(err)
+				c.setSyncError(err, false)
 			}
 		}
 	}
 }
 
 // syncHistory synchronizes from the zkmultisig contract the events & blockNums
-// from the given block to the current block height.
-func (c *Client) syncHistory(startBlock uint64) error {
-	header, err := c.client.HeaderByNumber(context.Background(), nil)
+// from the last synced block to the current block height.
+func (c *Client) syncHistory() error {
+	header, err := c.backend.HeaderByNumber(context.Background(), nil)
 	if err != nil {
 		log.Error(err)
 		return err
 	}
-	currBlockNum := header.Number
-	log.Debugf("[SyncHistory] blocks from: %d, to: %d", startBlock, currBlockNum)
-	err = c.syncEventsHistory(big.NewInt(int64(startBlock)), currBlockNum)
+	log.Debugf("[SyncHistory] blocks until: %d", header.Number)
+	return c.syncHead(header)
+}
+
+// syncHead synchronizes the db with the chain of the given head block. First
+// it checks that the last synced block is still in the canonical chain,
+// reverting the db otherwise, and then it processes the events of the
+// confirmed blocks that have not been synced yet.
+func (c *Client) syncHead(head *types.Header) error {
+	err := c.handleReorg()
+	if err != nil {
+		return err
+	}
+
+	lastSyncBlockNum, err := c.db.GetLastSyncBlockNum()
 	if err != nil {
-		log.Error(err)
 		return err
 	}
+	headBlockNum := head.Number.Uint64()
+	if headBlockNum >= c.confirmations &&
+		headBlockNum-c.confirmations > lastSyncBlockNum {
+		err = c.syncEventsByRanges(lastSyncBlockNum+1, headBlockNum-c.confirmations)
+		if err != nil {
+			return err
+		}
+	}
 
 	// update the processes which their ResPubStartBlock has been reached
-	// (and that they were still in status ProcessStatusOn
-	err = c.db.FrozeProcessesByCurrentBlockNum(currBlockNum.Uint64())
+	// (and that they were still in status ProcessStatusOn). This is done
+	// with the head block, as the votes must not be accepted after the
+	// ResPubStartBlock, and it is reverted by handleReorg when the synced
+	// blocks are orphaned.
+	err = c.db.FrozeProcessesByCurrentBlockNum(headBlockNum)
+	if err != nil {
+		return err
+	}
+
+	lastSyncBlockNum, err = c.db.GetLastSyncBlockNum()
 	if err != nil {
-		log.Error(err)
 		return err
 	}
-	// TODO take into account chain reorgs: for currBlockNum, set to
-	// ProcessStatusOn the processes with resPubStartBlock>currBlockNum
+	c.setHead(headBlockNum, lastSyncBlockNum)
+	return nil
+}
+
+// syncEventsByRanges synchronizes the events between the given fromBlock and
+// toBlock (both included) in ranges of at most blockRange blocks, persisting
+// the lastSyncBlockNum after each range, so a failed sync is resumed from the
+// last completed range. When the provider rejects a range because of its
+// limits, the range size is halved and the range retried, and after
+// blockRangeGrowAfter completed ranges the size is doubled, up to
+// maxBlockRange.
+func (c *Client) syncEventsByRanges(fromBlock, toBlock uint64) error {
+	for fromBlock <= toBlock {
+		endBlock := toBlock
+		if toBlock-fromBlock >= c.blockRange {
+			endBlock = fromBlock + c.blockRange - 1
+		}
+		err := c.syncEvents(fromBlock, endBlock)
+		if err != nil {
+			if !isRangeLimitErr(err) || endBlock == fromBlock {
+				return err
+			}
+			c.blockRange = (endBlock - fromBlock + 1) / 2 //nolint:gomnd
+			c.rangesOK = 0
+			log.Warnf("logs query of blocks %d-%d rejected (%s), reducing the"+
+				" block range to %d", fromBlock, endBlock, err, c.blockRange)
+			continue
+		}
+		fromBlock = endBlock + 1
+		c.rangesOK++
+		if c.blockRange < c.maxBlockRange && c.rangesOK >= blockRangeGrowAfter {
+			c.rangesOK = 0
+			c.blockRange *= 2
+			if c.blockRange > c.maxBlockRange {
+				c.blockRange = c.maxBlockRange
+			}
+		}
+	}
 	return nil
 }
 
-// syncEventsHistory synchronizes from the zkmultisig contract log events
-// between the given startBlock and endBlock
-func (c *Client) syncEventsHistory(startBlock, endBlock *big.Int) error {
+// isRangeLimitErr returns true if the given error has been returned by the
+// web3 provider because of the limits of a FilterLogs request
+func isRangeLimitErr(err error) bool {
+	msg := strings.ToLower(err.Error())
+	for _, s := range rangeLimitErrs {
+		if strings.Contains(msg, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// syncEvents processes the zkmultisig contract log events between the given
+// fromBlock and toBlock (both included), storing the hashes of the blocks
+// with events and of the toBlock, which becomes the lastSyncBlockNum
+func (c *Client) syncEvents(fromBlock, toBlock uint64) error {
+	log.Debugf("[SyncEvents] blocks from: %d, to: %d", fromBlock, toBlock)
 	query := ethereum.FilterQuery{
-		FromBlock: startBlock,
-		ToBlock:   endBlock,
+		FromBlock: new(big.Int).SetUint64(fromBlock),
+		ToBlock:   new(big.Int).SetUint64(toBlock),
 		Addresses: []common.Address{
 			c.contractAddr,
 		},
 	}
-	logs, err := c.client.FilterLogs(context.Background(), query)
+	logs, err := c.backend.FilterLogs(context.Background(), query)
 	if err != nil {
-		log.Error(err)
 		return err
 	}
 	for i := 0; i < len(logs); i++ {
+		err = c.db.StoreEvent(eventFromLog(logs[i]))
+		if err != nil {
+			return err
+		}
 		err = c.processEventLog(logs[i])
+		if errors.Is(err, errContractCall) {
+			return err
+		}
 		if err != nil {
 			log.Error(err)
 		}
+		err = c.db.StoreBlockHash(logs[i].BlockNumber, logs[i].BlockHash.Bytes())
+		if err != nil {
+			return err
+		}
 	}
 
-	return nil
+	header, err := c.backend.HeaderByNumber(context.Background(),
+		new(big.Int).SetUint64(toBlock))
+	if err != nil {
+		return err
+	}
+	err = c.db.StoreBlockHash(toBlock, header.Hash().Bytes())
+	if err != nil {
+		return err
+	}
+	if toBlock > blocksToKeep {
+		err = c.db.DeleteBlocksBefore(toBlock - blocksToKeep)
+		if err != nil {
+			return err
+		}
+	}
+	return c.db.UpdateLastSyncBlockNum(toBlock)
 }
 
+// handleReorg checks that the last synced block is in the canonical chain.
+// If it is not, it looks for the last stored block that is in the canonical
+// chain (the common ancestor), and reverts the db to that block.
+func (c *Client) handleReorg() error {
+	blocks, err := c.db.ReadLastBlocks(blocksToKeep)
+	if err != nil {
+		return err
+	}
+	if len(blocks) == 0 {
+		return nil
+	}
+	for i := 0; i < len(blocks); i++ {
+		header, err := c.backend.HeaderByNumber(context.Background(),
+			new(big.Int).SetUint64(blocks[i].Number))
+		if errors.Is(err, ethereum.NotFound) {
+			// the block does not exist in the canonical chain, as
+			// the new chain is shorter
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(header.Hash().Bytes(), blocks[i].Hash) {
+			continue
+		}
+		if i == 0 {
+			// the last synced block is in the canonical chain
+			return nil
+		}
+		log.Warnf("chain reorg detected, reverting the synced blocks after %d (%x)",
+			blocks[i].Number, blocks[i].Hash)
+		return c.db.RevertToBlock(blocks[i].Number)
+	}
+	return fmt.Errorf("%w, last synced block: %d", ErrReorgTooDeep, blocks[0].Number)
+}
+
+// processEventLog decodes the given contract event log using the contract
+// ABI, and updates the db with its data. Event logs that are not part of the
+// contract ABI are ignored.
 func (c *Client) processEventLog(eventLog types.Log) error {
-	// depending on eventLog.Data length, parse the different types of
-	// event logs
-	switch l := len(eventLog.Data); l {
-	case eventNewProcessLen:
-		e, err := parseEventNewProcess(eventLog.Data)
+	if len(eventLog.Topics) == 0 {
+		log.Debugf("ignoring anonymous event log (blocknum: %d, tx: %s)",
+			eventLog.BlockNumber, eventLog.TxHash)
+		return nil
+	}
+	event, err := contractABI.EventByID(eventLog.Topics[0])
+	if err != nil {
+		log.Debugf("ignoring unknown event log (blocknum: %d, tx: %s), topic: %s",
+			eventLog.BlockNumber, eventLog.TxHash, eventLog.Topics[0])
+		return nil
+	}
+
+	switch event.Name {
+	case eventProcessCreatedName:
+		e, err := parseEventNewProcess(eventLog)
 		if err != nil {
 			return fmt.Errorf("blocknum: %d, error parsing event log"+
 				" (newProcess): %x, err: %s",
@@ -222,16 +594,37 @@ func (c *Client) processEventLog(eventLog types.Log) error {
 		}
 		log.Debugf("Event: (blocknum: %d) %s",
 			eventLog.BlockNumber, e)
-		// store the process in the db
-		err = c.db.StoreProcess(e.ProcessID, e.CensusRoot[:], e.CensusSize,
+		// the replayed events were already checked when synced
+		var mismatches []string
+		if !c.replaying {
+			mismatches, err = c.checkProcess(e)
+			if err != nil {
+				return err
+			}
+		}
+		// store the process in the db, which when replaying the events
+		// may already exist
+		storeProcess := c.db.StoreProcess
+		if c.replaying {
+			storeProcess = c.db.ReplaceProcess
+		}
+		err = storeProcess(e.ProcessID, e.CensusRoot[:], e.CensusSize,
 			eventLog.BlockNumber, e.ResPubStartBlock, e.ResPubWindow,
 			e.MinParticipation, e.MinPositiveVotes, e.Type)
 		if err != nil {
 			return fmt.Errorf("error storing new process: %x, err: %s",
 				eventLog.Data, err)
 		}
-	case eventResultPublishedLen:
-		e, err := parseEventResultPublished(eventLog.Data)
+		if len(mismatches) > 0 {
+			log.Warnf("[ProcessID=%d] quarantined, the %s of the event do not match"+
+				" the contract", e.ProcessID, strings.Join(mismatches, ", "))
+			err = c.db.UpdateProcessStatus(e.ProcessID, ztypes.ProcessStatusQuarantined)
+			if err != nil {
+				return fmt.Errorf("error quarantining process: %s", err)
+			}
+		}
+	case eventResultPublishedName:
+		e, err := parseEventResultPublished(eventLog)
 		if err != nil {
 			return fmt.Errorf("blocknum: %d, error parsing event log"+
 				" (resultPublished): %x, err: %s",
@@ -239,8 +632,29 @@ func (c *Client) processEventLog(eventLog types.Log) error {
 		}
 		log.Debugf("Event: (blocknum: %d) %s",
 			eventLog.BlockNumber, e)
-	case eventProcessClosedLen:
-		e, err := parseEventProcessClosed(eventLog.Data)
+		result := &ztypes.ProcessResult{
+			ProcessID:    e.ProcessID,
+			Publisher:    e.Publisher.Bytes(),
+			ReceiptsRoot: e.ReceiptsRoot[:],
+			Result:       e.Result,
+			NVotes:       e.NVotes,
+			EthBlockNum:  eventLog.BlockNumber,
+		}
+		err = c.db.StoreResult(result)
+		if errors.Is(err, db.ErrProcessNotInDB) {
+			log.Warnf("ignoring result of ProcessID=%d, which is not synced", e.ProcessID)
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("error storing result: %x, err: %s",
+				eventLog.Data, err)
+		}
+		if result.Mismatch {
+			log.Warnf("[ProcessID=%d] result published by %s does not match the"+
+				" one computed by this node", e.ProcessID, e.Publisher)
+		}
+	case eventProcessClosedName:
+		e, err := parseEventProcessClosed(eventLog)
 		if err != nil {
 			return fmt.Errorf("blocknum: %d, error parsing event log"+
 				" (processClosed): %x, err: %s",
@@ -248,14 +662,64 @@ func (c *Client) processEventLog(eventLog types.Log) error {
 		}
 		log.Debugf("Event: (blocknum: %d) %s",
 			eventLog.BlockNumber, e)
+		err = c.db.CloseProcess(e.ProcessID, e.Caller.Bytes(), e.Success,
+			eventLog.BlockNumber)
+		if errors.Is(err, db.ErrProcessNotInDB) {
+			log.Warnf("ignoring closure of ProcessID=%d, which is not synced", e.ProcessID)
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("error closing process: %x, err: %s",
+				eventLog.Data, err)
+		}
 	default:
-		fmt.Printf("LOG in block %d:\n %x \n", eventLog.BlockNumber, eventLog.Data)
-		return fmt.Errorf("unrecognized event log with length %d", l)
+		log.Debugf("ignoring event log %s (blocknum: %d, tx: %s)",
+			event.Name, eventLog.BlockNumber, eventLog.TxHash)
 	}
 
 	return nil
 }
 
+// checkProcess calls the processes view function of the contract, returning
+// the names of the process parameters of the given event that do not match
+// the ones returned by the contract. The process parameters can not be
+// modified once created, so the contract is called at the latest block,
+// which does not need an archive node.
+func (c *Client) checkProcess(e *eventNewProcess) ([]string, error) {
+	caller, err := contracts.NewZKMultisigCaller(c.contractAddr, c.backend)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", errContractCall, err)
+	}
+	p, err := caller.Processes(&bind.CallOpts{Context: context.Background()},
+		new(big.Int).SetUint64(e.ProcessID))
+	if err != nil {
+		return nil, fmt.Errorf("%w: processes(%d): %s", errContractCall, e.ProcessID, err)
+	}
+
+	var censusRoot [32]byte
+	if p.CensusRoot.Sign() >= 0 && p.CensusRoot.BitLen() <= 256 {
+		p.CensusRoot.FillBytes(censusRoot[:])
+	}
+	var mismatches []string
+	for _, f := range []struct {
+		name  string
+		equal bool
+	}{
+		{"censusRoot", bytes.Equal(arbo.SwapEndianness(censusRoot[:]), e.CensusRoot[:])},
+		{"censusSize", p.CensusSize == e.CensusSize},
+		{"resPubStartBlock", p.ResPubStartBlock == e.ResPubStartBlock},
+		{"resPubWindow", p.ResPubWindow == e.ResPubWindow},
+		{"minParticipation", p.MinParticipation == e.MinParticipation},
+		{"minPositiveVotes", p.MinPositiveVotes == e.MinPositiveVotes},
+		{"typ", p.Typ == e.Type},
+	} {
+		if !f.equal {
+			mismatches = append(mismatches, f.name)
+		}
+	}
+	return mismatches, nil
+}
+
 // eventNewProcess contains the data received from an event log of newProcess
 type eventNewProcess struct {
 	Creator          common.Address
@@ -281,45 +745,27 @@ func (e *eventNewProcess) String() string {
 		e.MinPositiveVotes)
 }
 
-func parseEventNewProcess(d []byte) (*eventNewProcess, error) {
-	if len(d) != eventNewProcessLen {
-		return nil, fmt.Errorf("newProcess event log should be of length %d, current: %d",
-			eventNewProcessLen, len(d))
+func parseEventNewProcess(eventLog types.Log) (*eventNewProcess, error) {
+	d, err := decodeEventLog(eventProcessCreatedName, eventLog)
+	if err != nil {
+		return nil, err
+	}
+	e := eventNewProcess{
+		Creator:   d.address("creator"),
+		ProcessID: d.uint64("id"),
+		TxHash:    d.bytes32("transactionHash"),
+		// note that here Ethereum returns the CensusRoot in big endian
+		CensusRoot:       d.bytes32LE("censusRoot"),
+		CensusSize:       d.uint64("censusSize"),
+		ResPubStartBlock: d.uint64("resPubStartBlock"),
+		ResPubWindow:     d.uint64("resPubWindow"),
+		MinParticipation: d.uint8("minParticipation"),
+		MinPositiveVotes: d.uint8("minPositiveVotes"),
+		Type:             d.uint8("typ"),
+	}
+	if d.err != nil {
+		return nil, d.err
 	}
-	var e eventNewProcess
-
-	// contract event:
-	// event EventProcessCreated(address creator, uint256 id,uint256
-	// transactionHash,  uint256 censusRoot, uint8 typ, uint64 censusSize,
-	// uint64 resPubStartBlock, uint64 resPubWindow, uint8
-	// minParticipation, uint8 minPositiveVotes);
-
-	creatorBytes := d[:32]
-	e.Creator = common.BytesToAddress(creatorBytes[12:32])
-
-	// WARNING for the moment is uint256 but probably will change to uint64
-	// idBytes := new(big.Int).SetBytes(idBytes)
-	idBytes := d[64-8 : 64] // uint64
-	e.ProcessID = binary.BigEndian.Uint64(idBytes)
-
-	copy(e.TxHash[:], d[64:96])
-
-	// note that here Ethereum returns the CensusRoot in big endian
-	copy(e.CensusRoot[:], arbo.SwapEndianness(d[96:128]))
-
-	censusSizeBytes := d[160-8 : 160] // uint64
-	e.CensusSize = binary.BigEndian.Uint64(censusSizeBytes)
-
-	resPubStartBlockBytes := d[192-8 : 192] // uint64
-	e.ResPubStartBlock = binary.BigEndian.Uint64(resPubStartBlockBytes)
-
-	resPubWindowBytes := d[224-8 : 224] // uint64
-	e.ResPubWindow = binary.BigEndian.Uint64(resPubWindowBytes)
-
-	e.MinParticipation = uint8(d[255])
-	e.MinPositiveVotes = uint8(d[287])
-	e.Type = uint8(d[319])
-
 	return &e, nil
 }
 
@@ -339,32 +785,22 @@ func (e *eventResultPublished) String() string {
 		arbo.BytesToBigInt(e.ReceiptsRoot[:]), e.Result, e.NVotes)
 }
 
-func parseEventResultPublished(d []byte) (*eventResultPublished, error) {
-	if len(d) != eventResultPublishedLen {
-		return nil, fmt.Errorf("resultPublished event log should be of"+
-			" length %d, current: %d", eventResultPublishedLen, len(d))
+func parseEventResultPublished(eventLog types.Log) (*eventResultPublished, error) {
+	d, err := decodeEventLog(eventResultPublishedName, eventLog)
+	if err != nil {
+		return nil, err
+	}
+	e := eventResultPublished{
+		Publisher: d.address("publisher"),
+		ProcessID: d.uint64("id"),
+		// note that here Ethereum returns the ReceiptsRoot in big endian
+		ReceiptsRoot: d.bytes32LE("receiptsRoot"),
+		Result:       d.uint64("result"),
+		NVotes:       d.uint64("nVotes"),
+	}
+	if d.err != nil {
+		return nil, d.err
 	}
-
-	// event EventResultPublished(address publisher, uint256 id, uint256
-	// receiptsRoot, uint64 result, uint64 nVotes);
-
-	var e eventResultPublished
-
-	publisherBytes := d[:32]
-	e.Publisher = common.BytesToAddress(publisherBytes[12:32])
-
-	idBytes := d[64-8 : 64] // uint64
-	e.ProcessID = binary.BigEndian.Uint64(idBytes)
-
-	// note that here Ethereum returns the CensusRoot in big endian
-	copy(e.ReceiptsRoot[:], arbo.SwapEndianness(d[64:96]))
-
-	result := d[128-8 : 128] // uint64
-	e.Result = binary.BigEndian.Uint64(result)
-
-	nVotes := d[160-8 : 160] // uint64
-	e.NVotes = binary.BigEndian.Uint64(nVotes)
-
 	return &e, nil
 }
 
@@ -380,25 +816,18 @@ func (e *eventProcessClosed) String() string {
 		e.Caller, e.ProcessID, e.Success)
 }
 
-// event EventProcessClosed(address caller, uint256 id, bool success);
-func parseEventProcessClosed(d []byte) (*eventProcessClosed, error) {
-	if len(d) != eventProcessClosedLen {
-		return nil, fmt.Errorf("processClosed event log should be of length %d, current: %d",
-			eventProcessClosedLen, len(d))
+func parseEventProcessClosed(eventLog types.Log) (*eventProcessClosed, error) {
+	d, err := decodeEventLog(eventProcessClosedName, eventLog)
+	if err != nil {
+		return nil, err
 	}
-
-	var e eventProcessClosed
-
-	creatorBytes := d[:32]
-	e.Caller = common.BytesToAddress(creatorBytes[12:32])
-
-	idBytes := d[64-8 : 64] // uint64
-	e.ProcessID = binary.BigEndian.Uint64(idBytes)
-
-	success := d[96-1 : 96] // uint64
-	if success[0] == byte(1) {
-		e.Success = true
+	e := eventProcessClosed{
+		Caller:    d.address("caller"),
+		ProcessID: d.uint64("id"),
+		Success:   d.bool("success"),
+	}
+	if d.err != nil {
+		return nil, d.err
 	}
-
 	return &e, nil
 }
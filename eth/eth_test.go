@@ -4,14 +4,19 @@ import (
 	"context"
 	"database/sql"
 	"encoding/hex"
+	"errors"
 	"flag"
+	"fmt"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/aragon/zkmultisig-node/db"
+	ztypes "github.com/aragon/zkmultisig-node/types"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 	qt "github.com/frankban/quicktest"
+	"github.com/iden3/go-iden3-crypto/babyjub"
 	"github.com/vocdoni/arbo"
 	"go.vocdoni.io/dvote/log"
 )
@@ -49,7 +54,9 @@ func TestSyncHistory(t *testing.T) {
 		SQLite: sqlite, ContractAddr: addr})
 	c.Assert(err, qt.IsNil)
 
-	err = client.syncHistory(startBlock)
+	err = client.db.InitMeta(client.ChainID, startBlock-1)
+	c.Assert(err, qt.IsNil)
+	err = client.syncHistory()
 	c.Assert(err, qt.IsNil)
 }
 
@@ -74,8 +81,11 @@ func TestSyncLive(t *testing.T) {
 		SQLite: sqlite, ContractAddr: addr})
 	c.Assert(err, qt.IsNil)
 
-	go client.syncBlocksLive() // nolint:errcheck
-	err = client.syncEventsLive()
+	header, err := client.backend.HeaderByNumber(context.Background(), nil)
+	c.Assert(err, qt.IsNil)
+	err = client.db.InitMeta(client.ChainID, header.Number.Uint64())
+	c.Assert(err, qt.IsNil)
+	err = client.syncBlocksLive(context.Background())
 	c.Assert(err, qt.IsNil)
 }
 
@@ -101,13 +111,458 @@ func TestSync(t *testing.T) {
 	c.Assert(err, qt.IsNil)
 
 	// store meta into db
-	chainID, err := client.client.ChainID(context.Background())
+	err = client.db.InitMeta(client.ChainID, startBlock-1)
+	c.Assert(err, qt.IsNil)
+
+	err = client.Sync(context.Background())
+	c.Assert(err, qt.IsNil)
+}
+
+func testSyncClient(c *qt.C, confirmations uint64) (*Client, *testChain) {
+	sqlDB, err := sql.Open("sqlite3", filepath.Join(c.TempDir(), "testdb.sqlite3"))
+	c.Assert(err, qt.IsNil)
+
+	sqlite := db.NewSQLite(sqlDB)
+	err = sqlite.Migrate()
+	c.Assert(err, qt.IsNil)
+	err = sqlite.InitMeta(3, 0)
+	c.Assert(err, qt.IsNil)
+
+	addr := common.HexToAddress("0x79ea1cc5B8BFF0F46E1B98068727Fd02D8EB1aF3")
+	chain := newTestChain(addr)
+	client := newClient(chain, 3, Options{
+		SQLite: sqlite, ContractAddr: addr, Confirmations: confirmations})
+	return client, chain
+}
+
+func TestSyncConfirmations(t *testing.T) {
+	c := qt.New(t)
+
+	client, chain := testSyncClient(c, 2)
+
+	chain.addBlocks(2)
+	chain.addBlock(newProcessEventLog(1, 6))
+	chain.addBlocks(1)
+
+	// the block of the process has not enough confirmations
+	err := client.syncHistory()
+	c.Assert(err, qt.IsNil)
+	_, err = client.db.ReadProcessByID(1)
+	c.Assert(err, qt.ErrorMatches, "Process ID:1, does not exist in the db")
+	lastSyncBlockNum, err := client.db.GetLastSyncBlockNum()
+	c.Assert(err, qt.IsNil)
+	c.Assert(lastSyncBlockNum, qt.Equals, uint64(2))
+
+	chain.addBlocks(1)
+	err = client.syncHead(chain.head())
+	c.Assert(err, qt.IsNil)
+	process, err := client.db.ReadProcessByID(1)
+	c.Assert(err, qt.IsNil)
+	c.Assert(process.EthBlockNum, qt.Equals, uint64(3))
+	c.Assert(process.Status, qt.Equals, ztypes.ProcessStatusOn)
+
+	// the process is frozen at its ResPubStartBlock, without waiting for
+	// confirmations
+	chain.addBlocks(1)
+	err = client.syncHead(chain.head())
+	c.Assert(err, qt.IsNil)
+	process, err = client.db.ReadProcessByID(1)
+	c.Assert(err, qt.IsNil)
+	c.Assert(process.Status, qt.Equals, ztypes.ProcessStatusFrozen)
+	lastSyncBlockNum, err = client.db.GetLastSyncBlockNum()
+	c.Assert(err, qt.IsNil)
+	c.Assert(lastSyncBlockNum, qt.Equals, uint64(4))
+}
+
+func TestSyncNetworks(t *testing.T) {
+	c := qt.New(t)
+
+	sqlDB, err := sql.Open("sqlite3", filepath.Join(c.TempDir(), "testdb.sqlite3"))
+	c.Assert(err, qt.IsNil)
+	sqlite := db.NewSQLite(sqlDB)
+	c.Assert(sqlite.Migrate(), qt.IsNil)
+
+	// two contracts of the same chain, and a contract of another chain,
+	// synced into the same db
+	addrs := []common.Address{
+		common.HexToAddress("0x79ea1cc5B8BFF0F46E1B98068727Fd02D8EB1aF3"),
+		common.HexToAddress("0x0000000000000000000000000000000000000002"),
+		common.HexToAddress("0x79ea1cc5B8BFF0F46E1B98068727Fd02D8EB1aF3"),
+	}
+	chainIDs := []uint64{3, 3, 5}
+	var clients []*Client
+	for i, addr := range addrs {
+		netDB, err := sqlite.WithNetwork(chainIDs[i], addr.Bytes())
+		c.Assert(err, qt.IsNil)
+		c.Assert(netDB.InitMeta(chainIDs[i], 0), qt.IsNil)
+		chain := newTestChain(addr)
+		chain.addBlocks(i)
+		chain.addBlock(newProcessEventLog(1, uint64(10*(i+1))))
+		client := newClient(chain, chainIDs[i], Options{SQLite: netDB, ContractAddr: addr})
+		c.Assert(client.syncHistory(), qt.IsNil)
+		clients = append(clients, client)
+	}
+
+	// each network has its own process 1 and lastSyncBlockNum
+	for i, client := range clients {
+		process, err := client.SQLite().ReadProcessByID(1)
+		c.Assert(err, qt.IsNil)
+		c.Assert(process.ResPubStartBlock, qt.Equals, uint64(10*(i+1)))
+		lastSyncBlockNum, err := client.SQLite().GetLastSyncBlockNum()
+		c.Assert(err, qt.IsNil)
+		c.Assert(lastSyncBlockNum, qt.Equals, uint64(i+1))
+	}
+	networks, err := sqlite.ReadNetworks()
+	c.Assert(err, qt.IsNil)
+	c.Assert(len(networks), qt.Equals, len(addrs))
+}
+
+func TestSyncQuarantine(t *testing.T) {
+	c := qt.New(t)
+
+	client, chain := testSyncClient(c, 0)
+
+	chain.addBlock(newProcessEventLog(1, 3), newProcessEventLog(2, 3))
+	// the contract state of process 2 does not match its event
+	chain.setProcessCensusSize(2, 99)
+	chain.addBlocks(2)
+
+	err := client.syncHistory()
+	c.Assert(err, qt.IsNil)
+	process, err := client.db.ReadProcessByID(2)
 	c.Assert(err, qt.IsNil)
-	err = client.db.InitMeta(chainID.Uint64(), startBlock)
+	c.Assert(process.CensusSize, qt.Equals, uint64(100))
+	c.Assert(process.Status, qt.Equals, ztypes.ProcessStatusQuarantined)
+	// the quarantined process is not frozen at its ResPubStartBlock
+	status, err := client.db.GetProcessStatus(1)
+	c.Assert(err, qt.IsNil)
+	c.Assert(status, qt.Equals, ztypes.ProcessStatusFrozen)
+
+	// a process not found in the contract is quarantined
+	chain.addBlock(newProcessEventLog(3, 10))
+	chain.mu.Lock()
+	delete(chain.processes, 3)
+	chain.mu.Unlock()
+	err = client.syncHead(chain.head())
+	c.Assert(err, qt.IsNil)
+	status, err = client.db.GetProcessStatus(3)
+	c.Assert(err, qt.IsNil)
+	c.Assert(status, qt.Equals, ztypes.ProcessStatusQuarantined)
+}
+
+func TestSyncContractCallError(t *testing.T) {
+	c := qt.New(t)
+
+	client, chain := testSyncClient(c, 0)
+
+	chain.addBlocks(1)
+	chain.addBlock(newProcessEventLog(1, 10))
+	chain.callFails = 1
+
+	// the events of the block are not synced while the contract can not
+	// be called
+	err := client.syncHistory()
+	c.Assert(errors.Is(err, errContractCall), qt.IsTrue)
+	lastSyncBlockNum, err := client.db.GetLastSyncBlockNum()
 	c.Assert(err, qt.IsNil)
+	c.Assert(lastSyncBlockNum, qt.Equals, uint64(0))
+	_, err = client.db.ReadProcessByID(1)
+	c.Assert(err, qt.ErrorMatches, "Process ID:1, does not exist in the db")
 
-	err = client.Sync()
+	err = client.syncHistory()
 	c.Assert(err, qt.IsNil)
+	status, err := client.db.GetProcessStatus(1)
+	c.Assert(err, qt.IsNil)
+	c.Assert(status, qt.Equals, ztypes.ProcessStatusOn)
+	lastSyncBlockNum, err = client.db.GetLastSyncBlockNum()
+	c.Assert(err, qt.IsNil)
+	c.Assert(lastSyncBlockNum, qt.Equals, uint64(2))
+}
+
+func TestCheckContract(t *testing.T) {
+	c := qt.New(t)
+
+	client, chain := testSyncClient(c, 0)
+	c.Assert(client.CheckContract(context.Background()), qt.IsNil)
+
+	client = newClient(chain, 3, Options{SQLite: client.db,
+		ContractAddr: common.HexToAddress("0x0000000000000000000000000000000000000002")})
+	err := client.CheckContract(context.Background())
+	c.Assert(errors.Is(err, ErrNoContractCode), qt.IsTrue)
+}
+
+func TestSyncReorg(t *testing.T) {
+	c := qt.New(t)
+
+	client, chain := testSyncClient(c, 2)
+
+	chain.addBlocks(2)
+	chain.addBlock(newProcessEventLog(1, 8))
+	chain.addBlocks(2)
+	chain.addBlock(newProcessEventLog(2, 20))
+	chain.addBlocks(4)
+
+	err := client.syncHistory()
+	c.Assert(err, qt.IsNil)
+	processes, err := client.db.ReadProcesses()
+	c.Assert(err, qt.IsNil)
+	c.Assert(len(processes), qt.Equals, 2)
+	status, err := client.db.GetProcessStatus(1)
+	c.Assert(err, qt.IsNil)
+	c.Assert(status, qt.Equals, ztypes.ProcessStatusFrozen)
+
+	// a vote in the process 2, which will be orphaned
+	sk := babyjub.NewRandPrivKey()
+	err = client.db.StoreVotePackage(2, ztypes.VotePackage{
+		CensusProof: ztypes.CensusProof{PublicKey: sk.Public(),
+			MerkleProof: []byte("proof")},
+		Vote: []byte("1"),
+	})
+	c.Assert(err, qt.IsNil)
+
+	// reorg of the blocks after block 4, the new chain contains a
+	// different process in block 6 and it is shorter
+	chain.reorg(4)
+	chain.addBlocks(1)
+	chain.addBlock(newProcessEventLog(3, 20))
+	chain.addBlocks(1)
+
+	err = client.syncHead(chain.head())
+	c.Assert(err, qt.IsNil)
+	lastSyncBlockNum, err := client.db.GetLastSyncBlockNum()
+	c.Assert(err, qt.IsNil)
+	c.Assert(lastSyncBlockNum, qt.Equals, uint64(5))
+	processes, err = client.db.ReadProcesses()
+	c.Assert(err, qt.IsNil)
+	c.Assert(len(processes), qt.Equals, 1)
+	c.Assert(processes[0].ID, qt.Equals, uint64(1))
+	// the ResPubStartBlock of process 1 has not been reached in the new chain
+	c.Assert(processes[0].Status, qt.Equals, ztypes.ProcessStatusOn)
+	votes, err := client.db.ReadVotePackagesByProcessID(2)
+	c.Assert(err, qt.IsNil)
+	c.Assert(len(votes), qt.Equals, 0)
+
+	chain.addBlocks(2)
+	err = client.syncHead(chain.head())
+	c.Assert(err, qt.IsNil)
+	processes, err = client.db.ReadProcesses()
+	c.Assert(err, qt.IsNil)
+	c.Assert(len(processes), qt.Equals, 2)
+	process, err := client.db.ReadProcessByID(3)
+	c.Assert(err, qt.IsNil)
+	c.Assert(process.EthBlockNum, qt.Equals, uint64(6))
+	status, err = client.db.GetProcessStatus(1)
+	c.Assert(err, qt.IsNil)
+	c.Assert(status, qt.Equals, ztypes.ProcessStatusFrozen)
+
+	// a reorg deeper than the stored blocks can not be handled
+	chain.reorg(0)
+	chain.addBlocks(12)
+	err = client.syncHead(chain.head())
+	c.Assert(errors.Is(err, ErrReorgTooDeep), qt.IsTrue)
+}
+
+func TestSyncHistoryRanges(t *testing.T) {
+	c := qt.New(t)
+
+	client, chain := testSyncClient(c, 0)
+	client.maxBlockRange, client.blockRange = 40, 40
+	chain.maxRange = 10
+
+	for i := uint64(1); i <= 100; i++ {
+		if i%10 == 5 {
+			chain.addBlock(newProcessEventLog(i, 1000))
+			continue
+		}
+		chain.addBlock()
+	}
+
+	// the sync fails at block 73, and the lastSyncBlockNum contains the
+	// last completed range
+	chain.failBlock = 73
+	err := client.syncHistory()
+	c.Assert(err, qt.ErrorMatches, "connection refused")
+	lastSyncBlockNum, err := client.db.GetLastSyncBlockNum()
+	c.Assert(err, qt.IsNil)
+	c.Assert(lastSyncBlockNum, qt.Equals, uint64(70))
+	processes, err := client.db.ReadProcesses()
+	c.Assert(err, qt.IsNil)
+	c.Assert(len(processes), qt.Equals, 7)
+
+	// the block range has been reduced to the provider limit
+	for _, r := range chain.filterRanges {
+		c.Assert(r[1]-r[0] < chain.maxRange, qt.IsTrue)
+	}
+
+	// the sync is resumed from the last completed range
+	chain.failBlock = 0
+	chain.filterRanges = nil
+	err = client.syncHistory()
+	c.Assert(err, qt.IsNil)
+	c.Assert(chain.filterRanges[0][0], qt.Equals, uint64(71))
+	lastSyncBlockNum, err = client.db.GetLastSyncBlockNum()
+	c.Assert(err, qt.IsNil)
+	c.Assert(lastSyncBlockNum, qt.Equals, uint64(100))
+	processes, err = client.db.ReadProcesses()
+	c.Assert(err, qt.IsNil)
+	c.Assert(len(processes), qt.Equals, 10)
+
+	// without the provider limit, the block range grows back after
+	// blockRangeGrowAfter completed ranges
+	chain.maxRange = 0
+	chain.filterRanges = nil
+	chain.addBlocks(200)
+	err = client.syncHistory()
+	c.Assert(err, qt.IsNil)
+	c.Assert(len(chain.filterRanges), qt.Equals, 10)
+	c.Assert(chain.filterRanges[9], qt.Equals, [2]uint64{281, 300})
+	c.Assert(client.blockRange, qt.Equals, uint64(40))
+}
+
+func TestIsHTTPURL(t *testing.T) {
+	c := qt.New(t)
+
+	c.Assert(isHTTPURL("http://localhost:8545"), qt.IsTrue)
+	c.Assert(isHTTPURL("https://goerli.infura.io/v3/key"), qt.IsTrue)
+	c.Assert(isHTTPURL("wss://goerli.infura.io/ws/v3/key"), qt.IsFalse)
+	c.Assert(isHTTPURL("ws://localhost:8546"), qt.IsFalse)
+	c.Assert(isHTTPURL("/home/user/.ethereum/geth.ipc"), qt.IsFalse)
+}
+
+func TestPollHead(t *testing.T) {
+	c := qt.New(t)
+
+	client, chain := testSyncClient(c, 1)
+	c.Assert(client.polling, qt.IsFalse)
+	client = newClient(chain, 3, Options{EthURL: "http://localhost:8545",
+		SQLite: client.db, ContractAddr: client.contractAddr, Confirmations: 1})
+	c.Assert(client.polling, qt.IsTrue)
+	c.Assert(client.pollInterval, qt.Equals, DefaultPollInterval)
+
+	chain.addBlock(newProcessEventLog(1, 4))
+	chain.addBlocks(1)
+
+	lastHead, err := client.pollHead(common.Hash{})
+	c.Assert(err, qt.IsNil)
+	c.Assert(lastHead, qt.Equals, chain.head().Hash())
+	_, err = client.db.ReadProcessByID(1)
+	c.Assert(err, qt.IsNil)
+	c.Assert(len(chain.filterRanges), qt.Equals, 1)
+
+	// the head block has not changed
+	lastHead, err = client.pollHead(lastHead)
+	c.Assert(err, qt.IsNil)
+	c.Assert(len(chain.filterRanges), qt.Equals, 1)
+
+	// the new blocks are synchronized, and the process frozen
+	chain.addBlocks(2)
+	lastHead, err = client.pollHead(lastHead)
+	c.Assert(err, qt.IsNil)
+	c.Assert(lastHead, qt.Equals, chain.head().Hash())
+	c.Assert(chain.filterRanges[1], qt.Equals, [2]uint64{2, 3})
+	status, err := client.db.GetProcessStatus(1)
+	c.Assert(err, qt.IsNil)
+	c.Assert(status, qt.Equals, ztypes.ProcessStatusFrozen)
+}
+
+// waitFor waits until the given condition is true, failing the test after a
+// timeout
+func waitFor(c *qt.C, cond func() bool) {
+	timeout := time.After(10 * time.Second)
+	for !cond() {
+		select {
+		case <-timeout:
+			c.Fatal("timeout waiting for the condition")
+		case <-time.After(5 * time.Millisecond):
+		}
+	}
+}
+
+func TestSyncSupervision(t *testing.T) {
+	c := qt.New(t)
+
+	client, chain := testSyncClient(c, 1)
+	client.minRetryDelay = time.Millisecond
+	client.maxRetryDelay = 10 * time.Millisecond
+	client.dial = func() (Backend, error) { return chain, nil }
+
+	chain.addBlocks(2)
+	chain.addBlock(newProcessEventLog(1, 100))
+	chain.addBlocks(2)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	syncErr := make(chan error, 1)
+	go func() { syncErr <- client.Sync(ctx) }()
+
+	waitFor(c, func() bool { return client.SyncStatus().Synced })
+	status := client.SyncStatus()
+	c.Assert(status.Connected, qt.IsTrue)
+	c.Assert(status.Polling, qt.IsFalse)
+	c.Assert(status.HeadBlockNum, qt.Equals, uint64(5))
+	c.Assert(status.LastSyncBlockNum, qt.Equals, uint64(4))
+	c.Assert(status.BlocksBehind, qt.Equals, uint64(0))
+
+	// new blocks are received through the subscription
+	chain.addBlocks(1)
+	waitFor(c, func() bool { return client.SyncStatus().LastSyncBlockNum == 5 })
+
+	// the subscription fails, and the resubscriptions are refused
+	chain.disconnect(fmt.Errorf("connection lost"), 1<<20)
+	waitFor(c, func() bool { return client.SyncStatus().Reconnections > 1 })
+	status = client.SyncStatus()
+	c.Assert(status.Connected, qt.IsFalse)
+	c.Assert(status.Synced, qt.IsFalse)
+	c.Assert(status.LastError, qt.Matches, "new heads subscription error: .*")
+
+	// the blocks added while disconnected are backfilled once reconnected
+	chain.addBlock(newProcessEventLog(2, 100))
+	chain.addBlocks(2)
+	chain.reconnect()
+	waitFor(c, func() bool {
+		status := client.SyncStatus()
+		return status.Synced && status.LastSyncBlockNum == 8
+	})
+	_, err := client.db.ReadProcessByID(2)
+	c.Assert(err, qt.IsNil)
+
+	// the new subscription receives the new blocks
+	chain.addBlocks(1)
+	waitFor(c, func() bool { return client.SyncStatus().LastSyncBlockNum == 9 })
+
+	cancel()
+	c.Assert(<-syncErr, qt.Equals, context.Canceled)
+	c.Assert(client.SyncStatus().Connected, qt.IsFalse)
+}
+
+func TestSyncStatusBehind(t *testing.T) {
+	c := qt.New(t)
+
+	client, chain := testSyncClient(c, 2)
+	chain.maxRange = 5
+	chain.failBlock = 7
+	chain.addBlocks(20)
+
+	// the sync fails at the range containing the failBlock
+	err := client.syncHead(chain.head())
+	c.Assert(err, qt.ErrorMatches, "connection refused")
+	c.Assert(client.SyncStatus().HeadBlockNum, qt.Equals, uint64(0))
+
+	chain.failBlock = 0
+	err = client.syncHead(chain.head())
+	c.Assert(err, qt.IsNil)
+	client.setConnected(true)
+	status := client.SyncStatus()
+	c.Assert(status.HeadBlockNum, qt.Equals, uint64(20))
+	c.Assert(status.LastSyncBlockNum, qt.Equals, uint64(18))
+	c.Assert(status.BlocksBehind, qt.Equals, uint64(0))
+	c.Assert(status.Synced, qt.IsTrue)
+
+	// a head block ahead of the synced ones
+	client.setHead(30, 18)
+	status = client.SyncStatus()
+	c.Assert(status.BlocksBehind, qt.Equals, uint64(10))
+	c.Assert(status.Synced, qt.IsFalse)
 }
 
 func TestProcessEventLog(t *testing.T) {
@@ -121,7 +576,9 @@ func TestProcessEventLog(t *testing.T) {
 	err = sqlite.Migrate()
 	c.Assert(err, qt.IsNil)
 
-	client := Client{db: sqlite}
+	addr := common.HexToAddress("0x79ea1cc5B8BFF0F46E1B98068727Fd02D8EB1aF3")
+	chain := newTestChain(addr)
+	client := Client{db: sqlite, backend: chain, contractAddr: addr}
 	c.Assert(err, qt.IsNil)
 
 	// log bytes generated from the contract event logs.
@@ -150,9 +607,14 @@ func TestProcessEventLog(t *testing.T) {
 	d2, err := hex.DecodeString(d2Hex)
 	c.Assert(err, qt.IsNil)
 
-	log0 := types.Log{Data: d0, BlockNumber: 1}
-	log1 := types.Log{Data: d1, BlockNumber: 2}
-	log2 := types.Log{Data: d2, BlockNumber: 3}
+	log0 := testEventLog(eventProcessCreatedName, d0)
+	log0.BlockNumber = 1
+	log1 := testEventLog(eventResultPublishedName, d1)
+	log1.BlockNumber = 2
+	log2 := testEventLog(eventProcessClosedName, d2)
+	log2.BlockNumber = 3
+	// the process of the event log exists in the contract
+	chain.storeProcess(log0)
 
 	err = client.processEventLog(log0)
 	c.Assert(err, qt.IsNil)
@@ -175,6 +637,62 @@ func TestProcessEventLog(t *testing.T) {
 	c.Assert(process.MinParticipation, qt.Equals, uint8(10))
 	c.Assert(process.MinPositiveVotes, qt.Equals, uint8(60))
 	c.Assert(process.Type, qt.Equals, uint8(1))
+	c.Assert(process.Status, qt.Equals, ztypes.ProcessStatusClosedSuccess)
+
+	// check the result published in the event log
+	result, err := sqlite.ReadResult(6)
+	c.Assert(err, qt.IsNil)
+	c.Assert(common.BytesToAddress(result.Publisher), qt.Equals,
+		common.HexToAddress("0xa6a2e217af2f983ee55a6e2195c1763a9420f8ad"))
+	c.Assert(arbo.BytesToBigInt(result.ReceiptsRoot).String(), qt.Equals,
+		"3997482243935470019154908634129466064231369626981967795243271053776626526277")
+	c.Assert(result.Result, qt.Equals, uint64(300))
+	c.Assert(result.NVotes, qt.Equals, uint64(400))
+	c.Assert(result.EthBlockNum, qt.Equals, uint64(2))
+	c.Assert(result.Mismatch, qt.IsFalse)
+
+	// the events of processes that are not synced are ignored
+	d1[63] = 7
+	log1 = testEventLog(eventResultPublishedName, d1)
+	err = client.processEventLog(log1)
+	c.Assert(err, qt.IsNil)
+	_, err = sqlite.ReadResult(7)
+	c.Assert(err, qt.Equals, db.ErrResultNotInDB)
+}
+
+func TestProcessEventLogUnknown(t *testing.T) {
+	c := qt.New(t)
+
+	sqlDB, err := sql.Open("sqlite3", filepath.Join(c.TempDir(), "testdb.sqlite3"))
+	c.Assert(err, qt.IsNil)
+
+	sqlite := db.NewSQLite(sqlDB)
+	err = sqlite.Migrate()
+	c.Assert(err, qt.IsNil)
+
+	client := Client{db: sqlite}
+
+	// a newProcess event log data, but with an unknown event signature, is
+	// ignored
+	d := newProcessEventLog(1, 10).Data
+	err = client.processEventLog(types.Log{
+		Topics: []common.Hash{common.HexToHash("0x01")}, Data: d})
+	c.Assert(err, qt.IsNil)
+	// anonymous event logs are ignored
+	err = client.processEventLog(types.Log{Data: d})
+	c.Assert(err, qt.IsNil)
+	processes, err := sqlite.ReadProcesses()
+	c.Assert(err, qt.IsNil)
+	c.Assert(len(processes), qt.Equals, 0)
+
+	// a known event with invalid data
+	err = client.processEventLog(testEventLog(eventProcessCreatedName, d[:96]))
+	c.Assert(err, qt.ErrorMatches, "blocknum: 0, error parsing event log \\(newProcess\\).*")
+
+	// a resultPublished event log data with the newProcess signature
+	d = newProcessEventLog(1, 10).Data
+	_, err = parseEventResultPublished(testEventLog(eventProcessCreatedName, d))
+	c.Assert(err, qt.ErrorMatches, "event log is not a EventResultPublished event")
 }
 
 func TestParseEventNewProcess(t *testing.T) {
@@ -196,7 +714,7 @@ func TestParseEventNewProcess(t *testing.T) {
 	d, err := hex.DecodeString(dHex)
 	c.Assert(err, qt.IsNil)
 
-	e, err := parseEventNewProcess(d)
+	e, err := parseEventNewProcess(testEventLog(eventProcessCreatedName, d))
 	c.Assert(err, qt.IsNil)
 
 	c.Assert(e.Creator.String(), qt.Equals,
@@ -233,7 +751,7 @@ func TestParseEventNewProcess(t *testing.T) {
 	d, err = hex.DecodeString(dHex)
 	c.Assert(err, qt.IsNil)
 
-	e, err = parseEventNewProcess(d)
+	e, err = parseEventNewProcess(testEventLog(eventProcessCreatedName, d))
 	c.Assert(err, qt.IsNil)
 
 	c.Assert(e.Creator.String(), qt.Equals,
@@ -264,7 +782,7 @@ func TestParseEventNewProcess(t *testing.T) {
 	d, err = hex.DecodeString(dHex)
 	c.Assert(err, qt.IsNil)
 
-	e, err = parseEventNewProcess(d)
+	e, err = parseEventNewProcess(testEventLog(eventProcessCreatedName, d))
 	c.Assert(err, qt.IsNil)
 
 	c.Assert(e.Creator.String(), qt.Equals,
@@ -292,7 +810,7 @@ func TestParseEventResultPublished(t *testing.T) {
 	d, err := hex.DecodeString(dHex)
 	c.Assert(err, qt.IsNil)
 
-	e, err := parseEventResultPublished(d)
+	e, err := parseEventResultPublished(testEventLog(eventResultPublishedName, d))
 	c.Assert(err, qt.IsNil)
 
 	c.Assert(e.Publisher.String(), qt.Equals,
@@ -314,7 +832,7 @@ func TestParseEventProcessClosed(t *testing.T) {
 	d, err := hex.DecodeString(dHex)
 	c.Assert(err, qt.IsNil)
 
-	e, err := parseEventProcessClosed(d)
+	e, err := parseEventProcessClosed(testEventLog(eventProcessClosedName, d))
 	c.Assert(err, qt.IsNil)
 
 	c.Assert(e.Caller.String(), qt.Equals, "0xa6a2E217aF2f983ee55A6e2195C1763a9420f8ad")
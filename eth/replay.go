@@ -0,0 +1,77 @@
+package eth
+
+import (
+	"fmt"
+
+	"github.com/aragon/zkmultisig-node/db"
+	ztypes "github.com/aragon/zkmultisig-node/types"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// ErrNoEventsStored is returned by Replay when the db does not contain events
+// of the network to replay
+var ErrNoEventsStored = fmt.Errorf("no events stored in the db")
+
+// Replay rebuilds the processes of the network of the given db from the contract event logs
+// stored in it, without accessing the web3 provider. The processes are
+// created or updated from their events keeping their votes and proofs, and
+// their results, closures and statuses are recomputed. Returns the number of
+// replayed events.
+func Replay(sqlite *db.SQLite) (int, error) {
+	events, err := sqlite.ReadEvents()
+	if err != nil {
+		return 0, err
+	}
+	if len(events) == 0 {
+		return 0, ErrNoEventsStored
+	}
+	lastSyncBlockNum, err := sqlite.GetLastSyncBlockNum()
+	if err != nil {
+		return 0, err
+	}
+	if err := sqlite.ClearProcessResults(); err != nil {
+		return 0, err
+	}
+
+	c := &Client{db: sqlite, replaying: true}
+	for _, event := range events {
+		if err := c.processEventLog(logFromEvent(event)); err != nil {
+			return 0, fmt.Errorf("error replaying event (blocknum: %d, index: %d): %s",
+				event.BlockNum, event.LogIndex, err)
+		}
+	}
+	return len(events), sqlite.RebuildProcessStatuses(lastSyncBlockNum)
+}
+
+// eventFromLog returns the ztypes.EthEvent to store the given event log
+func eventFromLog(l types.Log) ztypes.EthEvent {
+	event := ztypes.EthEvent{
+		BlockNum:  l.BlockNumber,
+		BlockHash: l.BlockHash.Bytes(),
+		TxHash:    l.TxHash.Bytes(),
+		LogIndex:  uint64(l.Index),
+		Address:   l.Address.Bytes(),
+		Data:      l.Data,
+	}
+	for _, topic := range l.Topics {
+		event.Topics = append(event.Topics, topic.Bytes())
+	}
+	return event
+}
+
+// logFromEvent returns the event log of the given stored ztypes.EthEvent
+func logFromEvent(event ztypes.EthEvent) types.Log {
+	l := types.Log{
+		BlockNumber: event.BlockNum,
+		BlockHash:   common.BytesToHash(event.BlockHash),
+		TxHash:      common.BytesToHash(event.TxHash),
+		Index:       uint(event.LogIndex),
+		Address:     common.BytesToAddress(event.Address),
+		Data:        event.Data,
+	}
+	for _, topic := range event.Topics {
+		l.Topics = append(l.Topics, common.BytesToHash(topic))
+	}
+	return l
+}
@@ -0,0 +1,59 @@
+package eth
+
+import (
+	"testing"
+
+	ztypes "github.com/aragon/zkmultisig-node/types"
+	qt "github.com/frankban/quicktest"
+)
+
+func TestReplay(t *testing.T) {
+	c := qt.New(t)
+
+	client, chain := testSyncClient(c, 0)
+
+	_, err := Replay(client.db)
+	c.Assert(err, qt.ErrorMatches, "no events stored in the db")
+
+	chain.addBlock(newProcessEventLog(1, 3), newProcessEventLog(2, 10))
+	chain.addBlocks(2)
+	chain.addBlock(resultPublishedEventLog(1, 7, 10))
+	chain.addBlocks(1)
+	err = client.syncHead(chain.head())
+	c.Assert(err, qt.IsNil)
+
+	events, err := client.db.ReadEvents()
+	c.Assert(err, qt.IsNil)
+	c.Assert(len(events), qt.Equals, 3)
+	c.Assert(events[1].BlockNum, qt.Equals, uint64(1))
+	c.Assert(events[1].LogIndex, qt.Equals, uint64(1))
+	c.Assert(events[1].BlockHash, qt.DeepEquals, chain.headers[1].Hash().Bytes())
+	c.Assert(events[2].Topics[0], qt.DeepEquals,
+		contractABI.Events[eventResultPublishedName].ID.Bytes())
+
+	// break the db, and replay the stored events
+	c.Assert(client.db.ClearProcessResults(), qt.IsNil)
+	err = client.db.UpdateProcessStatus(2, ztypes.ProcessStatusProofFailed)
+	c.Assert(err, qt.IsNil)
+
+	n, err := Replay(client.db)
+	c.Assert(err, qt.IsNil)
+	c.Assert(n, qt.Equals, 3)
+
+	process, err := client.db.ReadProcessByID(1)
+	c.Assert(err, qt.IsNil)
+	c.Assert(process.Status, qt.Equals, ztypes.ProcessStatusResultsPublished)
+	c.Assert(process.ResPubStartBlock, qt.Equals, uint64(3))
+	result, err := client.db.ReadResult(1)
+	c.Assert(err, qt.IsNil)
+	c.Assert(result.Result, qt.Equals, uint64(7))
+	c.Assert(result.NVotes, qt.Equals, uint64(10))
+	status, err := client.db.GetProcessStatus(2)
+	c.Assert(err, qt.IsNil)
+	c.Assert(status, qt.Equals, ztypes.ProcessStatusOn)
+
+	// the replay does not store the events again
+	events, err = client.db.ReadEvents()
+	c.Assert(err, qt.IsNil)
+	c.Assert(len(events), qt.Equals, 3)
+}
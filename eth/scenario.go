@@ -0,0 +1,213 @@
+package eth
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"path/filepath"
+
+	ztypes "github.com/aragon/zkmultisig-node/types"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/vocdoni/arbo"
+	"gopkg.in/yaml.v2"
+)
+
+// ScenarioEvent is a contract event emitted in a block of a Scenario. Event
+// is the name of the contract event (EventProcessCreated,
+// EventResultPublished or EventProcessClosed), and the fields that do not
+// belong to the event are ignored. The CensusRoot and ReceiptsRoot are
+// decimal strings, and the Publisher and Caller hex addresses.
+type ScenarioEvent struct {
+	Event     string `json:"event" yaml:"event"`
+	ProcessID uint64 `json:"processID" yaml:"processID"`
+
+	CensusRoot       string `json:"censusRoot,omitempty" yaml:"censusRoot"`
+	CensusSize       uint64 `json:"censusSize,omitempty" yaml:"censusSize"`
+	ResPubStartBlock uint64 `json:"resPubStartBlock,omitempty" yaml:"resPubStartBlock"`
+	ResPubWindow     uint64 `json:"resPubWindow,omitempty" yaml:"resPubWindow"`
+	MinParticipation uint8  `json:"minParticipation,omitempty" yaml:"minParticipation"`
+	MinPositiveVotes uint8  `json:"minPositiveVotes,omitempty" yaml:"minPositiveVotes"`
+	Type             uint8  `json:"type,omitempty" yaml:"type"`
+
+	Publisher    string `json:"publisher,omitempty" yaml:"publisher"`
+	ReceiptsRoot string `json:"receiptsRoot,omitempty" yaml:"receiptsRoot"`
+	Result       uint64 `json:"result,omitempty" yaml:"result"`
+	NVotes       uint64 `json:"nVotes,omitempty" yaml:"nVotes"`
+
+	Caller  string `json:"caller,omitempty" yaml:"caller"`
+	Success bool   `json:"success,omitempty" yaml:"success"`
+
+	// Dropped makes the web3 provider not return the event log, simulating
+	// a log lost by the provider
+	Dropped bool `json:"dropped,omitempty" yaml:"dropped"`
+}
+
+// ScenarioStep is a step of a Scenario. A step first orphans the blocks after
+// the Reorg block number (if set), then adds Advance empty blocks, and then a
+// block with the Events (if any).
+type ScenarioStep struct {
+	Reorg   *uint64         `json:"reorg,omitempty" yaml:"reorg"`
+	Advance uint64          `json:"advance,omitempty" yaml:"advance"`
+	Events  []ScenarioEvent `json:"events,omitempty" yaml:"events"`
+}
+
+// Scenario is a scripted sequence of steps of the chain, which is run by the
+// TestEthClient. A Scenario can be built with the Scenario methods, or loaded
+// from a json or yaml file with LoadScenario.
+type Scenario struct {
+	Steps []ScenarioStep `json:"steps" yaml:"steps"`
+}
+
+// NewScenario returns an empty Scenario
+func NewScenario() *Scenario {
+	return &Scenario{}
+}
+
+// LoadScenario loads the Scenario of the given json or yaml file, depending on
+// its extension
+func LoadScenario(path string) (*Scenario, error) {
+	ext := filepath.Ext(path)
+	if ext != ".json" && ext != ".yaml" && ext != ".yml" {
+		return nil, fmt.Errorf("unsupported scenario file extension: %s", path)
+	}
+	b, err := ioutil.ReadFile(path) //nolint:gosec
+	if err != nil {
+		return nil, err
+	}
+	var s Scenario
+	if ext == ".json" {
+		dec := json.NewDecoder(bytes.NewReader(b))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(&s)
+	} else {
+		err = yaml.UnmarshalStrict(b, &s)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error parsing scenario %s: %s", path, err)
+	}
+	return &s, nil
+}
+
+// Block adds a step that adds a block with the given events
+func (s *Scenario) Block(events ...ScenarioEvent) *Scenario {
+	if len(events) == 0 {
+		return s.Advance(1)
+	}
+	s.Steps = append(s.Steps, ScenarioStep{Events: events})
+	return s
+}
+
+// Advance adds a step that adds n empty blocks
+func (s *Scenario) Advance(n uint64) *Scenario {
+	s.Steps = append(s.Steps, ScenarioStep{Advance: n})
+	return s
+}
+
+// Reorg adds a step that orphans the blocks after the given block number, so
+// the next blocks build a new chain on top of it
+func (s *Scenario) Reorg(blockNum uint64) *Scenario {
+	s.Steps = append(s.Steps, ScenarioStep{Reorg: &blockNum})
+	return s
+}
+
+// ProcessCreated returns the ScenarioEvent of the creation of the given
+// process
+func ProcessCreated(p ztypes.Process) ScenarioEvent {
+	return ScenarioEvent{
+		Event:            eventProcessCreatedName,
+		ProcessID:        p.ID,
+		CensusRoot:       arbo.BytesToBigInt(p.CensusRoot).String(),
+		CensusSize:       p.CensusSize,
+		ResPubStartBlock: p.ResPubStartBlock,
+		ResPubWindow:     p.ResPubWindow,
+		MinParticipation: p.MinParticipation,
+		MinPositiveVotes: p.MinPositiveVotes,
+		Type:             p.Type,
+	}
+}
+
+// ResultPublished returns the ScenarioEvent of the publication of the given
+// result of the given processID
+func ResultPublished(processID, result, nVotes uint64) ScenarioEvent {
+	return ScenarioEvent{
+		Event:     eventResultPublishedName,
+		ProcessID: processID,
+		Result:    result,
+		NVotes:    nVotes,
+	}
+}
+
+// ProcessClosed returns the ScenarioEvent of the closure of the given
+// processID
+func ProcessClosed(processID uint64, success bool) ScenarioEvent {
+	return ScenarioEvent{
+		Event:     eventProcessClosedName,
+		ProcessID: processID,
+		Success:   success,
+	}
+}
+
+// Drop returns a copy of the ScenarioEvent that is not returned by the web3
+// provider
+func (e ScenarioEvent) Drop() ScenarioEvent {
+	e.Dropped = true
+	return e
+}
+
+// log returns the contract event log of the ScenarioEvent, packed with the
+// contract ABI
+func (e ScenarioEvent) log() (types.Log, error) {
+	processID := new(big.Int).SetUint64(e.ProcessID)
+	var args []interface{}
+	switch e.Event {
+	case eventProcessCreatedName:
+		censusRoot, err := parseScenarioBigInt(e.CensusRoot)
+		if err != nil {
+			return types.Log{}, err
+		}
+		args = []interface{}{common.Address{}, processID, big.NewInt(0), censusRoot,
+			e.CensusSize, e.ResPubStartBlock, e.ResPubWindow, e.MinParticipation,
+			e.MinPositiveVotes, e.Type}
+	case eventResultPublishedName:
+		receiptsRoot, err := parseScenarioBigInt(e.ReceiptsRoot)
+		if err != nil {
+			return types.Log{}, err
+		}
+		args = []interface{}{common.HexToAddress(e.Publisher), processID, receiptsRoot,
+			e.Result, e.NVotes}
+	case eventProcessClosedName:
+		args = []interface{}{common.HexToAddress(e.Caller), processID, e.Success}
+	default:
+		return types.Log{}, fmt.Errorf("unknown scenario event %q", e.Event)
+	}
+	return packEventLog(e.Event, args...)
+}
+
+// packEventLog returns the event log of the contract event with the given name,
+// with the given event arguments packed as its data
+func packEventLog(name string, args ...interface{}) (types.Log, error) {
+	data, err := contractABI.Events[name].Inputs.Pack(args...)
+	if err != nil {
+		return types.Log{}, fmt.Errorf("error packing %s: %s", name, err)
+	}
+	return types.Log{
+		Topics: []common.Hash{contractABI.Events[name].ID},
+		Data:   data,
+	}, nil
+}
+
+// parseScenarioBigInt parses the given decimal string, where an empty string
+// is 0
+func parseScenarioBigInt(s string) (*big.Int, error) {
+	if s == "" {
+		return big.NewInt(0), nil
+	}
+	v, ok := new(big.Int).SetString(s, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid decimal number %q", s)
+	}
+	return v, nil
+}
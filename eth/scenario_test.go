@@ -0,0 +1,123 @@
+package eth
+
+import (
+	"database/sql"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/aragon/zkmultisig-node/db"
+	ztypes "github.com/aragon/zkmultisig-node/types"
+	qt "github.com/frankban/quicktest"
+)
+
+func newScenarioClient(c *qt.C, startBlock uint64) (*TestEthClient, *db.SQLite) {
+	sqlDB, err := sql.Open("sqlite3", filepath.Join(c.TempDir(), "testdb.sqlite3"))
+	c.Assert(err, qt.IsNil)
+
+	sqlite := db.NewSQLite(sqlDB)
+	err = sqlite.Migrate()
+	c.Assert(err, qt.IsNil)
+	return NewTestEthClient(sqlite, startBlock, nil), sqlite
+}
+
+func TestScenario(t *testing.T) {
+	c := qt.New(t)
+
+	eth, sqlite := newScenarioClient(c, 10)
+	checkStatus := func(processID uint64, status ztypes.ProcessStatus) {
+		s, err := sqlite.GetProcessStatus(processID)
+		c.Assert(err, qt.IsNil)
+		c.Assert(s, qt.Equals, status)
+	}
+
+	process := ztypes.Process{ID: 1, CensusRoot: []byte("root"), CensusSize: 100,
+		ResPubStartBlock: 13, ResPubWindow: 10, MinParticipation: 20,
+		MinPositiveVotes: 60, Type: 1}
+	s := NewScenario().Block(
+		ProcessCreated(process),
+		ProcessCreated(ztypes.Process{ID: 2, ResPubStartBlock: 20}),
+		ProcessCreated(ztypes.Process{ID: 3, ResPubStartBlock: 20}).Drop(),
+	)
+	c.Assert(eth.RunScenario(s), qt.IsNil)
+	c.Assert(eth.CurrentBlock(), qt.Equals, uint64(11))
+	checkStatus(1, ztypes.ProcessStatusOn)
+	checkStatus(2, ztypes.ProcessStatusOn)
+	_, err := sqlite.ReadProcessByID(3)
+	c.Assert(err, qt.Not(qt.IsNil))
+
+	// process 1 is frozen at its ResPubStartBlock
+	c.Assert(eth.RunScenario(NewScenario().Advance(2)), qt.IsNil)
+	checkStatus(1, ztypes.ProcessStatusFrozen)
+
+	s = NewScenario().
+		Block(ResultPublished(1, 7, 10)).
+		Block(ProcessClosed(1, false))
+	c.Assert(eth.RunScenario(s), qt.IsNil)
+	c.Assert(eth.CurrentBlock(), qt.Equals, uint64(15))
+	checkStatus(1, ztypes.ProcessStatusClosedFail)
+	result, err := sqlite.ReadResult(1)
+	c.Assert(err, qt.IsNil)
+	c.Assert(result.Result, qt.Equals, uint64(7))
+	c.Assert(result.NVotes, qt.Equals, uint64(10))
+	c.Assert(result.EthBlockNum, qt.Equals, uint64(14))
+
+	// the result and the closure of process 1 are orphaned by the reorg,
+	// while process 2 is closed in the new chain
+	s = NewScenario().Reorg(13).Block(ProcessClosed(2, true))
+	c.Assert(eth.RunScenario(s), qt.IsNil)
+	c.Assert(eth.CurrentBlock(), qt.Equals, uint64(14))
+	checkStatus(1, ztypes.ProcessStatusFrozen)
+	checkStatus(2, ztypes.ProcessStatusClosedSuccess)
+	_, err = sqlite.ReadResult(1)
+	c.Assert(errors.Is(err, db.ErrResultNotInDB), qt.IsTrue)
+
+	lastSync, err := sqlite.GetLastSyncBlockNum()
+	c.Assert(err, qt.IsNil)
+	c.Assert(lastSync, qt.Equals, uint64(14))
+
+	err = eth.RunScenario(NewScenario().Block(ScenarioEvent{Event: "EventUnknown"}))
+	c.Assert(err, qt.ErrorMatches, `unknown scenario event "EventUnknown"`)
+}
+
+func TestLoadScenario(t *testing.T) {
+	c := qt.New(t)
+
+	s, err := LoadScenario("testdata/scenario.yaml")
+	c.Assert(err, qt.IsNil)
+	c.Assert(len(s.Steps), qt.Equals, 5)
+	c.Assert(*s.Steps[4].Reorg, qt.Equals, uint64(4))
+	sJSON, err := LoadScenario("testdata/scenario.json")
+	c.Assert(err, qt.IsNil)
+	c.Assert(sJSON, qt.DeepEquals, s)
+
+	eth, sqlite := newScenarioClient(c, 0)
+	c.Assert(eth.RunScenario(s), qt.IsNil)
+	c.Assert(eth.CurrentBlock(), qt.Equals, uint64(6))
+	process, err := sqlite.ReadProcessByID(1)
+	c.Assert(err, qt.IsNil)
+	c.Assert(process.Status, qt.Equals, ztypes.ProcessStatusResultsPublished)
+	c.Assert(process.CensusSize, qt.Equals, uint64(100))
+	result, err := sqlite.ReadResult(1)
+	c.Assert(err, qt.IsNil)
+	c.Assert(result.Result, qt.Equals, uint64(7))
+	_, err = sqlite.ReadProcessByID(2)
+	c.Assert(err, qt.Not(qt.IsNil))
+
+	// unknown fields are rejected
+	dir := c.TempDir()
+	path := filepath.Join(dir, "scenario.yaml")
+	err = ioutil.WriteFile(path, []byte("steps:\n  - advanc: 2\n"), 0600)
+	c.Assert(err, qt.IsNil)
+	_, err = LoadScenario(path)
+	c.Assert(err, qt.ErrorMatches, "(?s)error parsing scenario .*field advanc not found.*")
+	path = filepath.Join(dir, "scenario.json")
+	err = ioutil.WriteFile(path, []byte(`{"steps": [{"advanc": 2}]}`), 0600)
+	c.Assert(err, qt.IsNil)
+	_, err = LoadScenario(path)
+	c.Assert(err, qt.ErrorMatches, `error parsing scenario .*unknown field "advanc"`)
+
+	_, err = LoadScenario(filepath.Join(dir, "scenario.txt"))
+	c.Assert(err, qt.ErrorMatches, "unsupported scenario file extension: .*")
+}
@@ -0,0 +1,220 @@
+package eth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"database/sql"
+	"math/big"
+	"path/filepath"
+	"testing"
+
+	"github.com/aragon/zkmultisig-node/db"
+	"github.com/aragon/zkmultisig-node/eth/contracts"
+	ztypes "github.com/aragon/zkmultisig-node/types"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/crypto"
+	qt "github.com/frankban/quicktest"
+	"github.com/vocdoni/arbo"
+)
+
+// simulatedChainID is the ChainID used by the go-ethereum simulated backend
+const simulatedChainID = 1337
+
+// testSimulated contains a simulated blockchain with the zkMultisig mock
+// contract deployed, and a Client that syncs from it
+type testSimulated struct {
+	c        *qt.C
+	sim      *backends.SimulatedBackend
+	key      *ecdsa.PrivateKey
+	auth     *bind.TransactOpts
+	contract *contracts.ZKMultisigMock
+	client   *Client
+	sqlite   *db.SQLite
+}
+
+func newTestSimulated(c *qt.C) *testSimulated {
+	key, err := crypto.GenerateKey()
+	c.Assert(err, qt.IsNil)
+	auth, err := bind.NewKeyedTransactorWithChainID(key, big.NewInt(simulatedChainID))
+	c.Assert(err, qt.IsNil)
+	balance, _ := new(big.Int).SetString("1000000000000000000000", 10)
+	sim := backends.NewSimulatedBackend(core.GenesisAlloc{
+		auth.From: {Balance: balance},
+	}, 10_000_000)
+	c.Cleanup(func() { sim.Close() }) //nolint:errcheck
+
+	contractAddr, _, contract, err := contracts.DeployZKMultisigMock(auth, sim)
+	c.Assert(err, qt.IsNil)
+	sim.Commit()
+
+	sqlDB, err := sql.Open("sqlite3", filepath.Join(c.TempDir(), "testdb.sqlite3"))
+	c.Assert(err, qt.IsNil)
+	sqlite := db.NewSQLite(sqlDB)
+	c.Assert(sqlite.Migrate(), qt.IsNil)
+	// the contract is deployed at block 1
+	c.Assert(sqlite.InitMeta(simulatedChainID, 1), qt.IsNil)
+
+	client := newClient(sim, simulatedChainID, Options{SQLite: sqlite,
+		ContractAddr: contractAddr})
+	return &testSimulated{c: c, sim: sim, key: key, auth: auth, contract: contract,
+		client: client, sqlite: sqlite}
+}
+
+// blockNum returns the number of the last mined block
+func (ts *testSimulated) blockNum() uint64 {
+	header, err := ts.sim.HeaderByNumber(context.Background(), nil)
+	ts.c.Assert(err, qt.IsNil)
+	return header.Number.Uint64()
+}
+
+// createProcess sends a createProcess transaction and mines its block
+func (ts *testSimulated) createProcess(id, resPubStartBlock uint64) {
+	_, err := ts.contract.CreateProcess(ts.auth, new(big.Int).SetUint64(id),
+		big.NewInt(0), big.NewInt(1234), 100, resPubStartBlock, 10, 20, 60, 1)
+	ts.c.Assert(err, qt.IsNil)
+	ts.sim.Commit()
+}
+
+// publishResult sends a publishResult transaction, with a zkProof that is not
+// verified by the mock, and mines its block
+func (ts *testSimulated) publishResult(id, result, nVotes uint64) {
+	zero := big.NewInt(0)
+	_, err := ts.contract.PublishResult(ts.auth, new(big.Int).SetUint64(id),
+		[2]*big.Int{zero, zero}, [2][2]*big.Int{{zero, zero}, {zero, zero}},
+		[2]*big.Int{zero, zero}, big.NewInt(5678), result, nVotes)
+	ts.c.Assert(err, qt.IsNil)
+	ts.sim.Commit()
+}
+
+// closeProcess sends a closeProcess transaction and mines its block
+func (ts *testSimulated) closeProcess(id uint64, success bool) {
+	_, err := ts.contract.CloseProcess(ts.auth, new(big.Int).SetUint64(id), success)
+	ts.c.Assert(err, qt.IsNil)
+	ts.sim.Commit()
+}
+
+// setCensusSize modifies the censusSize of the contract state of the given
+// process and mines its block
+func (ts *testSimulated) setCensusSize(id, censusSize uint64) {
+	_, err := ts.contract.SetCensusSize(ts.auth, new(big.Int).SetUint64(id), censusSize)
+	ts.c.Assert(err, qt.IsNil)
+	ts.sim.Commit()
+}
+
+// mine mines n empty blocks
+func (ts *testSimulated) mine(n int) {
+	for i := 0; i < n; i++ {
+		ts.sim.Commit()
+	}
+}
+
+func (ts *testSimulated) processStatus(id uint64) ztypes.ProcessStatus {
+	status, err := ts.sqlite.GetProcessStatus(id)
+	ts.c.Assert(err, qt.IsNil)
+	return status
+}
+
+func TestSimulatedSyncHistory(t *testing.T) {
+	c := qt.New(t)
+	ts := newTestSimulated(c)
+
+	ts.createProcess(1, 5)     // block 2
+	ts.createProcess(2, 100)   // block 3
+	ts.mine(2)                 // blocks 4, 5
+	ts.publishResult(1, 7, 10) // block 6
+	ts.closeProcess(1, true)   // block 7
+	c.Assert(ts.blockNum(), qt.Equals, uint64(7))
+
+	c.Assert(ts.client.syncHistory(), qt.IsNil)
+
+	lastSync, err := ts.sqlite.GetLastSyncBlockNum()
+	c.Assert(err, qt.IsNil)
+	c.Assert(lastSync, qt.Equals, uint64(7))
+
+	process, err := ts.sqlite.ReadProcessByID(1)
+	c.Assert(err, qt.IsNil)
+	c.Assert(process.EthBlockNum, qt.Equals, uint64(2))
+	c.Assert(process.CensusRoot, qt.DeepEquals,
+		arbo.BigIntToBytes(32, big.NewInt(1234)))
+	c.Assert(process.CensusSize, qt.Equals, uint64(100))
+	c.Assert(process.ResPubStartBlock, qt.Equals, uint64(5))
+	c.Assert(process.ResPubWindow, qt.Equals, uint64(10))
+	c.Assert(process.MinParticipation, qt.Equals, uint8(20))
+	c.Assert(process.MinPositiveVotes, qt.Equals, uint8(60))
+	c.Assert(process.Type, qt.Equals, uint8(1))
+	c.Assert(process.Status, qt.Equals, ztypes.ProcessStatusClosedSuccess)
+	c.Assert(ts.processStatus(2), qt.Equals, ztypes.ProcessStatusOn)
+
+	result, err := ts.sqlite.ReadResult(1)
+	c.Assert(err, qt.IsNil)
+	c.Assert(result.Publisher, qt.DeepEquals, ztypes.ByteArray(ts.auth.From.Bytes()))
+	c.Assert(result.Result, qt.Equals, uint64(7))
+	c.Assert(result.NVotes, qt.Equals, uint64(10))
+	c.Assert(result.EthBlockNum, qt.Equals, uint64(6))
+
+	events, err := ts.sqlite.ReadEvents()
+	c.Assert(err, qt.IsNil)
+	c.Assert(len(events), qt.Equals, 4)
+}
+
+func TestSimulatedSyncLive(t *testing.T) {
+	c := qt.New(t)
+	ts := newTestSimulated(c)
+
+	ts.createProcess(1, 6) // block 2
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- ts.client.Sync(ctx) }()
+	defer func() {
+		cancel()
+		c.Assert(<-done, qt.Equals, context.Canceled)
+	}()
+
+	// the process created before starting is synced by the history sync
+	waitFor(c, func() bool { return ts.client.SyncStatus().Connected })
+	c.Assert(ts.processStatus(1), qt.Equals, ztypes.ProcessStatusOn)
+
+	ts.createProcess(2, 100) // block 3
+	waitFor(c, func() bool {
+		_, err := ts.sqlite.ReadProcessByID(2)
+		return err == nil
+	})
+	c.Assert(ts.processStatus(1), qt.Equals, ztypes.ProcessStatusOn)
+
+	// process 1 is frozen when the chain reaches its ResPubStartBlock
+	ts.mine(3) // blocks 4 to 6
+	waitFor(c, func() bool { return ts.client.SyncStatus().HeadBlockNum == 6 })
+	c.Assert(ts.processStatus(1), qt.Equals, ztypes.ProcessStatusFrozen)
+	c.Assert(ts.processStatus(2), qt.Equals, ztypes.ProcessStatusOn)
+
+	ts.publishResult(1, 3, 4) // block 7
+	waitFor(c, func() bool {
+		return ts.processStatus(1) == ztypes.ProcessStatusResultsPublished
+	})
+	ts.closeProcess(1, false) // block 8
+	waitFor(c, func() bool {
+		return ts.processStatus(1) == ztypes.ProcessStatusClosedFail
+	})
+
+	status := ts.client.SyncStatus()
+	c.Assert(status.Synced, qt.IsTrue)
+	c.Assert(status.LastSyncBlockNum, qt.Equals, uint64(8))
+}
+
+func TestSimulatedQuarantine(t *testing.T) {
+	c := qt.New(t)
+	ts := newTestSimulated(c)
+
+	c.Assert(ts.client.CheckContract(context.Background()), qt.IsNil)
+
+	ts.createProcess(1, 100) // block 2
+	ts.createProcess(2, 100) // block 3
+	ts.setCensusSize(2, 99)  // block 4
+
+	c.Assert(ts.client.syncHistory(), qt.IsNil)
+	c.Assert(ts.processStatus(1), qt.Equals, ztypes.ProcessStatusOn)
+	c.Assert(ts.processStatus(2), qt.Equals, ztypes.ProcessStatusQuarantined)
+}
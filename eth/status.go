@@ -0,0 +1,89 @@
+package eth
+
+import (
+	"time"
+)
+
+// SyncStatus contains the health of the synchronization of the Client with
+// the Ethereum blockchain
+type SyncStatus struct {
+	// Connected is set while the Client is receiving the new blocks from
+	// the web3 provider
+	Connected bool `json:"connected"`
+	// Polling is set when the new blocks are requested periodically
+	// instead of being received through a subscription
+	Polling bool `json:"polling"`
+	// HeadBlockNum is the number of the last head block received
+	HeadBlockNum uint64 `json:"headBlockNum"`
+	// LastSyncBlockNum is the number of the last confirmed block synced
+	LastSyncBlockNum uint64 `json:"lastSyncBlockNum"`
+	// BlocksBehind is the number of confirmed blocks of the last head
+	// block that have not been synced yet
+	BlocksBehind uint64 `json:"blocksBehind"`
+	// Synced is set when the Client is connected and all the confirmed
+	// blocks of the last head block have been synced
+	Synced        bool      `json:"synced"`
+	LastHeadTime  time.Time `json:"lastHeadTime"`
+	LastError     string    `json:"lastError,omitempty"`
+	LastErrorTime time.Time `json:"lastErrorTime"`
+	// Reconnections is the number of times that the connection with the
+	// web3 provider has been restarted after a failure
+	Reconnections uint64 `json:"reconnections"`
+}
+
+// SyncStatus returns the current status of the synchronization with the
+// Ethereum blockchain
+func (c *Client) SyncStatus() SyncStatus {
+	c.statusMu.RLock()
+	defer c.statusMu.RUnlock()
+	s := c.status
+	s.Synced = s.Connected && !s.LastHeadTime.IsZero() && s.BlocksBehind == 0
+	return s
+}
+
+// HeadBlockNum returns the number of the last head block received, which is 0
+// until the first head block is received
+func (c *Client) HeadBlockNum() uint64 {
+	c.statusMu.RLock()
+	defer c.statusMu.RUnlock()
+	return c.status.HeadBlockNum
+}
+
+func (c *Client) setConnected(connected bool) {
+	c.statusMu.Lock()
+	defer c.statusMu.Unlock()
+	c.status.Connected = connected
+}
+
+// setSyncError stores the given error in the status, marking the Client as
+// disconnected if the error comes from the connection with the web3 provider
+func (c *Client) setSyncError(err error, disconnected bool) {
+	c.statusMu.Lock()
+	defer c.statusMu.Unlock()
+	c.status.LastError = err.Error()
+	c.status.LastErrorTime = time.Now()
+	if disconnected {
+		c.status.Connected = false
+	}
+}
+
+func (c *Client) addReconnection() {
+	c.statusMu.Lock()
+	defer c.statusMu.Unlock()
+	c.status.Reconnections++
+}
+
+// setHead updates the status with the given head block number and the last
+// synced block number
+func (c *Client) setHead(headBlockNum, lastSyncBlockNum uint64) {
+	c.statusMu.Lock()
+	defer c.statusMu.Unlock()
+	c.status.HeadBlockNum = headBlockNum
+	c.status.LastSyncBlockNum = lastSyncBlockNum
+	c.status.LastHeadTime = time.Now()
+	c.status.BlocksBehind = 0
+	if headBlockNum >= c.confirmations &&
+		headBlockNum-c.confirmations > lastSyncBlockNum {
+		c.status.BlocksBehind = headBlockNum - c.confirmations - lastSyncBlockNum
+	}
+}
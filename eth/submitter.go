@@ -0,0 +1,589 @@
+package eth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/aragon/zkmultisig-node/db"
+	"github.com/aragon/zkmultisig-node/eth/contracts"
+	ztypes "github.com/aragon/zkmultisig-node/types"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"go.vocdoni.io/dvote/log"
+)
+
+const (
+	// DefaultResubmitBlocks is the default number of blocks that the
+	// Submitter waits for the inclusion of a transaction before
+	// resubmitting it
+	DefaultResubmitBlocks = 5
+	// DefaultGasPriceBump is the default percentage by which the gas price
+	// of a transaction is increased when it is resubmitted. The web3
+	// providers do not accept replacement transactions with an increase
+	// lower than 10%.
+	DefaultGasPriceBump = 20
+	// DefaultSubmitInterval is the default interval between the checks of
+	// the results to submit
+	DefaultSubmitInterval = 15 * time.Second
+
+	// maxPublishAttempts determines the number of times that the result of
+	// a process is sent after its transactions have been reverted, before
+	// setting it to ProcessStatusPublishFailed
+	maxPublishAttempts = 3
+)
+
+// TxBackend defines the methods of the Ethereum node client used by the
+// Submitter to send the transactions and watch their inclusion, which are
+// implemented by *ethclient.Client
+type TxBackend interface {
+	bind.ContractBackend
+	TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error)
+}
+
+// SubmitterOptions is used to pass the parameters to load a new Submitter
+type SubmitterOptions struct {
+	EthURL string
+	// SQLite is scoped by NewSubmitter to the network of the ChainID of
+	// the web3 provider and the ContractAddr, see db.SQLite.WithNetwork
+	SQLite       *db.SQLite
+	ContractAddr common.Address
+	// Key signs the transactions, and its account pays for them, see
+	// LoadKey
+	Key *ecdsa.PrivateKey
+	// Nonces is shared by the Submitters of different contracts that use
+	// the same Key, so their transactions on the same chain do not reuse a
+	// nonce. If not set, the Submitter uses its own Nonces.
+	Nonces *Nonces
+	// ResubmitBlocks is the number of blocks that a transaction is waited
+	// to be included before resubmitting it with a bumped gas price. If
+	// not set, DefaultResubmitBlocks is used.
+	ResubmitBlocks uint64
+	// GasPriceBump is the percentage by which the gas price is increased
+	// at each resubmission. If not set, DefaultGasPriceBump is used.
+	GasPriceBump uint64
+	// Interval is the interval between the checks of the results to
+	// submit. If not set, DefaultSubmitInterval is used.
+	Interval time.Duration
+}
+
+// Submitter sends the results of the processes, together with their zkProofs,
+// to the publishResult method of the zkMultisig contract. The results are only
+// sent during the results publishing window of each process, and the
+// transactions that are not included are resubmitted with a bumped gas price.
+// The sent transactions are stored in the db, so they are resumed after a
+// restart, and the results whose transaction is reverted are sent again up to
+// maxPublishAttempts times.
+type Submitter struct {
+	backend      TxBackend
+	db           *db.SQLite
+	contractAddr common.Address
+	contract     *contracts.ZKMultisigTransactor
+	auth         *bind.TransactOpts
+
+	resubmitBlocks uint64
+	gasPriceBump   uint64
+	interval       time.Duration
+	// txs contains the transactions sent for each processID, which are
+	// kept while the result of the process has not been synced, and are
+	// loaded from the db the first time that the process is checked
+	txs     map[uint64]*resultTx
+	nonce   *accountNonce
+	ChainID uint64
+}
+
+// Nonces assigns the nonces of the transactions sent by the accounts of the
+// Submitters, which can be shared by the Submitters of different contracts
+// with the same key, see SubmitterOptions.Nonces
+type Nonces struct {
+	mu       sync.Mutex
+	accounts map[nonceAccount]*accountNonce
+}
+
+type nonceAccount struct {
+	chainID uint64
+	addr    common.Address
+}
+
+// accountNonce is locked while a new transaction of the account is built and
+// sent
+type accountNonce struct {
+	sync.Mutex
+	// next is the nonce that follows the last transaction sent by the
+	// account, as the pending nonce returned by the web3 provider of a
+	// contract may not include the transactions sent through the web3
+	// provider of another contract
+	next uint64
+}
+
+// NewNonces returns a new Nonces
+func NewNonces() *Nonces {
+	return &Nonces{accounts: make(map[nonceAccount]*accountNonce)}
+}
+
+func (n *Nonces) account(chainID uint64, addr common.Address) *accountNonce {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	k := nonceAccount{chainID: chainID, addr: addr}
+	if _, ok := n.accounts[k]; !ok {
+		n.accounts[k] = &accountNonce{}
+	}
+	return n.accounts[k]
+}
+
+// resultTx contains the publishResult call of a process together with its
+// sent transactions, which are stored in the db
+type resultTx struct {
+	call *publishResultCall
+	ztypes.ResultTx
+	// included is set once one of the transactions has been included
+	included bool
+}
+
+// LoadKey loads the private key of the given keystore file, encrypted with the
+// given passphrase
+func LoadKey(path, passphrase string) (*ecdsa.PrivateKey, error) {
+	b, err := ioutil.ReadFile(path) //nolint:gosec
+	if err != nil {
+		return nil, err
+	}
+	key, err := keystore.DecryptKey(b, passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("can not decrypt keystore %s: %s", path, err)
+	}
+	return key.PrivateKey, nil
+}
+
+// NewSubmitter loads a new Submitter
+func NewSubmitter(opts SubmitterOptions) (*Submitter, error) {
+	client, err := ethclient.Dial(opts.EthURL)
+	if err != nil {
+		return nil, err
+	}
+
+	// get network ChainID
+	chainID, err := client.ChainID(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	opts.SQLite, err = opts.SQLite.WithNetwork(chainID.Uint64(), opts.ContractAddr.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	return newSubmitter(client, chainID.Uint64(), opts)
+}
+
+func newSubmitter(backend TxBackend, chainID uint64, opts SubmitterOptions) (*Submitter,
+	error) {
+	if opts.Key == nil {
+		return nil, fmt.Errorf("Submitter error: no key set")
+	}
+	auth, err := bind.NewKeyedTransactorWithChainID(opts.Key,
+		new(big.Int).SetUint64(chainID))
+	if err != nil {
+		return nil, err
+	}
+	contract, err := contracts.NewZKMultisigTransactor(opts.ContractAddr, backend)
+	if err != nil {
+		return nil, err
+	}
+	resubmitBlocks := opts.ResubmitBlocks
+	if resubmitBlocks == 0 {
+		resubmitBlocks = DefaultResubmitBlocks
+	}
+	gasPriceBump := opts.GasPriceBump
+	if gasPriceBump == 0 {
+		gasPriceBump = DefaultGasPriceBump
+	}
+	interval := opts.Interval
+	if interval == 0 {
+		interval = DefaultSubmitInterval
+	}
+	nonces := opts.Nonces
+	if nonces == nil {
+		nonces = NewNonces()
+	}
+	return &Submitter{
+		backend:        backend,
+		db:             opts.SQLite,
+		contractAddr:   opts.ContractAddr,
+		contract:       contract,
+		auth:           auth,
+		resubmitBlocks: resubmitBlocks,
+		gasPriceBump:   gasPriceBump,
+		interval:       interval,
+		txs:            make(map[uint64]*resultTx),
+		nonce:          nonces.account(chainID, auth.From),
+		ChainID:        chainID,
+	}, nil
+}
+
+// Address returns the address of the account that sends the transactions
+func (s *Submitter) Address() common.Address {
+	return s.auth.From
+}
+
+// Run submits the results of the processes with a generated zkProof every
+// interval, until the given context is done. This method is designed to be
+// called in a goroutine.
+func (s *Submitter) Run(ctx context.Context) error {
+	for {
+		if err := s.submitResults(ctx); err != nil {
+			log.Error(err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(s.interval):
+		}
+	}
+}
+
+// submitResults submits the results of the processes in status
+// ProcessStatusProofGenerated, and watches the inclusion of the already sent
+// ones
+func (s *Submitter) submitResults(ctx context.Context) error {
+	head, err := s.backend.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return err
+	}
+	processes, err := s.db.ReadProcessesByStatus(ztypes.ProcessStatusProofGenerated)
+	if err != nil {
+		return err
+	}
+
+	// forget the transactions of the processes whose result has been
+	// synced, or that have been closed
+	pending := make(map[uint64]bool, len(processes))
+	for i := 0; i < len(processes); i++ {
+		pending[processes[i].ID] = true
+	}
+	for processID := range s.txs {
+		if !pending[processID] {
+			delete(s.txs, processID)
+		}
+	}
+
+	for i := 0; i < len(processes); i++ {
+		if err := s.submitResult(ctx, head, &processes[i]); err != nil {
+			log.Warnf("[ProcessID=%d] result submission failed: %s",
+				processes[i].ID, err)
+		}
+	}
+	return nil
+}
+
+// submitResult sends the result of the given process if the next block is in
+// its results publishing window, resubmitting it with a bumped gas price if
+// the previous transaction has not been included after resubmitBlocks. The
+// results of the multi-choice processes are rejected, as publishResult only
+// takes the result of the processes with a 0 or 1 vote value.
+func (s *Submitter) submitResult(ctx context.Context, head *types.Header,
+	process *ztypes.Process) error {
+	nOptions, err := ztypes.ProcessTypeOptions(process.Type)
+	if err != nil {
+		return err
+	}
+	if nOptions > 0 {
+		return fmt.Errorf("the results of the multi-choice processes (type %#x)"+
+			" can not be published", process.Type)
+	}
+
+	tx, sent := s.txs[process.ID]
+	if !sent {
+		// resume the transactions sent before a restart
+		tx, err = s.loadResultTx(process.ID)
+		if err != nil {
+			return err
+		}
+		if tx != nil {
+			s.txs[process.ID] = tx
+			sent = true
+		}
+	}
+	if sent {
+		if tx.included {
+			return nil
+		}
+		included, err := s.checkInclusion(ctx, process.ID, tx)
+		if err != nil || included {
+			return err
+		}
+	}
+
+	// the transaction can be included at the next block at the earliest
+	headNum := head.Number.Uint64()
+	next := headNum + 1
+	if next < process.ResPubStartBlock {
+		return nil
+	}
+	if next > process.ResPubStartBlock+process.ResPubWindow {
+		log.Debugf("[ProcessID=%d] results publishing window ended at block %d",
+			process.ID, process.ResPubStartBlock+process.ResPubWindow)
+		return nil
+	}
+
+	if !sent {
+		return s.sendNew(ctx, head, process.ID)
+	}
+	if headNum < tx.SentBlock+s.resubmitBlocks {
+		return nil
+	}
+	tx.bumpGasPrice(s.gasPriceBump)
+	log.Infof("[ProcessID=%d] publishResult transaction not included after %d"+
+		" blocks, resubmitting it with a bumped gas price", process.ID,
+		headNum-tx.SentBlock)
+	return s.send(ctx, headNum, process.ID, tx)
+}
+
+// sendNew sends the first publishResult transaction of the given process. The
+// nonce of the account is locked until the transaction is sent, as the account
+// may be shared with the Submitters of other contracts.
+func (s *Submitter) sendNew(ctx context.Context, head *types.Header,
+	processID uint64) error {
+	s.nonce.Lock()
+	defer s.nonce.Unlock()
+
+	tx, err := s.newResultTx(ctx, head, processID)
+	if err != nil {
+		return err
+	}
+	s.txs[processID] = tx
+	err = s.send(ctx, head.Number.Uint64(), processID, tx)
+	// the transaction may have been sent even if it could not be stored
+	if len(tx.Hashes) > 0 {
+		s.nonce.next = tx.Nonce + 1
+	}
+	return err
+}
+
+// loadResultTx returns the publishResult transactions of the given process
+// stored in the db, or nil if no transaction has been sent for the process
+func (s *Submitter) loadResultTx(processID uint64) (*resultTx, error) {
+	stored, err := s.db.ReadResultTx(processID)
+	if err == db.ErrResultTxNotInDB {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	call, err := s.newPublishResultCall(processID)
+	if err != nil {
+		return nil, err
+	}
+	return &resultTx{call: call, ResultTx: *stored}, nil
+}
+
+// newPublishResultCall returns the publishResult arguments of the given
+// process from its stored zkProof
+func (s *Submitter) newPublishResultCall(processID uint64) (*publishResultCall, error) {
+	zkProof, err := s.db.ReadProof(processID)
+	if err != nil {
+		return nil, err
+	}
+	return newPublishResultCall(processID, zkProof)
+}
+
+// newResultTx builds the publishResult transaction of the given process from
+// its stored zkProof, estimating its gas and suggesting its gas price. The
+// nonce of the account must be locked by the caller.
+func (s *Submitter) newResultTx(ctx context.Context, head *types.Header,
+	processID uint64) (*resultTx, error) {
+	call, err := s.newPublishResultCall(processID)
+	if err != nil {
+		return nil, err
+	}
+	data, err := call.pack()
+	if err != nil {
+		return nil, err
+	}
+	gasLimit, err := s.backend.EstimateGas(ctx, ethereum.CallMsg{
+		From: s.auth.From,
+		To:   &s.contractAddr,
+		Data: data,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("can not estimate gas: %s", err)
+	}
+	nonce, err := s.backend.PendingNonceAt(ctx, s.auth.From)
+	if err != nil {
+		return nil, err
+	}
+	if nonce < s.nonce.next {
+		nonce = s.nonce.next
+	}
+
+	tx := &resultTx{call: call, ResultTx: ztypes.ResultTx{Nonce: nonce, GasLimit: gasLimit}}
+	if head.BaseFee == nil {
+		tx.GasPrice, err = s.backend.SuggestGasPrice(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return tx, nil
+	}
+	// EIP-1559 fees, which allow the base fee to double before the
+	// transaction is not includable, as done by the bind package
+	tx.GasTipCap, err = s.backend.SuggestGasTipCap(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tx.GasFeeCap = new(big.Int).Add(tx.GasTipCap,
+		new(big.Int).Mul(head.BaseFee, big.NewInt(2))) //nolint:gomnd
+	return tx, nil
+}
+
+// send sends a publishResult transaction with the nonce and gas price of the
+// given resultTx, and stores it in the db
+func (s *Submitter) send(ctx context.Context, headNum, processID uint64,
+	tx *resultTx) error {
+	opts := *s.auth
+	opts.Context = ctx
+	opts.Nonce = new(big.Int).SetUint64(tx.Nonce)
+	opts.GasLimit = tx.GasLimit
+	opts.GasPrice = tx.GasPrice
+	opts.GasTipCap = tx.GasTipCap
+	opts.GasFeeCap = tx.GasFeeCap
+	c := tx.call
+	sentTx, err := s.contract.PublishResult(&opts, c.id, c.a, c.b, c.c, c.receiptsRoot,
+		c.result, c.nVotes)
+	if err != nil {
+		return err
+	}
+	tx.Hashes = append(tx.Hashes, sentTx.Hash().Bytes())
+	tx.SentBlock = headNum
+	log.Infof("[ProcessID=%d] publishResult transaction sent: %s (nonce: %d)",
+		processID, sentTx.Hash().Hex(), tx.Nonce)
+	return s.db.StoreResultTx(processID, &tx.ResultTx)
+}
+
+// checkInclusion returns true if any of the transactions of the given
+// resultTx has been included. If the included transaction has been reverted,
+// the resultTx is discarded, so the result is sent again in a new transaction,
+// or the process is set to ProcessStatusPublishFailed once maxPublishAttempts
+// is reached.
+func (s *Submitter) checkInclusion(ctx context.Context, processID uint64,
+	tx *resultTx) (bool, error) {
+	for _, h := range tx.Hashes {
+		hash := common.BytesToHash(h)
+		receipt, err := s.backend.TransactionReceipt(ctx, hash)
+		if err == ethereum.NotFound || (err == nil && receipt == nil) {
+			continue
+		}
+		if err != nil {
+			return false, err
+		}
+		if receipt.Status != types.ReceiptStatusSuccessful {
+			revertErr := fmt.Errorf("publishResult transaction %s reverted in block %d",
+				hash.Hex(), receipt.BlockNumber)
+			log.Errorf("[ProcessID=%d] %s", processID, revertErr)
+			delete(s.txs, processID)
+			attempts, err := s.db.StoreResultTxError(processID, revertErr)
+			if err != nil {
+				return true, err
+			}
+			if attempts >= maxPublishAttempts {
+				return true, s.db.UpdateProcessStatus(processID,
+					ztypes.ProcessStatusPublishFailed)
+			}
+			return true, nil
+		}
+		tx.included = true
+		log.Infof("[ProcessID=%d] publishResult transaction %s included in block %d",
+			processID, hash.Hex(), receipt.BlockNumber)
+		return true, nil
+	}
+	return false, nil
+}
+
+// bumpGasPrice increases the gas price of the resultTx by the given percentage
+func (tx *resultTx) bumpGasPrice(percent uint64) {
+	bump := func(v *big.Int) *big.Int {
+		if v == nil {
+			return nil
+		}
+		b := new(big.Int).Mul(v, new(big.Int).SetUint64(100+percent)) //nolint:gomnd
+		b.Div(b, big.NewInt(100))                                     //nolint:gomnd
+		if b.Cmp(v) <= 0 {
+			b.Add(v, big.NewInt(1))
+		}
+		return b
+	}
+	tx.GasPrice = bump(tx.GasPrice)
+	tx.GasTipCap = bump(tx.GasTipCap)
+	tx.GasFeeCap = bump(tx.GasFeeCap)
+}
+
+// publishResultCall contains the arguments of the publishResult method of the
+// zkMultisig contract
+type publishResultCall struct {
+	id           *big.Int
+	a            [2]*big.Int
+	b            [2][2]*big.Int
+	c            [2]*big.Int
+	receiptsRoot *big.Int
+	result       uint64
+	nVotes       uint64
+}
+
+// newPublishResultCall returns the publishResult arguments of the given
+// process, where the result, nVotes and receiptsRoot are the public inputs of
+// its zkProof
+func newPublishResultCall(processID uint64, zkProof *ztypes.ZKProof) (
+	*publishResultCall, error) {
+	receiptsRoot, nVotes, result, err := zkProof.Result()
+	if err != nil {
+		return nil, err
+	}
+	call := &publishResultCall{
+		id:           new(big.Int).SetUint64(processID),
+		receiptsRoot: receiptsRoot,
+		result:       result,
+		nVotes:       nVotes,
+	}
+	p := zkProof.Proof
+	if len(p.A) != 3 || len(p.B) != 3 || len(p.B[0]) != 2 || len(p.B[1]) != 2 ||
+		len(p.C) != 3 { //nolint:gomnd
+		return nil, fmt.Errorf("invalid Groth16 proof dimensions")
+	}
+	// the verifier of the contract expects the coordinates of the G2
+	// point b with the imaginary part first, as exported by snarkjs
+	for i := 0; i < 2; i++ {
+		if call.a[i], err = parseProofCoordinate(p.A[i]); err != nil {
+			return nil, err
+		}
+		if call.c[i], err = parseProofCoordinate(p.C[i]); err != nil {
+			return nil, err
+		}
+		for j := 0; j < 2; j++ {
+			if call.b[i][j], err = parseProofCoordinate(p.B[i][1-j]); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return call, nil
+}
+
+// parseProofCoordinate parses the given decimal coordinate of a point of a
+// Groth16 proof
+func parseProofCoordinate(s string) (*big.Int, error) {
+	v, ok := new(big.Int).SetString(s, 10) //nolint:gomnd
+	if !ok || v.Sign() < 0 {
+		return nil, fmt.Errorf("can not parse proof coordinate %q", s)
+	}
+	return v, nil
+}
+
+// pack returns the call data of the publishResult call
+func (c *publishResultCall) pack() ([]byte, error) {
+	return contractABI.Pack(methodPublishResultName, c.id, c.a, c.b, c.c,
+		c.receiptsRoot, c.result, c.nVotes)
+}
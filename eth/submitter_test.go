@@ -0,0 +1,392 @@
+package eth
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/aragon/zkmultisig-node/db"
+	ztypes "github.com/aragon/zkmultisig-node/types"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	qt "github.com/frankban/quicktest"
+)
+
+// testZKProof returns a zkProof with the given public inputs, whose Groth16
+// points are not valid (the mock contract does not verify them)
+func testZKProof(processID, receiptsRoot, nVotes, result uint64) *ztypes.ZKProof {
+	return &ztypes.ZKProof{
+		Proof: ztypes.Groth16Proof{
+			A:        []string{"1", "2", "1"},
+			B:        [][]string{{"3", "4"}, {"5", "6"}, {"1", "0"}},
+			C:        []string{"7", "8", "1"},
+			Protocol: "groth16",
+		},
+		PublicInputs: []string{"1337", new(big.Int).SetUint64(processID).String(), "1234",
+			new(big.Int).SetUint64(receiptsRoot).String(),
+			new(big.Int).SetUint64(nVotes).String(),
+			new(big.Int).SetUint64(result).String(), "1"},
+	}
+}
+
+// newTestSubmitter returns a Submitter of the simulated contract, with the
+// account that deployed it
+func (ts *testSimulated) newTestSubmitter() *Submitter {
+	return ts.newTestSubmitterWithBackend(ts.sim)
+}
+
+// newTestSubmitterWithBackend returns a Submitter of the simulated contract
+// that uses the given backend
+func (ts *testSimulated) newTestSubmitterWithBackend(backend TxBackend) *Submitter {
+	s, err := newSubmitter(backend, simulatedChainID, SubmitterOptions{
+		SQLite:         ts.sqlite,
+		ContractAddr:   ts.client.contractAddr,
+		Key:            ts.key,
+		ResubmitBlocks: 2,
+	})
+	ts.c.Assert(err, qt.IsNil)
+	return s
+}
+
+// storeTestProof stores a zkProof for the given process, setting it to
+// ProcessStatusProofGenerated
+func (ts *testSimulated) storeTestProof(id, receiptsRoot, nVotes, result uint64) {
+	err := ts.sqlite.StoreProof(id, testZKProof(id, receiptsRoot, nVotes, result))
+	ts.c.Assert(err, qt.IsNil)
+	err = ts.sqlite.UpdateProcessStatus(id, ztypes.ProcessStatusProofGenerated)
+	ts.c.Assert(err, qt.IsNil)
+}
+
+func TestSubmitterPublishResult(t *testing.T) {
+	c := qt.New(t)
+	ts := newTestSimulated(c)
+	s := ts.newTestSubmitter()
+	ctx := context.Background()
+
+	ts.createProcess(1, 6) // block 2, window [6, 16]
+	c.Assert(ts.client.syncHistory(), qt.IsNil)
+	ts.storeTestProof(1, 5678, 3, 2)
+
+	// the result is not sent before the results publishing window
+	ts.mine(2) // blocks 3, 4
+	c.Assert(s.submitResults(ctx), qt.IsNil)
+	c.Assert(len(s.txs), qt.Equals, 0)
+
+	// the transaction sent at block 5 is included at block 6
+	ts.mine(1) // block 5
+	c.Assert(s.submitResults(ctx), qt.IsNil)
+	c.Assert(len(s.txs[1].Hashes), qt.Equals, 1)
+	ts.mine(1) // block 6
+	c.Assert(s.submitResults(ctx), qt.IsNil)
+	c.Assert(s.txs[1].included, qt.IsTrue)
+
+	// the proof is sent with the coordinates of b in the order of the
+	// contract verifier
+	tx, _, err := ts.sim.TransactionByHash(ctx, common.BytesToHash(s.txs[1].Hashes[0]))
+	c.Assert(err, qt.IsNil)
+	args, err := contractABI.Methods[methodPublishResultName].Inputs.Unpack(tx.Data()[4:])
+	c.Assert(err, qt.IsNil)
+	c.Assert(fmt.Sprint(args[1:4]), qt.Equals, "[[1 2] [[4 3] [6 5]] [7 8]]")
+
+	// the synced result is the one of the proof, published by the
+	// Submitter account
+	c.Assert(ts.client.syncHistory(), qt.IsNil)
+	result, err := ts.sqlite.ReadResult(1)
+	c.Assert(err, qt.IsNil)
+	c.Assert(result.Publisher, qt.DeepEquals, ztypes.ByteArray(s.Address().Bytes()))
+	c.Assert(result.Result, qt.Equals, uint64(2))
+	c.Assert(result.NVotes, qt.Equals, uint64(3))
+	c.Assert(result.EthBlockNum, qt.Equals, uint64(6))
+	c.Assert(result.Mismatch, qt.IsFalse)
+	c.Assert(ts.processStatus(1), qt.Equals, ztypes.ProcessStatusResultsPublished)
+
+	// once synced, the transaction is forgotten
+	c.Assert(s.submitResults(ctx), qt.IsNil)
+	c.Assert(len(s.txs), qt.Equals, 0)
+}
+
+func TestSubmitterResubmit(t *testing.T) {
+	c := qt.New(t)
+	ts := newTestSimulated(c)
+	s := ts.newTestSubmitter()
+	ctx := context.Background()
+
+	ts.createProcess(1, 3) // block 2, window [3, 13]
+	c.Assert(ts.client.syncHistory(), qt.IsNil)
+	ts.storeTestProof(1, 5678, 3, 2)
+
+	c.Assert(s.submitResults(ctx), qt.IsNil)
+	c.Assert(len(s.txs[1].Hashes), qt.Equals, 1)
+	first, _, err := ts.sim.TransactionByHash(ctx, common.BytesToHash(s.txs[1].Hashes[0]))
+	c.Assert(err, qt.IsNil)
+
+	// the transaction is dropped, and it is not resubmitted until
+	// ResubmitBlocks blocks have passed
+	ts.sim.Rollback()
+	ts.mine(1) // block 3
+	c.Assert(s.submitResults(ctx), qt.IsNil)
+	c.Assert(len(s.txs[1].Hashes), qt.Equals, 1)
+
+	ts.mine(1) // block 4
+	c.Assert(s.submitResults(ctx), qt.IsNil)
+	c.Assert(len(s.txs[1].Hashes), qt.Equals, 2)
+	second, _, err := ts.sim.TransactionByHash(ctx, common.BytesToHash(s.txs[1].Hashes[1]))
+	c.Assert(err, qt.IsNil)
+	c.Assert(second.Nonce(), qt.Equals, first.Nonce())
+	c.Assert(second.GasFeeCap().Cmp(first.GasFeeCap()), qt.Equals, 1)
+	c.Assert(second.GasTipCap().Cmp(first.GasTipCap()), qt.Equals, 1)
+
+	ts.mine(1) // block 5
+	c.Assert(s.submitResults(ctx), qt.IsNil)
+	c.Assert(s.txs[1].included, qt.IsTrue)
+	c.Assert(ts.client.syncHistory(), qt.IsNil)
+	c.Assert(ts.processStatus(1), qt.Equals, ztypes.ProcessStatusResultsPublished)
+}
+
+func TestSubmitterResume(t *testing.T) {
+	c := qt.New(t)
+	ts := newTestSimulated(c)
+	s := ts.newTestSubmitter()
+	ctx := context.Background()
+
+	ts.createProcess(1, 3) // block 2, window [3, 13]
+	c.Assert(ts.client.syncHistory(), qt.IsNil)
+	ts.storeTestProof(1, 5678, 3, 2)
+
+	c.Assert(s.submitResults(ctx), qt.IsNil)
+	c.Assert(len(s.txs[1].Hashes), qt.Equals, 1)
+	nonce := s.txs[1].Nonce
+
+	// after a restart, the pending transaction is resumed from the db
+	// instead of sending a new one with another nonce
+	s = ts.newTestSubmitter()
+	c.Assert(s.submitResults(ctx), qt.IsNil)
+	c.Assert(len(s.txs[1].Hashes), qt.Equals, 1)
+	c.Assert(s.txs[1].Nonce, qt.Equals, nonce)
+	pendingNonce, err := ts.sim.PendingNonceAt(ctx, s.Address())
+	c.Assert(err, qt.IsNil)
+	c.Assert(pendingNonce, qt.Equals, nonce+1)
+
+	ts.mine(1) // block 3
+	c.Assert(s.submitResults(ctx), qt.IsNil)
+	c.Assert(s.txs[1].included, qt.IsTrue)
+	c.Assert(ts.client.syncHistory(), qt.IsNil)
+	c.Assert(ts.processStatus(1), qt.Equals, ztypes.ProcessStatusResultsPublished)
+}
+
+// revertingBackend is a simulated backend whose transaction receipts are
+// reported as reverted
+type revertingBackend struct {
+	*backends.SimulatedBackend
+}
+
+func (b *revertingBackend) TransactionReceipt(ctx context.Context, txHash common.Hash) (
+	*types.Receipt, error) {
+	receipt, err := b.SimulatedBackend.TransactionReceipt(ctx, txHash)
+	if receipt == nil {
+		return receipt, err
+	}
+	reverted := *receipt
+	reverted.Status = types.ReceiptStatusFailed
+	return &reverted, err
+}
+
+func TestSubmitterReverted(t *testing.T) {
+	c := qt.New(t)
+	ts := newTestSimulated(c)
+	s := ts.newTestSubmitterWithBackend(&revertingBackend{ts.sim})
+	ctx := context.Background()
+
+	ts.createProcess(1, 3) // block 2, window [3, 13]
+	c.Assert(ts.client.syncHistory(), qt.IsNil)
+	ts.storeTestProof(1, 5678, 3, 2)
+
+	// the result is sent again in a new transaction after each revert,
+	// until maxPublishAttempts is reached
+	var nonces []uint64
+	for i := 1; i <= maxPublishAttempts; i++ {
+		c.Assert(s.submitResults(ctx), qt.IsNil)
+		c.Assert(len(s.txs[1].Hashes), qt.Equals, 1)
+		nonces = append(nonces, s.txs[1].Nonce)
+		ts.mine(1)
+		c.Assert(s.submitResults(ctx), qt.IsNil)
+		c.Assert(len(s.txs), qt.Equals, 0)
+		_, err := ts.sqlite.ReadResultTx(1)
+		c.Assert(err, qt.Equals, db.ErrResultTxNotInDB)
+		attempts, lastErr, err := ts.sqlite.ReadResultTxError(1)
+		c.Assert(err, qt.IsNil)
+		c.Assert(attempts, qt.Equals, i)
+		c.Assert(lastErr, qt.Matches, "publishResult transaction .* reverted in block .*")
+	}
+	c.Assert(nonces[1], qt.Equals, nonces[0]+1)
+	c.Assert(nonces[2], qt.Equals, nonces[1]+1)
+	c.Assert(ts.processStatus(1), qt.Equals, ztypes.ProcessStatusPublishFailed)
+
+	c.Assert(s.submitResults(ctx), qt.IsNil)
+	c.Assert(len(s.txs), qt.Equals, 0)
+}
+
+// laggingBackend is a simulated backend whose pending nonce does not include
+// the pending transactions, like a web3 provider that has not received the
+// transactions sent through another one
+type laggingBackend struct {
+	*backends.SimulatedBackend
+}
+
+func (b *laggingBackend) PendingNonceAt(ctx context.Context, account common.Address) (
+	uint64, error) {
+	return b.SimulatedBackend.NonceAt(ctx, account, nil)
+}
+
+func TestSubmitterSharedNonces(t *testing.T) {
+	c := qt.New(t)
+	ts := newTestSimulated(c)
+	ctx := context.Background()
+	ts.mine(1) // block 2
+
+	// newNetworkSubmitter returns a Submitter with its own network in the
+	// db, like the Submitter of another contract, with a process in its
+	// results publishing window
+	nonces := NewNonces()
+	newNetworkSubmitter := func(i int) *Submitter {
+		netDB, err := ts.sqlite.WithNetwork(simulatedChainID, []byte{byte(i)})
+		c.Assert(err, qt.IsNil)
+		err = netDB.StoreProcess(1, []byte("censusRoot"), 100, 2, 3, 10, 20, 60, 1)
+		c.Assert(err, qt.IsNil)
+		c.Assert(netDB.StoreProof(1, testZKProof(1, 5678, 3, 2)), qt.IsNil)
+		c.Assert(netDB.UpdateProcessStatus(1, ztypes.ProcessStatusProofGenerated), qt.IsNil)
+		s, err := newSubmitter(&laggingBackend{ts.sim}, simulatedChainID, SubmitterOptions{
+			SQLite:       netDB,
+			ContractAddr: ts.client.contractAddr,
+			Key:          ts.key,
+			Nonces:       nonces,
+		})
+		c.Assert(err, qt.IsNil)
+		return s
+	}
+
+	// the Submitters that share the Nonces of the key send their
+	// transactions with consecutive nonces
+	var submitters []*Submitter
+	for i := 1; i <= 3; i++ {
+		s := newNetworkSubmitter(i)
+		c.Assert(s.submitResults(ctx), qt.IsNil)
+		c.Assert(len(s.txs[1].Hashes), qt.Equals, 1)
+		submitters = append(submitters, s)
+	}
+	c.Assert(submitters[1].txs[1].Nonce, qt.Equals, submitters[0].txs[1].Nonce+1)
+	c.Assert(submitters[2].txs[1].Nonce, qt.Equals, submitters[1].txs[1].Nonce+1)
+
+	// while the pending nonce of the backend is the one of the first
+	// transaction
+	pending, err := (&laggingBackend{ts.sim}).PendingNonceAt(ctx, ts.auth.From)
+	c.Assert(err, qt.IsNil)
+	c.Assert(pending, qt.Equals, submitters[0].txs[1].Nonce)
+
+	ts.mine(1)
+	for _, s := range submitters {
+		c.Assert(s.submitResults(ctx), qt.IsNil)
+		c.Assert(s.txs[1].included, qt.IsTrue)
+	}
+}
+
+func TestSubmitterWindowEnded(t *testing.T) {
+	c := qt.New(t)
+	ts := newTestSimulated(c)
+	s := ts.newTestSubmitter()
+
+	ts.createProcess(1, 2) // block 2, window [2, 12]
+	c.Assert(ts.client.syncHistory(), qt.IsNil)
+	ts.storeTestProof(1, 5678, 3, 2)
+	ts.mine(10) // blocks 3 to 12
+
+	c.Assert(s.submitResults(context.Background()), qt.IsNil)
+	c.Assert(len(s.txs), qt.Equals, 0)
+}
+
+func TestSubmitterMultiChoice(t *testing.T) {
+	c := qt.New(t)
+	ts := newTestSimulated(c)
+	s := ts.newTestSubmitter()
+
+	_, err := ts.contract.CreateProcess(ts.auth, big.NewInt(1), big.NewInt(0),
+		big.NewInt(1234), 100, 2, 10, 20, 60, ztypes.ProcessTypeMultiChoice|3)
+	c.Assert(err, qt.IsNil)
+	ts.sim.Commit() // block 2, window [2, 12]
+	c.Assert(ts.client.syncHistory(), qt.IsNil)
+	ts.storeTestProof(1, 5678, 3, 0)
+
+	// the result is not sent, as the contract does not take the results
+	// of each option
+	head, err := ts.sim.HeaderByNumber(context.Background(), nil)
+	c.Assert(err, qt.IsNil)
+	process, err := ts.sqlite.ReadProcessByID(1)
+	c.Assert(err, qt.IsNil)
+	err = s.submitResult(context.Background(), head, process)
+	c.Assert(err, qt.ErrorMatches,
+		`the results of the multi-choice processes \(type 0x83\) can not be published`)
+	c.Assert(len(s.txs), qt.Equals, 0)
+}
+
+func TestBumpGasPrice(t *testing.T) {
+	c := qt.New(t)
+
+	tx := &resultTx{ResultTx: ztypes.ResultTx{GasPrice: big.NewInt(100)}}
+	tx.bumpGasPrice(20)
+	c.Assert(tx.GasPrice.String(), qt.Equals, "120")
+	c.Assert(tx.GasTipCap, qt.IsNil)
+
+	// the gas price is always increased
+	tx = &resultTx{ResultTx: ztypes.ResultTx{GasTipCap: big.NewInt(1), GasFeeCap: big.NewInt(10)}}
+	tx.bumpGasPrice(20)
+	c.Assert(tx.GasTipCap.String(), qt.Equals, "2")
+	c.Assert(tx.GasFeeCap.String(), qt.Equals, "12")
+}
+
+func TestNewPublishResultCall(t *testing.T) {
+	c := qt.New(t)
+
+	zkProof := testZKProof(1, 5678, 3, 2)
+	call, err := newPublishResultCall(1, zkProof)
+	c.Assert(err, qt.IsNil)
+	c.Assert(call.receiptsRoot.String(), qt.Equals, "5678")
+	c.Assert(call.nVotes, qt.Equals, uint64(3))
+	c.Assert(call.result, qt.Equals, uint64(2))
+
+	zkProof.Proof.B = zkProof.Proof.B[:2]
+	_, err = newPublishResultCall(1, zkProof)
+	c.Assert(err, qt.ErrorMatches, "invalid Groth16 proof dimensions")
+
+	zkProof = testZKProof(1, 5678, 3, 2)
+	zkProof.Proof.C[1] = "c"
+	_, err = newPublishResultCall(1, zkProof)
+	c.Assert(err, qt.ErrorMatches, `can not parse proof coordinate "c"`)
+
+	zkProof = testZKProof(1, 5678, 3, 2)
+	zkProof.PublicInputs = zkProof.PublicInputs[:3]
+	_, err = newPublishResultCall(1, zkProof)
+	c.Assert(err, qt.ErrorMatches, `invalid number of public inputs \(3\)`)
+}
+
+func TestLoadKey(t *testing.T) {
+	c := qt.New(t)
+
+	account, err := keystore.StoreKey(c.TempDir(), "passphrase",
+		keystore.LightScryptN, keystore.LightScryptP)
+	c.Assert(err, qt.IsNil)
+	path := account.URL.Path
+
+	key, err := LoadKey(path, "passphrase")
+	c.Assert(err, qt.IsNil)
+	c.Assert(crypto.PubkeyToAddress(key.PublicKey), qt.Equals, account.Address)
+
+	_, err = LoadKey(path, "wrong")
+	c.Assert(err, qt.ErrorMatches, "can not decrypt keystore .*")
+
+	_, err = newSubmitter(nil, 1, SubmitterOptions{ContractAddr: common.Address{}})
+	c.Assert(err, qt.ErrorMatches, "Submitter error: no key set")
+}
@@ -0,0 +1,290 @@
+package eth
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// ensure that testChain implements the Backend interface
+var _ Backend = (*testChain)(nil)
+
+// testChain is a scripted chain that implements the Backend interface, which
+// allows to test the Client synchronization, including chain reorgs
+type testChain struct {
+	mu           sync.Mutex
+	contractAddr common.Address
+	// headers contains the canonical chain, where headers[i] is the block
+	// number i
+	headers []*types.Header
+	logs    map[common.Hash][]types.Log
+	// dropped contains the indexes of the logs of each block that are not
+	// returned by FilterLogs, simulating logs lost by the web3 provider
+	dropped map[common.Hash]map[uint]bool
+	// processes contains the contract state of the created processes, as
+	// the outputs of the processes view function
+	processes map[uint64][]interface{}
+	// callFails is the number of next CallContract calls that will fail
+	callFails int
+	// fork is increased at each reorg, so the new blocks have different
+	// hashes than the orphaned ones
+	fork byte
+	// maxRange simulates the block range limit of the web3 providers for
+	// FilterLogs, which is not limited if it is 0
+	maxRange uint64
+	// failBlock makes FilterLogs fail for the ranges that contain it, if
+	// it is not 0
+	failBlock uint64
+	// filterRanges contains the ranges of the successful FilterLogs
+	filterRanges [][2]uint64
+	// subs contains the active new heads subscriptions, and subscribeFails
+	// the number of next SubscribeNewHead calls that will fail
+	subs           []*testSub
+	subscribeFails int
+}
+
+// testSub is a new heads subscription of the testChain
+type testSub struct {
+	ch        chan<- *types.Header
+	errc      chan error
+	quit      chan struct{}
+	closeOnce sync.Once
+}
+
+// Unsubscribe implements the ethereum.Subscription interface
+func (s *testSub) Unsubscribe() {
+	s.closeOnce.Do(func() { close(s.quit) })
+}
+
+// Err implements the ethereum.Subscription interface
+func (s *testSub) Err() <-chan error {
+	return s.errc
+}
+
+func newTestChain(contractAddr common.Address) *testChain {
+	tc := &testChain{
+		contractAddr: contractAddr,
+		logs:         make(map[common.Hash][]types.Log),
+		dropped:      make(map[common.Hash]map[uint]bool),
+		processes:    make(map[uint64][]interface{}),
+	}
+	tc.headers = []*types.Header{{Number: big.NewInt(0)}}
+	return tc
+}
+
+// addBlock adds a new block to the canonical chain containing the given logs,
+// which are emitted by the contract
+func (tc *testChain) addBlock(logs ...types.Log) *types.Header {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	parent := tc.headers[len(tc.headers)-1]
+	header := &types.Header{
+		ParentHash: parent.Hash(),
+		Number:     new(big.Int).Add(parent.Number, big.NewInt(1)),
+		Extra:      []byte{tc.fork},
+	}
+	tc.headers = append(tc.headers, header)
+	for i, l := range logs {
+		l.Address = tc.contractAddr
+		l.BlockNumber = header.Number.Uint64()
+		l.BlockHash = header.Hash()
+		l.Index = uint(i)
+		tc.logs[header.Hash()] = append(tc.logs[header.Hash()], l)
+		tc.storeProcess(l)
+	}
+	for _, sub := range tc.subs {
+		go func(sub *testSub) {
+			select {
+			case sub.ch <- header:
+			case <-sub.quit:
+			}
+		}(sub)
+	}
+	return header
+}
+
+// storeProcess stores the contract state of the process created by the given
+// log, if it is a valid process creation event
+func (tc *testChain) storeProcess(l types.Log) {
+	event := contractABI.Events[eventProcessCreatedName]
+	if len(l.Topics) == 0 || l.Topics[0] != event.ID {
+		return
+	}
+	args, err := event.Inputs.Unpack(l.Data)
+	if err != nil {
+		return
+	}
+	id, ok := args[1].(*big.Int)
+	if !ok || !id.IsUint64() {
+		return
+	}
+	// the outputs of processes are the event arguments without the id
+	tc.processes[id.Uint64()] = append([]interface{}{args[0]}, args[2:]...)
+}
+
+// setProcessCensusSize modifies the censusSize of the contract state of the
+// given process, so it does not match its creation event
+func (tc *testChain) setProcessCensusSize(processID, censusSize uint64) {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	if p, ok := tc.processes[processID]; ok {
+		p[3] = censusSize
+	}
+}
+
+// dropLog makes FilterLogs not return the log with the given index of the
+// given block
+func (tc *testChain) dropLog(blockHash common.Hash, index uint) {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	if tc.dropped[blockHash] == nil {
+		tc.dropped[blockHash] = make(map[uint]bool)
+	}
+	tc.dropped[blockHash][index] = true
+}
+
+// addBlocks adds n empty blocks to the canonical chain
+func (tc *testChain) addBlocks(n int) {
+	for i := 0; i < n; i++ {
+		tc.addBlock()
+	}
+}
+
+// reorg orphans the blocks after the given block number, so the next added
+// blocks build a new chain on top of it
+func (tc *testChain) reorg(blockNum uint64) {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	tc.headers = tc.headers[:blockNum+1]
+	tc.fork++
+}
+
+// disconnect fails the active subscriptions with the given error, and makes
+// the next subscribeFails SubscribeNewHead calls fail
+func (tc *testChain) disconnect(err error, subscribeFails int) {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	for _, sub := range tc.subs {
+		sub.errc <- err
+	}
+	tc.subs = nil
+	tc.subscribeFails = subscribeFails
+}
+
+// reconnect makes the next SubscribeNewHead calls succeed
+func (tc *testChain) reconnect() {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	tc.subscribeFails = 0
+}
+
+func (tc *testChain) head() *types.Header {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	return tc.headers[len(tc.headers)-1]
+}
+
+// HeaderByNumber implements the Backend interface
+func (tc *testChain) HeaderByNumber(ctx context.Context, number *big.Int) (
+	*types.Header, error) {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	if number == nil {
+		return tc.headers[len(tc.headers)-1], nil
+	}
+	if number.Uint64() >= uint64(len(tc.headers)) {
+		return nil, ethereum.NotFound
+	}
+	return tc.headers[number.Uint64()], nil
+}
+
+// FilterLogs implements the Backend interface
+func (tc *testChain) FilterLogs(ctx context.Context, q ethereum.FilterQuery) (
+	[]types.Log, error) {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	from, to := q.FromBlock.Uint64(), q.ToBlock.Uint64()
+	if to >= uint64(len(tc.headers)) {
+		return nil, fmt.Errorf("toBlock %d is after the head block", to)
+	}
+	if tc.maxRange != 0 && to-from+1 > tc.maxRange {
+		return nil, fmt.Errorf("block range is too large, max: %d", tc.maxRange)
+	}
+	if tc.failBlock != 0 && from <= tc.failBlock && tc.failBlock <= to {
+		return nil, fmt.Errorf("connection refused")
+	}
+	tc.filterRanges = append(tc.filterRanges, [2]uint64{from, to})
+	var logs []types.Log
+	for i := from; i <= to; i++ {
+		hash := tc.headers[i].Hash()
+		for _, l := range tc.logs[hash] {
+			if tc.dropped[hash][l.Index] {
+				continue
+			}
+			for _, addr := range q.Addresses {
+				if l.Address == addr {
+					logs = append(logs, l)
+				}
+			}
+		}
+	}
+	return logs, nil
+}
+
+// CodeAt implements the Backend interface, returning a non empty code for the
+// contract address
+func (tc *testChain) CodeAt(ctx context.Context, contract common.Address,
+	blockNumber *big.Int) ([]byte, error) {
+	if contract != tc.contractAddr {
+		return nil, nil
+	}
+	return []byte{0x00}, nil
+}
+
+// CallContract implements the Backend interface, answering the calls to the
+// processes view function of the contract
+func (tc *testChain) CallContract(ctx context.Context, call ethereum.CallMsg,
+	blockNumber *big.Int) ([]byte, error) {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	if tc.callFails > 0 {
+		tc.callFails--
+		return nil, fmt.Errorf("connection refused")
+	}
+	method := contractABI.Methods[methodProcessesName]
+	if call.To == nil || *call.To != tc.contractAddr || len(call.Data) < 4 ||
+		!bytes.Equal(call.Data[:4], method.ID) {
+		return nil, fmt.Errorf("execution reverted")
+	}
+	args, err := method.Inputs.Unpack(call.Data[4:])
+	if err != nil {
+		return nil, err
+	}
+	p, ok := tc.processes[args[0].(*big.Int).Uint64()]
+	if !ok {
+		// the contract returns the zero values for unknown processes
+		p = []interface{}{common.Address{}, big.NewInt(0), big.NewInt(0),
+			uint64(0), uint64(0), uint64(0), uint8(0), uint8(0), uint8(0)}
+	}
+	return method.Outputs.Pack(p...)
+}
+
+// SubscribeNewHead implements the Backend interface
+func (tc *testChain) SubscribeNewHead(ctx context.Context, ch chan<- *types.Header) (
+	ethereum.Subscription, error) {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	if tc.subscribeFails > 0 {
+		tc.subscribeFails--
+		return nil, fmt.Errorf("connection refused")
+	}
+	sub := &testSub{ch: ch, errc: make(chan error, 1), quit: make(chan struct{})}
+	tc.subs = append(tc.subs, sub)
+	return sub, nil
+}
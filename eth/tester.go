@@ -2,63 +2,111 @@ package eth
 
 import (
 	"github.com/aragon/zkmultisig-node/db"
-	"github.com/aragon/zkmultisig-node/types"
+	ztypes "github.com/aragon/zkmultisig-node/types"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
 )
 
 // ensure that TestEthClient implements the eth.ClientInterf interface
 var _ ClientInterf = (*TestEthClient)(nil)
 
-// TestEthClient simulates an EthReader for testing purposes
+const (
+	// TestChainID is the ChainID of the chain simulated by the
+	// TestEthClient
+	TestChainID = 3
+	// TestContractAddr is the zkMultisig contract address in the chain
+	// simulated by the TestEthClient
+	TestContractAddr = "0x79ea1cc5B8BFF0F46E1B98068727Fd02D8EB1aF3"
+)
+
+// TestEthClient simulates an EthReader for testing purposes. It runs a
+// Client over a scripted chain, so the simulated contract events are
+// processed by the same code that processes the real ones.
 type TestEthClient struct {
-	db              *db.SQLite
-	currentBlock    uint64
+	client          *Client
+	chain           *testChain
+	startBlock      uint64
 	blocksWithEvent map[uint64][]TestEvent
 }
 
 // TestEvent is used to simulate creation of new processes in the SmartContract
-type TestEvent types.Process
+type TestEvent ztypes.Process
 
-// NewTestEthClient returns a new TestEthClient with the given configuration
+// NewTestEthClient returns a new TestEthClient with the given configuration,
+// where the given blocks contain the processes created at each block number
 func NewTestEthClient(sqlite *db.SQLite, startBlock uint64,
 	blocks map[uint64][]TestEvent) *TestEthClient {
-	return &TestEthClient{db: sqlite, currentBlock: startBlock, blocksWithEvent: blocks}
+	contractAddr := common.HexToAddress(TestContractAddr)
+	chain := newTestChain(contractAddr)
+	chain.addBlocks(int(startBlock))
+	client := newClient(chain, TestChainID, Options{SQLite: sqlite, ContractAddr: contractAddr})
+	return &TestEthClient{client: client, chain: chain, startBlock: startBlock,
+		blocksWithEvent: blocks}
+}
+
+// CurrentBlock returns the current block number of the simulated chain
+func (e *TestEthClient) CurrentBlock() uint64 {
+	return e.chain.head().Number.Uint64()
 }
 
-// AdvanceBlock simulates the advance of the EthBlockNum in the TestEthClient
+// AdvanceBlock simulates the advance of the EthBlockNum in the TestEthClient,
+// adding a block with the processes created at its block number
 func (e *TestEthClient) AdvanceBlock() error {
-	e.currentBlock++
-	events, ok := e.blocksWithEvent[e.currentBlock]
-	if ok {
-		// simulate event from the SmartContract, and store the process
-		// into the db
-		for i := 0; i < len(events); i++ {
-			err := e.db.StoreProcess(events[i].ID,
-				events[i].CensusRoot, events[i].CensusSize,
-				e.currentBlock, events[i].ResPubStartBlock,
-				events[i].ResPubWindow, events[i].MinParticipation,
-				events[i].MinPositiveVotes, events[i].Type)
-			if err != nil {
-				return err
-			}
-		}
+	var events []ScenarioEvent
+	for _, event := range e.blocksWithEvent[e.CurrentBlock()+1] {
+		events = append(events, ProcessCreated(ztypes.Process(event)))
 	}
+	return e.RunScenario(NewScenario().Block(events...))
+}
 
-	// TODO better to do this in a single SQL query (get & update)
-	// get processes that end at this block and update their status
-	processes, err := e.db.ReadProcessesByResPubStartBlock(e.currentBlock)
-	if err != nil {
+// RunScenario runs the steps of the given Scenario, synchronizing the db after
+// each step
+func (e *TestEthClient) RunScenario(s *Scenario) error {
+	if err := e.initMeta(); err != nil {
 		return err
 	}
-	for i := 0; i < len(processes); i++ {
-		err = e.db.UpdateProcessStatus(processes[i].ID, types.ProcessStatusFrozen)
-		if err != nil {
+	for _, step := range s.Steps {
+		if err := e.runStep(step); err != nil {
 			return err
 		}
 	}
-
 	return nil
 }
 
+func (e *TestEthClient) runStep(step ScenarioStep) error {
+	if step.Reorg != nil {
+		e.chain.reorg(*step.Reorg)
+	}
+	e.chain.addBlocks(int(step.Advance))
+	if len(step.Events) > 0 {
+		logs := make([]types.Log, len(step.Events))
+		for i, event := range step.Events {
+			l, err := event.log()
+			if err != nil {
+				return err
+			}
+			logs[i] = l
+		}
+		header := e.chain.addBlock(logs...)
+		for i, event := range step.Events {
+			if event.Dropped {
+				e.chain.dropLog(header.Hash(), uint(i))
+			}
+		}
+	}
+	return e.client.syncHead(e.chain.head())
+}
+
+// initMeta initializes the meta of the db if it is not initialized yet,
+// starting to sync after the startBlock
+func (e *TestEthClient) initMeta() error {
+	_, err := e.client.db.GetLastSyncBlockNum()
+	if err == db.ErrMetaNotInDB {
+		return e.client.db.InitMeta(TestChainID, e.startBlock)
+	}
+	return err
+}
+
 // Start implements the EthReader.Start method of the interface
 func (e *TestEthClient) Start(fromBlock uint64) error {
 	return nil
@@ -9,6 +9,7 @@ import (
 	"github.com/aragon/zkmultisig-node/types"
 	qt "github.com/frankban/quicktest"
 	_ "github.com/mattn/go-sqlite3"
+	"github.com/vocdoni/arbo"
 )
 
 func TestAdvanceBlock(t *testing.T) {
@@ -61,9 +62,13 @@ func TestAdvanceBlock(t *testing.T) {
 	c.Assert(err, qt.IsNil)
 	c.Assert(len(processes), qt.Equals, 2)
 
-	// check that the obtained processes match the introduced values
+	// check that the obtained processes match the introduced values, where
+	// the CensusRoot is stored as the 32 bytes emitted by the contract
+	censusRoot32 := func(root []byte) []byte {
+		return arbo.BigIntToBytes(32, arbo.BytesToBigInt(root))
+	}
 	c.Assert(processes[0].ID, qt.Equals, events[1001][0].ID)
-	c.Assert(processes[0].CensusRoot, qt.DeepEquals, events[1001][0].CensusRoot)
+	c.Assert(processes[0].CensusRoot, qt.DeepEquals, censusRoot32(events[1001][0].CensusRoot))
 	c.Assert(processes[0].CensusSize, qt.DeepEquals, events[1001][0].CensusSize)
 	c.Assert(processes[0].ResPubStartBlock, qt.Equals, events[1001][0].ResPubStartBlock)
 	c.Assert(processes[0].ResPubWindow, qt.Equals, events[1001][0].ResPubWindow)
@@ -71,7 +76,7 @@ func TestAdvanceBlock(t *testing.T) {
 	c.Assert(processes[0].MinPositiveVotes, qt.Equals, events[1001][0].MinPositiveVotes)
 	c.Assert(processes[0].Status, qt.Equals, types.ProcessStatusOn)
 	c.Assert(processes[1].ID, qt.Equals, events[1002][0].ID)
-	c.Assert(processes[1].CensusRoot, qt.DeepEquals, events[1002][0].CensusRoot)
+	c.Assert(processes[1].CensusRoot, qt.DeepEquals, censusRoot32(events[1002][0].CensusRoot))
 	c.Assert(processes[1].CensusSize, qt.DeepEquals, events[1002][0].CensusSize)
 	c.Assert(processes[1].ResPubStartBlock, qt.Equals, events[1002][0].ResPubStartBlock)
 	c.Assert(processes[1].ResPubWindow, qt.Equals, events[1002][0].ResPubWindow)
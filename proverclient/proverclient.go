@@ -0,0 +1,341 @@
+package proverclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/aragon/zkmultisig-node/types"
+	"go.vocdoni.io/dvote/log"
+)
+
+const (
+	defaultRequestTimeout = 30 * time.Second
+	defaultPollTime       = 5 * time.Second
+	defaultMaxPollTime    = 1 * time.Minute
+	defaultProofTimeout   = 60 * time.Minute
+)
+
+var (
+	// ErrProverBusy is returned when the prover-server replies that it is
+	// busy generating another proof (http.StatusLocked)
+	ErrProverBusy = errors.New("prover busy")
+	// ErrProofNotFound is returned when the prover-server does not have a
+	// proof generation job for the requested id
+	ErrProofNotFound = errors.New("proof not found")
+	// ErrProofNotReady is returned when the proof generation job exists
+	// but the proof has not been generated yet (http.StatusAccepted)
+	ErrProofNotReady = errors.New("proof not ready")
+)
+
+// Client is an http client of the prover-server API
+type Client struct {
+	url        string
+	circuit    string
+	httpClient *http.Client
+
+	pollTime     time.Duration
+	maxPollTime  time.Duration
+	proofTimeout time.Duration
+}
+
+// Options is used to pass the parameters to load a new Client
+type Options struct {
+	// URL of the prover-server API
+	URL string
+	// Circuit is the name of the prover-server circuit used to generate
+	// the proofs. If empty, the prover-server uses its only circuit
+	Circuit string
+	// RequestTimeout sets the timeout of each http request
+	RequestTimeout time.Duration
+	// PollTime sets the initial time to wait between each poll to the
+	// prover-server. It is doubled at each poll, up to MaxPollTime
+	PollTime    time.Duration
+	MaxPollTime time.Duration
+	// ProofTimeout sets the maximum time to wait for a proof to be
+	// generated (or for the prover to be ready to accept a proof request)
+	ProofTimeout time.Duration
+}
+
+// StatusResponse is the response of the prover-server /status endpoint
+type StatusResponse struct {
+	Status string `json:"status"`
+}
+
+// JobStatus is the response of the prover-server /proof/:id/status endpoint
+type JobStatus struct {
+	ID    string `json:"id"`
+	State string `json:"state"`
+	// Error contains the error output of the job when State is failed
+	Error      string    `json:"error,omitempty"`
+	QueuedAt   time.Time `json:"queuedAt"`
+	StartedAt  time.Time `json:"startedAt,omitempty"`
+	ProvingAt  time.Time `json:"provingAt,omitempty"`
+	FinishedAt time.Time `json:"finishedAt,omitempty"`
+}
+
+// CircuitInfo contains the name and meta of a circuit available in the
+// prover-server
+type CircuitInfo struct {
+	Name      string `json:"name"`
+	NMaxVotes int    `json:"nMaxVotes"`
+	NLevels   int    `json:"nLevels"`
+	// NOptions is only set in the circuits of multi-choice processes
+	NOptions int `json:"nOptions,omitempty"`
+}
+
+// genProofResponse is the response of the prover-server /proof endpoint
+type genProofResponse struct {
+	ID json.RawMessage `json:"id"`
+}
+
+// errorResponse is the json returned by the prover-server on errors
+type errorResponse struct {
+	Message string `json:"message"`
+}
+
+// New returns a new Client with the given Options. Zero values of the
+// Options durations are replaced by default values.
+func New(opts Options) (*Client, error) {
+	if opts.URL == "" {
+		return nil, fmt.Errorf("prover url not set")
+	}
+	if opts.RequestTimeout == 0 {
+		opts.RequestTimeout = defaultRequestTimeout
+	}
+	if opts.PollTime == 0 {
+		opts.PollTime = defaultPollTime
+	}
+	if opts.MaxPollTime == 0 {
+		opts.MaxPollTime = defaultMaxPollTime
+	}
+	if opts.ProofTimeout == 0 {
+		opts.ProofTimeout = defaultProofTimeout
+	}
+
+	return &Client{
+		url:          strings.TrimSuffix(opts.URL, "/"),
+		circuit:      opts.Circuit,
+		httpClient:   &http.Client{Timeout: opts.RequestTimeout},
+		pollTime:     opts.PollTime,
+		maxPollTime:  opts.MaxPollTime,
+		proofTimeout: opts.ProofTimeout,
+	}, nil
+}
+
+// Circuit returns the name of the prover-server circuit used to generate the
+// proofs, which is empty if the prover-server uses its only circuit
+func (c *Client) Circuit() string {
+	return c.circuit
+}
+
+// Status returns the status of the prover-server. If the prover is busy,
+// returns ErrProverBusy.
+func (c *Client) Status() (*StatusResponse, error) {
+	resp, err := c.httpClient.Get(c.url + "/status")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	body, err := readResponse(resp)
+	if err != nil {
+		return nil, err
+	}
+	var status StatusResponse
+	if err := json.Unmarshal(body, &status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// Circuits returns the circuits available in the prover-server
+func (c *Client) Circuits() ([]CircuitInfo, error) {
+	resp, err := c.httpClient.Get(c.url + "/circuits")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	body, err := readResponse(resp)
+	if err != nil {
+		return nil, err
+	}
+	var circuits []CircuitInfo
+	if err := json.Unmarshal(body, &circuits); err != nil {
+		return nil, err
+	}
+	return circuits, nil
+}
+
+// GenProof sends the given ZKInputs to the prover-server, and returns the id
+// of the proof generation job. If the prover is busy, it retries until the
+// ProofTimeout is reached.
+func (c *Client) GenProof(zki *types.ZKInputs) (string, error) {
+	zkiBytes, err := json.Marshal(zki)
+	if err != nil {
+		return "", err
+	}
+
+	var id string
+	err = c.poll(func() (bool, error) {
+		id, err = c.genProof(zkiBytes)
+		if err == ErrProverBusy {
+			log.Debugf("prover busy, retrying")
+			return false, nil
+		}
+		return err == nil, err
+	})
+	return id, err
+}
+
+func (c *Client) genProof(zkiBytes []byte) (string, error) {
+	proofURL := c.url + "/proof"
+	if c.circuit != "" {
+		proofURL += "?circuit=" + url.QueryEscape(c.circuit)
+	}
+	resp, err := c.httpClient.Post(proofURL, "application/json",
+		bytes.NewReader(zkiBytes))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	body, err := readResponse(resp)
+	if err != nil {
+		return "", err
+	}
+	var r genProofResponse
+	if err := json.Unmarshal(body, &r); err != nil {
+		return "", err
+	}
+	// the id can be returned either as a json number or as a json string
+	id := strings.Trim(string(r.ID), `"`)
+	if id == "" {
+		return "", fmt.Errorf("prover returned an empty proof id")
+	}
+	return id, nil
+}
+
+// JobStatus returns the status of the proof generation job of the given id
+func (c *Client) JobStatus(id string) (*JobStatus, error) {
+	resp, err := c.httpClient.Get(c.url + "/proof/" + url.PathEscape(id) + "/status")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	body, err := readResponse(resp)
+	if err != nil {
+		return nil, err
+	}
+	var status JobStatus
+	if err := json.Unmarshal(body, &status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// GetProof returns the zkProof for the given proof generation job id. If the
+// proof is not ready yet, returns ErrProofNotReady.
+func (c *Client) GetProof(id string) (*types.ZKProof, error) {
+	resp, err := c.httpClient.Get(c.url + "/proof/" + url.PathEscape(id))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	body, err := readResponse(resp)
+	if err != nil {
+		return nil, err
+	}
+	var zkProof types.ZKProof
+	if err := json.Unmarshal(body, &zkProof); err != nil {
+		return nil, fmt.Errorf("can not decode proof %s: %s", id, err)
+	}
+	if len(zkProof.Proof.A) == 0 || len(zkProof.Proof.B) == 0 ||
+		len(zkProof.Proof.C) == 0 {
+		return nil, fmt.Errorf("prover returned an incomplete proof for id %s", id)
+	}
+	return &zkProof, nil
+}
+
+// WaitProof polls the prover-server until the zkProof of the given id is
+// generated, and returns it. If the ProofTimeout is reached, returns an error.
+func (c *Client) WaitProof(id string) (*types.ZKProof, error) {
+	var zkProof *types.ZKProof
+	err := c.poll(func() (bool, error) {
+		var err error
+		zkProof, err = c.GetProof(id)
+		if err == ErrProofNotReady {
+			return false, nil
+		}
+		return err == nil, err
+	})
+	return zkProof, err
+}
+
+// ComputeProof sends the given ZKInputs to the prover-server, and waits
+// until the zkProof is generated
+func (c *Client) ComputeProof(zki *types.ZKInputs) (*types.ZKProof, error) {
+	id, err := c.GenProof(zki)
+	if err != nil {
+		return nil, err
+	}
+	return c.WaitProof(id)
+}
+
+// poll calls the given function until it returns true or an error, waiting
+// between calls with an exponential backoff. If the ProofTimeout is reached,
+// returns an error.
+func (c *Client) poll(f func() (bool, error)) error {
+	deadline := time.Now().Add(c.proofTimeout)
+	wait := c.pollTime
+	for {
+		done, err := f()
+		if err != nil {
+			return err
+		}
+		if done {
+			return nil
+		}
+		if time.Now().Add(wait).After(deadline) {
+			return fmt.Errorf("prover timeout (%s) reached", c.proofTimeout)
+		}
+		time.Sleep(wait)
+		wait *= 2
+		if wait > c.maxPollTime {
+			wait = c.maxPollTime
+		}
+	}
+}
+
+// readResponse reads the body of the given http.Response, returning an error
+// if the status code is not http.StatusOK
+func readResponse(resp *http.Response) ([]byte, error) {
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return body, nil
+	case http.StatusAccepted:
+		return nil, ErrProofNotReady
+	case http.StatusLocked:
+		return nil, ErrProverBusy
+	case http.StatusNotFound:
+		return nil, ErrProofNotFound
+	}
+	var errResp errorResponse
+	if err := json.Unmarshal(body, &errResp); err == nil && errResp.Message != "" {
+		return nil, fmt.Errorf("prover error (%d): %s", resp.StatusCode, errResp.Message)
+	}
+	return nil, fmt.Errorf("prover error (%d): %s", resp.StatusCode, body)
+}
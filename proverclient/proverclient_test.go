@@ -0,0 +1,151 @@
+package proverclient
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aragon/zkmultisig-node/types"
+	qt "github.com/frankban/quicktest"
+)
+
+// testProverServer simulates the prover-server API, being busy during the
+// first nBusy requests to /proof, and not having the proof ready during the
+// first nPending requests to /proof/:id
+type testProverServer struct {
+	nBusy    int
+	nPending int
+	zkProof  types.ZKProof
+}
+
+func (s *testProverServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.URL.Path == "/status":
+		_ = json.NewEncoder(w).Encode(StatusResponse{Status: "ok"})
+	case r.URL.Path == "/circuits":
+		_ = json.NewEncoder(w).Encode([]CircuitInfo{{Name: "c4", NMaxVotes: 4, NLevels: 3}})
+	case r.URL.Path == "/proof" && r.Method == http.MethodPost:
+		if r.URL.Query().Get("circuit") != "c4" {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(errorResponse{Message: "circuit not found"})
+			return
+		}
+		if s.nBusy > 0 {
+			s.nBusy--
+			w.WriteHeader(http.StatusLocked)
+			_ = json.NewEncoder(w).Encode(StatusResponse{Status: "prover busy"})
+			return
+		}
+		var zki map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&zki); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(errorResponse{Message: err.Error()})
+			return
+		}
+		_, _ = w.Write([]byte(`{"id":"a1b2"}`))
+	case r.URL.Path == "/proof/a1b2/status":
+		_ = json.NewEncoder(w).Encode(JobStatus{ID: "a1b2", State: "proving"})
+	case r.URL.Path == "/proof/a1b2":
+		if s.nPending > 0 {
+			s.nPending--
+			w.WriteHeader(http.StatusAccepted)
+			_ = json.NewEncoder(w).Encode(JobStatus{ID: "a1b2", State: "queued"})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(s.zkProof)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func newTestClient(c *qt.C, s *testProverServer) *Client {
+	server := httptest.NewServer(s)
+	c.Cleanup(server.Close)
+
+	client, err := New(Options{
+		URL:          server.URL,
+		Circuit:      "c4",
+		PollTime:     time.Millisecond,
+		MaxPollTime:  4 * time.Millisecond,
+		ProofTimeout: time.Second,
+	})
+	c.Assert(err, qt.IsNil)
+	return client
+}
+
+func TestComputeProof(t *testing.T) {
+	c := qt.New(t)
+
+	s := &testProverServer{
+		nBusy:    2,
+		nPending: 3,
+		zkProof: types.ZKProof{
+			Proof: types.Groth16Proof{
+				A:        []string{"1", "2", "1"},
+				B:        [][]string{{"1", "2"}, {"3", "4"}, {"1", "0"}},
+				C:        []string{"5", "6", "1"},
+				Protocol: "groth16",
+			},
+			PublicInputs: []string{"3", "123"},
+		},
+	}
+	client := newTestClient(c, s)
+
+	status, err := client.Status()
+	c.Assert(err, qt.IsNil)
+	c.Assert(status.Status, qt.Equals, "ok")
+
+	_, err = client.GetProof("a1b2")
+	c.Assert(err, qt.Equals, ErrProofNotReady)
+
+	circuits, err := client.Circuits()
+	c.Assert(err, qt.IsNil)
+	c.Assert(circuits, qt.DeepEquals, []CircuitInfo{{Name: "c4", NMaxVotes: 4, NLevels: 3}})
+
+	jobStatus, err := client.JobStatus("a1b2")
+	c.Assert(err, qt.IsNil)
+	c.Assert(jobStatus.State, qt.Equals, "proving")
+
+	zki := types.NewZKInputs(4, 3)
+	zkProof, err := client.ComputeProof(zki)
+	c.Assert(err, qt.IsNil)
+	c.Assert(*zkProof, qt.DeepEquals, s.zkProof)
+	c.Assert(s.nBusy, qt.Equals, 0)
+	c.Assert(s.nPending, qt.Equals, 0)
+}
+
+func TestWaitProofTimeout(t *testing.T) {
+	c := qt.New(t)
+
+	client := newTestClient(c, &testProverServer{nPending: 1000})
+	client.proofTimeout = 20 * time.Millisecond
+
+	_, err := client.WaitProof("a1b2")
+	c.Assert(err, qt.ErrorMatches, "prover timeout .* reached")
+
+	// unknown ids are not polled
+	_, err = client.WaitProof("c3d4")
+	c.Assert(err, qt.Equals, ErrProofNotFound)
+}
+
+func TestJobIDEscaped(t *testing.T) {
+	c := qt.New(t)
+
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.EscapedPath())
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	c.Cleanup(server.Close)
+	client, err := New(Options{URL: server.URL})
+	c.Assert(err, qt.IsNil)
+
+	// the job ids returned by the prover-server are escaped in the path
+	_, err = client.JobStatus("a1/b2?c")
+	c.Assert(err, qt.Equals, ErrProofNotFound)
+	_, err = client.GetProof("a1/b2?c")
+	c.Assert(err, qt.Equals, ErrProofNotFound)
+	c.Assert(paths, qt.DeepEquals, []string{"/proof/a1%2Fb2%3Fc/status", "/proof/a1%2Fb2%3Fc"})
+}
@@ -60,14 +60,22 @@ func GenVotes(c *qt.C, cens *Census, chainID, processID uint64, ratio int) []typ
 	if ratio >= 100 { //nolint:gomnd
 		panic(fmt.Errorf("ratio can not be >=100, ratio: %d", ratio))
 	}
-	var votes []types.VotePackage
+	values := make([]uint64, len(cens.Keys.PrivateKeys))
 	nPosVotes := int(math.Ceil(float64(len(cens.Keys.PrivateKeys)) * (float64(ratio) / 100)))
+	for i := 0; i < nPosVotes; i++ {
+		values[i] = 1
+	}
+	return GenVotesWithValues(c, cens, chainID, processID, values)
+}
+
+// GenVotesWithValues generate the votes from the given Census, where the vote
+// of each key has the value of the same position in the given values
+func GenVotesWithValues(c *qt.C, cens *Census, chainID, processID uint64,
+	values []uint64) []types.VotePackage {
+	var votes []types.VotePackage
 	l := arbo.HashFunctionPoseidon.Len()
-	for i := 0; i < len(cens.Keys.PrivateKeys); i++ {
-		voteBytes := make([]byte, l)
-		if i < nPosVotes {
-			voteBytes = arbo.BigIntToBytes(l, big.NewInt(1))
-		}
+	for i := 0; i < len(values); i++ {
+		voteBytes := arbo.BigIntToBytes(l, new(big.Int).SetUint64(values[i]))
 		msgToSign, err := types.HashVote(chainID, processID, voteBytes)
 		c.Assert(err, qt.IsNil)
 		sigUncomp := cens.Keys.PrivateKeys[i].SignPoseidon(msgToSign)
@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"math/big"
+	"strconv"
 	"time"
 
 	"github.com/iden3/go-iden3-crypto/babyjub"
@@ -32,6 +33,50 @@ var (
 	// ProcessStatusProofGenerated indicates that the process is finished,
 	// and the zkProof is already generated
 	ProcessStatusProofGenerated ProcessStatus = 3
+	// ProcessStatusProofFailed indicates that the zkProof generation has
+	// failed more times than the allowed number of attempts, and it will
+	// not be retried
+	ProcessStatusProofFailed ProcessStatus = 4
+	// ProcessStatusResultsPublished indicates that the results of the
+	// process have been published in the SmartContract
+	ProcessStatusResultsPublished ProcessStatus = 5
+	// ProcessStatusClosedSuccess indicates that the process has been
+	// closed in the SmartContract, and that it succeeded
+	ProcessStatusClosedSuccess ProcessStatus = 6
+	// ProcessStatusClosedFail indicates that the process has been closed
+	// in the SmartContract, and that it did not succeed
+	ProcessStatusClosedFail ProcessStatus = 7
+	// ProcessStatusQuarantined indicates that the process data of its
+	// creation event does not match the one returned by the SmartContract,
+	// so the process does not accept votes and its zkProof is not generated
+	ProcessStatusQuarantined ProcessStatus = 8
+	// ProcessStatusProofExpired indicates that the zkProof of the process
+	// is not generated, as it is estimated that it can not be generated
+	// before the end of the results publishing window
+	ProcessStatusProofExpired ProcessStatus = 9
+	// ProcessStatusPublishFailed indicates that the transactions that
+	// publish the result of the process in the SmartContract have been
+	// reverted more times than the allowed number of attempts, and they
+	// will not be retried
+	ProcessStatusPublishFailed ProcessStatus = 10
+)
+
+const (
+	// ProcessTypeMultisig is the Type of the multisig processes, in which
+	// the vote value is 0 or 1
+	ProcessTypeMultisig uint8 = 0
+	// ProcessTypeReferendum is the Type of the referendum processes, in
+	// which the vote value is 0 or 1
+	ProcessTypeReferendum uint8 = 1
+	// ProcessTypeMultiChoice flags the Type of the multi-choice processes,
+	// whose lower bits contain the number of options, so the Type of a
+	// process of 3 options is ProcessTypeMultiChoice|3. The vote value is
+	// the index of the chosen option. The zkMultisig contract only
+	// publishes the result of the processes with a 0 or 1 vote value, so
+	// the results of the multi-choice processes are not published.
+	ProcessTypeMultiChoice uint8 = 0x80
+	// minOptions is the minimum number of options of a multi-choice process
+	minOptions = 2
 )
 
 // ByteArray is a type alias over []byte to implement custom json marshalers in
@@ -101,7 +146,8 @@ type Process struct {
 	// MinPositiveVotes sets a threshold of minimum votes supporting the
 	// proposal, over all the processed votes (% over nVotes)
 	MinPositiveVotes uint8
-	// Type of process, where 0: multisig, 1: referendum
+	// Type of process, where 0: multisig, 1: referendum, and
+	// ProcessTypeMultiChoice|nOptions: multi-choice of nOptions options
 	Type uint8
 	// InsertedDatetime contains the datetime of when the process was
 	// inserted in the db
@@ -110,6 +156,85 @@ type Process struct {
 	Status ProcessStatus
 }
 
+// ProcessResult contains the result of a process published in the
+// SmartContract
+type ProcessResult struct {
+	ProcessID uint64
+	// Publisher is the Ethereum address that published the result
+	Publisher    ByteArray
+	ReceiptsRoot ByteArray
+	Result       uint64
+	NVotes       uint64
+	// EthBlockNum indicates at which Ethereum block number the result has
+	// been published
+	EthBlockNum uint64
+	// Mismatch is set when the published result differs from the one
+	// computed by this node, which is contained in the public inputs of
+	// its zkProof
+	Mismatch         bool
+	InsertedDatetime time.Time
+}
+
+// MatchesProof returns true if the ReceiptsRoot, NVotes and Result of the
+// ProcessResult are the ones of the public inputs of the given zkProof
+func (r *ProcessResult) MatchesProof(zkProof *ZKProof) bool {
+	if len(zkProof.PublicInputs) <= resultIdx {
+		return false
+	}
+	return zkProof.PublicInputs[receiptsRootIdx] ==
+		arbo.BytesToBigInt(r.ReceiptsRoot).String() &&
+		zkProof.PublicInputs[nVotesIdx] == strconv.FormatUint(r.NVotes, 10) &&
+		zkProof.PublicInputs[resultIdx] == strconv.FormatUint(r.Result, 10)
+}
+
+// ResultTx contains the publishResult transactions sent by the node for a
+// process, which share the nonce, so only one of them can be included
+type ResultTx struct {
+	Nonce    uint64 `json:"nonce"`
+	GasLimit uint64 `json:"gasLimit"`
+	// GasPrice is used by the legacy transactions, and GasTipCap and
+	// GasFeeCap by the EIP-1559 transactions
+	GasPrice  *big.Int `json:"gasPrice,omitempty"`
+	GasTipCap *big.Int `json:"gasTipCap,omitempty"`
+	GasFeeCap *big.Int `json:"gasFeeCap,omitempty"`
+	// Hashes contains the hashes of all the sent transactions, as any of
+	// them may be included
+	Hashes []ByteArray `json:"hashes"`
+	// SentBlock is the number of the head block when the last transaction
+	// was sent
+	SentBlock uint64 `json:"sentBlock"`
+}
+
+// EthBlock contains the number and hash of a synchronized Ethereum block, used
+// to detect chain reorgs
+type EthBlock struct {
+	Number uint64
+	Hash   []byte
+}
+
+// Network is a zkMultisig contract of a chain synchronized by the node, whose
+// processes are stored in the db under the Network ID
+type Network struct {
+	ID               uint64
+	ChainID          uint64
+	ContractAddr     []byte
+	LastSyncBlockNum uint64
+}
+
+// EthEvent contains a raw event log emitted by the SmartContract, which is
+// stored to keep an audit trail of the synchronized events, and to allow
+// replaying them
+type EthEvent struct {
+	BlockNum  uint64
+	BlockHash []byte
+	TxHash    []byte
+	// LogIndex is the index of the log in the block
+	LogIndex uint64
+	Address  []byte
+	Topics   [][]byte
+	Data     []byte
+}
+
 // HashVote computes the vote hash following the circuit approach
 func HashVote(chainID, processID uint64, vote []byte) (*big.Int, error) {
 	voteBI := arbo.BytesToBigInt(vote)
@@ -159,18 +284,51 @@ func (vp *VotePackage) verifyMerkleProof(root []byte) error {
 	return nil
 }
 
-// Verify checks the signature and merkleproof of the VotePackage
-func (vp *VotePackage) Verify(chainID, processID uint64, root []byte) error {
+// Verify checks the signature and merkleproof of the VotePackage, and that
+// its vote value is valid for the given process Type
+func (vp *VotePackage) Verify(chainID, processID uint64, root []byte, typ uint8) error {
 	if err := vp.verifySignature(chainID, processID); err != nil {
 		return err
 	}
 	if err := vp.verifyMerkleProof(root); err != nil {
 		return err
 	}
-	// TODO ensure that vote value is 0 or 1
+	return vp.CheckVoteValue(typ)
+}
+
+// CheckVoteValue checks that the vote value of the VotePackage is valid for
+// the given process Type: 0 or 1 for the multisig and referendum processes,
+// and the index of one of the options for the multi-choice processes
+func (vp *VotePackage) CheckVoteValue(typ uint8) error {
+	nOptions, err := ProcessTypeOptions(typ)
+	if err != nil {
+		return err
+	}
+	maxValue := int64(1)
+	if nOptions > 0 {
+		maxValue = int64(nOptions - 1)
+	}
+	voteBI := arbo.BytesToBigInt(vp.Vote)
+	if voteBI.Cmp(big.NewInt(maxValue)) == 1 {
+		return fmt.Errorf("invalid vote value %s, the maximum vote value of the"+
+			" process type %d is %d", voteBI, typ, maxValue)
+	}
 	return nil
 }
 
+// ProcessTypeOptions returns the number of options of the multi-choice
+// processes of the given Type, and 0 for the processes with a 0 or 1 vote
+// value. Returns an error for unknown Types.
+func ProcessTypeOptions(typ uint8) (int, error) {
+	switch {
+	case typ == ProcessTypeMultisig || typ == ProcessTypeReferendum:
+		return 0, nil
+	case typ&ProcessTypeMultiChoice != 0 && int(typ&^ProcessTypeMultiChoice) >= minOptions:
+		return int(typ &^ ProcessTypeMultiChoice), nil
+	}
+	return 0, fmt.Errorf("unknown process type %d", typ)
+}
+
 // Uint64ToIndex returns the bytes representation of the given uint64 that will
 // be used as a leaf index in the MerkleTree
 func Uint64ToIndex(u uint64) []byte {
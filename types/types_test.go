@@ -2,7 +2,9 @@ package types
 
 import (
 	"encoding/json"
+	"fmt"
 	"math/big"
+	"strings"
 	"testing"
 
 	qt "github.com/frankban/quicktest"
@@ -46,7 +48,7 @@ func TestVerifyCensusProof(t *testing.T) {
 	chainID := uint64(3)
 	processID := uint64(123)
 
-	vote := []byte("votetest")
+	vote := []byte{1}
 	msgToSign, err := HashVote(chainID, processID, vote)
 	c.Assert(err, qt.IsNil)
 	sig := sk.SignPoseidon(msgToSign)
@@ -67,12 +69,63 @@ func TestVerifyCensusProof(t *testing.T) {
 
 	c.Assert(vp.verifySignature(chainID, processID), qt.IsNil)
 	c.Assert(vp.verifyMerkleProof(root), qt.IsNil)
-	c.Assert(vp.Verify(chainID, processID, root), qt.IsNil)
+	c.Assert(vp.Verify(chainID, processID, root, ProcessTypeReferendum), qt.IsNil)
 
 	vp.CensusProof.Index++
 	c.Assert(vp.verifySignature(chainID, processID), qt.IsNil)
 	c.Assert(vp.verifyMerkleProof(root), qt.Not(qt.IsNil))
-	c.Assert(vp.Verify(chainID, processID, root), qt.Not(qt.IsNil))
+	c.Assert(vp.Verify(chainID, processID, root, ProcessTypeReferendum), qt.Not(qt.IsNil))
+	vp.CensusProof.Index--
+
+	// a validly signed vote with a value out of the process type domain
+	vote = []byte("votetest")
+	msgToSign, err = HashVote(chainID, processID, vote)
+	c.Assert(err, qt.IsNil)
+	vp.Signature = sk.SignPoseidon(msgToSign).Compress()
+	vp.Vote = vote
+	c.Assert(vp.verifySignature(chainID, processID), qt.IsNil)
+	c.Assert(vp.Verify(chainID, processID, root, ProcessTypeReferendum),
+		qt.ErrorMatches, "invalid vote value .*")
+}
+
+func TestCheckVoteValue(t *testing.T) {
+	c := qt.New(t)
+
+	multiChoice3 := ProcessTypeMultiChoice | 3
+	for _, tc := range []struct {
+		typ   uint8
+		vote  []byte
+		valid bool
+	}{
+		{ProcessTypeMultisig, []byte{0}, true},
+		{ProcessTypeMultisig, []byte{1}, true},
+		{ProcessTypeMultisig, []byte{2}, false},
+		{ProcessTypeReferendum, []byte{1}, true},
+		{ProcessTypeReferendum, []byte("votetest"), false},
+		{multiChoice3, []byte{2}, true},
+		{multiChoice3, []byte{3}, false},
+		// the vote bytes are little-endian
+		{multiChoice3, []byte{0, 1}, false},
+		{multiChoice3, []byte{2, 0}, true},
+	} {
+		vp := VotePackage{Vote: tc.vote}
+		err := vp.CheckVoteValue(tc.typ)
+		c.Assert(err == nil, qt.Equals, tc.valid, qt.Commentf("type %d, vote %x: %v",
+			tc.typ, tc.vote, err))
+	}
+
+	nOptions, err := ProcessTypeOptions(ProcessTypeReferendum)
+	c.Assert(err, qt.IsNil)
+	c.Assert(nOptions, qt.Equals, 0)
+	nOptions, err = ProcessTypeOptions(multiChoice3)
+	c.Assert(err, qt.IsNil)
+	c.Assert(nOptions, qt.Equals, 3)
+	for _, typ := range []uint8{2, ProcessTypeMultiChoice, ProcessTypeMultiChoice | 1} {
+		_, err = ProcessTypeOptions(typ)
+		c.Assert(err, qt.ErrorMatches, "unknown process type .*")
+		vp := VotePackage{Vote: []byte{0}}
+		c.Assert(vp.CheckVoteValue(typ), qt.ErrorMatches, "unknown process type .*")
+	}
 }
 
 func TestByteArrayJSON(t *testing.T) {
@@ -203,3 +256,90 @@ func TestIndexAndWeightParser(t *testing.T) {
 	c.Assert(i2, qt.Equals, index)
 	c.Assert(weight.String(), qt.Equals, w2.String())
 }
+
+func TestZKInputsJSON(t *testing.T) {
+	c := qt.New(t)
+
+	z := NewZKInputs(4, 3)
+	z.ChainID = big.NewInt(3)
+	z.ProcessID = big.NewInt(123)
+	z.Vote[1] = big.NewInt(1)
+	z.Siblings[2][3], _ = new(big.Int).SetString(
+		"3997482243935470019154908634129466064231369626981967795243271053776626526277", 10)
+
+	j, err := json.Marshal(z)
+	c.Assert(err, qt.IsNil)
+
+	var z2 ZKInputs
+	err = json.Unmarshal(j, &z2)
+	c.Assert(err, qt.IsNil)
+	c.Assert(z2.Meta, qt.Equals, ZKCircuitMeta{NMaxVotes: 4, NLevels: 3})
+	c.Assert(z2.ChainID.String(), qt.Equals, "3")
+	c.Assert(z2.ProcessID.String(), qt.Equals, "123")
+	c.Assert(z2.Vote[1].String(), qt.Equals, "1")
+	c.Assert(z2.Siblings[2][3].String(), qt.Equals, z.Siblings[2][3].String())
+
+	j2, err := json.Marshal(z2)
+	c.Assert(err, qt.IsNil)
+	c.Assert(string(j2), qt.Equals, string(j))
+
+	// values encoded as json numbers are also accepted
+	err = json.Unmarshal([]byte(`{"chainID":3,"vote":[1,"0"]}`), &z2)
+	c.Assert(err, qt.IsNil)
+	c.Assert(z2.ChainID.String(), qt.Equals, "3")
+	c.Assert(len(z2.Vote), qt.Equals, 2)
+
+	c.Assert(z.CheckDimensions(ZKCircuitMeta{NMaxVotes: 4, NLevels: 3}), qt.IsNil)
+	c.Assert(z.CheckDimensions(ZKCircuitMeta{NMaxVotes: 4, NLevels: 4}),
+		qt.ErrorMatches, ".*does not match nLevels\\+1 \\(5\\)")
+	c.Assert(z.CheckDimensions(ZKCircuitMeta{NMaxVotes: 8, NLevels: 3}),
+		qt.ErrorMatches, ".*does not match nMaxVotes \\(8\\)")
+	c.Assert(z.CheckDimensions(ZKCircuitMeta{NMaxVotes: 4, NLevels: 3, NOptions: 3}),
+		qt.ErrorMatches, "results length \\(0\\) does not match nOptions \\(3\\)")
+	c.Assert(strings.Contains(string(j), "results"), qt.IsFalse)
+
+	// the results of the multi-choice processes follow the public inputs
+	z.Results = []*big.Int{big.NewInt(2), big.NewInt(0), big.NewInt(5)}
+	j, err = json.Marshal(z)
+	c.Assert(err, qt.IsNil)
+	var z3 ZKInputs
+	err = json.Unmarshal(j, &z3)
+	c.Assert(err, qt.IsNil)
+	c.Assert(z3.Meta, qt.Equals, ZKCircuitMeta{NMaxVotes: 4, NLevels: 3, NOptions: 3})
+	c.Assert(z3.CheckDimensions(z3.Meta), qt.IsNil)
+	publicInputs := z3.PublicInputs()
+	c.Assert(len(publicInputs), qt.Equals, 10)
+	c.Assert(fmt.Sprint(publicInputs[7:]), qt.Equals, "[2 0 5]")
+}
+
+func TestProcessResultMatchesProof(t *testing.T) {
+	c := qt.New(t)
+
+	z := NewZKInputs(4, 3)
+	z.ReceiptsRoot = big.NewInt(1234)
+	z.NVotes = big.NewInt(10)
+	z.Result = big.NewInt(7)
+	zkProof := &ZKProof{}
+	for _, in := range z.PublicInputs() {
+		zkProof.PublicInputs = append(zkProof.PublicInputs, in.String())
+	}
+
+	result := ProcessResult{
+		ReceiptsRoot: arbo.BigIntToBytes(32, big.NewInt(1234)),
+		NVotes:       10,
+		Result:       7,
+	}
+	c.Assert(result.MatchesProof(zkProof), qt.IsTrue)
+
+	result.Result = 8
+	c.Assert(result.MatchesProof(zkProof), qt.IsFalse)
+	result.Result = 7
+	result.NVotes = 11
+	c.Assert(result.MatchesProof(zkProof), qt.IsFalse)
+	result.NVotes = 10
+	result.ReceiptsRoot = arbo.BigIntToBytes(32, big.NewInt(1235))
+	c.Assert(result.MatchesProof(zkProof), qt.IsFalse)
+
+	zkProof.PublicInputs = zkProof.PublicInputs[:5]
+	c.Assert(result.MatchesProof(zkProof), qt.IsFalse)
+}
@@ -18,6 +18,10 @@ import (
 type ZKCircuitMeta struct {
 	NMaxVotes int
 	NLevels   int
+	// NOptions is the number of options of the multi-choice processes
+	// proven by the circuit, which is 0 for the circuits of the processes
+	// with a 0 or 1 vote value
+	NOptions int
 }
 
 // ZKInputs contains the inputs used to generate the zkProof
@@ -32,6 +36,10 @@ type ZKInputs struct {
 	NVotes       *big.Int `json:"nVotes"`
 	Result       *big.Int `json:"result"`
 	WithReceipts *big.Int `json:"withReceipts"`
+	// Results contains the weighted sum of the votes of each option of the
+	// multi-choice processes, whose Result is 0, and it is empty for the
+	// processes with a 0 or 1 vote value
+	Results []*big.Int `json:"results"`
 
 	/////
 	// private inputs
@@ -135,9 +143,163 @@ func (z ZKInputs) MarshalJSON() ([]byte, error) {
 	for k, v := range m {
 		m[k] = bigIntsToStrings(v)
 	}
+	// the circuits of the processes with a 0 or 1 vote value do not have
+	// the results input
+	if len(z.Results) == 0 {
+		delete(m, "results")
+	}
 	return json.Marshal(m)
 }
 
+// zkInputsJSON is used to unmarshal the ZKInputs json, where the values can be
+// encoded either as json strings or as json numbers
+type zkInputsJSON struct {
+	ChainID          json.Number     `json:"chainID"`
+	ProcessID        json.Number     `json:"processID"`
+	CensusRoot       json.Number     `json:"censusRoot"`
+	ReceiptsRoot     json.Number     `json:"receiptsRoot"`
+	NVotes           json.Number     `json:"nVotes"`
+	Result           json.Number     `json:"result"`
+	WithReceipts     json.Number     `json:"withReceipts"`
+	Results          []json.Number   `json:"results"`
+	Vote             []json.Number   `json:"vote"`
+	Index            []json.Number   `json:"index"`
+	PkX              []json.Number   `json:"pkX"`
+	PkY              []json.Number   `json:"pkY"`
+	Weight           []json.Number   `json:"weight"`
+	S                []json.Number   `json:"s"`
+	R8x              []json.Number   `json:"r8x"`
+	R8y              []json.Number   `json:"r8y"`
+	Siblings         [][]json.Number `json:"siblings"`
+	ReceiptsSiblings [][]json.Number `json:"receiptsSiblings"`
+}
+
+func numberToBI(n json.Number) (*big.Int, error) {
+	if n == "" {
+		return big.NewInt(0), nil
+	}
+	bi, ok := new(big.Int).SetString(n.String(), 10) //nolint:gomnd
+	if !ok {
+		return nil, fmt.Errorf("invalid number: %s", n)
+	}
+	return bi, nil
+}
+
+func numbersToBIs(ns []json.Number) ([]*big.Int, error) {
+	r := make([]*big.Int, len(ns))
+	for i := range ns {
+		var err error
+		r[i], err = numberToBI(ns[i])
+		if err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// UnmarshalJSON implements the json unmarshaler for ZKInputs. The ZKInputs.Meta
+// is set from the length of the unmarshaled arrays.
+func (z *ZKInputs) UnmarshalJSON(b []byte) error {
+	var aux zkInputsJSON
+	if err := json.Unmarshal(b, &aux); err != nil {
+		return err
+	}
+
+	var err error
+	scalars := []struct {
+		n json.Number
+		v **big.Int
+	}{
+		{aux.ChainID, &z.ChainID}, {aux.ProcessID, &z.ProcessID},
+		{aux.CensusRoot, &z.CensusRoot}, {aux.ReceiptsRoot, &z.ReceiptsRoot},
+		{aux.NVotes, &z.NVotes}, {aux.Result, &z.Result},
+		{aux.WithReceipts, &z.WithReceipts},
+	}
+	for i := range scalars {
+		if *scalars[i].v, err = numberToBI(scalars[i].n); err != nil {
+			return err
+		}
+	}
+	arrays := []struct {
+		ns []json.Number
+		v  *[]*big.Int
+	}{
+		{aux.Vote, &z.Vote}, {aux.Index, &z.Index}, {aux.PkX, &z.PkX},
+		{aux.PkY, &z.PkY}, {aux.Weight, &z.Weight}, {aux.S, &z.S},
+		{aux.R8x, &z.R8x}, {aux.R8y, &z.R8y}, {aux.Results, &z.Results},
+	}
+	for i := range arrays {
+		if *arrays[i].v, err = numbersToBIs(arrays[i].ns); err != nil {
+			return err
+		}
+	}
+	z.Siblings = make([][]*big.Int, len(aux.Siblings))
+	for i := range aux.Siblings {
+		if z.Siblings[i], err = numbersToBIs(aux.Siblings[i]); err != nil {
+			return err
+		}
+	}
+	z.ReceiptsSiblings = make([][]*big.Int, len(aux.ReceiptsSiblings))
+	for i := range aux.ReceiptsSiblings {
+		if z.ReceiptsSiblings[i], err = numbersToBIs(aux.ReceiptsSiblings[i]); err != nil {
+			return err
+		}
+	}
+
+	z.Meta.NMaxVotes = len(z.Vote)
+	z.Meta.NOptions = len(z.Results)
+	z.Meta.NLevels = 0
+	if len(z.Siblings) > 0 {
+		z.Meta.NLevels = len(z.Siblings[0]) - 1
+	}
+	return nil
+}
+
+// CheckDimensions checks that the length of all the ZKInputs arrays match the
+// given ZKCircuitMeta
+func (z *ZKInputs) CheckDimensions(meta ZKCircuitMeta) error {
+	if len(z.Results) != meta.NOptions {
+		return fmt.Errorf("results length (%d) does not match nOptions (%d)",
+			len(z.Results), meta.NOptions)
+	}
+	arrays := map[string][]*big.Int{
+		"vote": z.Vote, "index": z.Index, "pkX": z.PkX, "pkY": z.PkY,
+		"weight": z.Weight, "s": z.S, "r8x": z.R8x, "r8y": z.R8y,
+	}
+	for name, a := range arrays {
+		if len(a) != meta.NMaxVotes {
+			return fmt.Errorf("%s length (%d) does not match nMaxVotes (%d)",
+				name, len(a), meta.NMaxVotes)
+		}
+	}
+	siblings := map[string][][]*big.Int{
+		"siblings": z.Siblings, "receiptsSiblings": z.ReceiptsSiblings,
+	}
+	for name, s := range siblings {
+		if len(s) != meta.NMaxVotes {
+			return fmt.Errorf("%s length (%d) does not match nMaxVotes (%d)",
+				name, len(s), meta.NMaxVotes)
+		}
+		for i := range s {
+			if len(s[i]) != meta.NLevels+1 {
+				return fmt.Errorf("%s[%d] length (%d) does not match nLevels+1 (%d)",
+					name, i, len(s[i]), meta.NLevels+1)
+			}
+		}
+	}
+	return nil
+}
+
+// PublicInputs returns the public inputs of the ZKInputs, in the order in
+// which they are declared as public in the circuit, which is the order of the
+// public signals of the zkProof. The Results of the multi-choice processes
+// follow the WithReceipts.
+func (z *ZKInputs) PublicInputs() []*big.Int {
+	inputs := []*big.Int{z.ChainID, z.ProcessID, z.CensusRoot, z.ReceiptsRoot,
+		z.NVotes, z.Result, z.WithReceipts}
+	return append(inputs, z.Results...)
+}
+
 // MerkleProofToZKInputsFormat prepares the given MerkleProof into the
 // ZKInputs.Siblings format for the circuit
 func (z *ZKInputs) MerkleProofToZKInputsFormat(p []byte) ([]*big.Int, error) {
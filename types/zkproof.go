@@ -0,0 +1,53 @@
+package types
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+)
+
+// ZKProof contains the Groth16 zkProof together with the public inputs
+// (signals) used to generate it, following the snarkjs json format
+type ZKProof struct {
+	Proof        Groth16Proof `json:"proof"`
+	PublicInputs []string     `json:"public"`
+}
+
+// Groth16Proof represents a Groth16 proof in the snarkjs json format
+type Groth16Proof struct {
+	A        []string   `json:"pi_a"`
+	B        [][]string `json:"pi_b"`
+	C        []string   `json:"pi_c"`
+	Protocol string     `json:"protocol"`
+}
+
+// indexes of the public inputs of the zkProof, in the order of
+// ZKInputs.PublicInputs
+const (
+	receiptsRootIdx = 3
+	nVotesIdx       = 4
+	resultIdx       = 5
+)
+
+// Result returns the ReceiptsRoot, NVotes and Result of the public inputs of
+// the zkProof
+func (p *ZKProof) Result() (receiptsRoot *big.Int, nVotes, result uint64, err error) {
+	if len(p.PublicInputs) <= resultIdx {
+		return nil, 0, 0, fmt.Errorf("invalid number of public inputs (%d)",
+			len(p.PublicInputs))
+	}
+	receiptsRoot, ok := new(big.Int).SetString(p.PublicInputs[receiptsRootIdx], 10) //nolint:gomnd
+	if !ok {
+		return nil, 0, 0, fmt.Errorf("can not parse receiptsRoot %q",
+			p.PublicInputs[receiptsRootIdx])
+	}
+	nVotes, err = strconv.ParseUint(p.PublicInputs[nVotesIdx], 10, 64)
+	if err != nil {
+		return nil, 0, 0, fmt.Errorf("can not parse nVotes: %s", err)
+	}
+	result, err = strconv.ParseUint(p.PublicInputs[resultIdx], 10, 64)
+	if err != nil {
+		return nil, 0, 0, fmt.Errorf("can not parse result: %s", err)
+	}
+	return receiptsRoot, nVotes, result, nil
+}
@@ -0,0 +1,251 @@
+package verifier
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+
+	"github.com/aragon/zkmultisig-node/types"
+	bn256 "github.com/ethereum/go-ethereum/crypto/bn256/cloudflare"
+)
+
+const (
+	// fieldElementLen is the number of bytes of a BN254 base field element
+	fieldElementLen = 32
+)
+
+var (
+	// ErrInvalidProof is returned when the zkProof does not pass the
+	// Groth16 verification
+	ErrInvalidProof = errors.New("invalid zkProof")
+	// ErrPublicInputsMismatch is returned when the public inputs of the
+	// zkProof do not match the public inputs of the ZKInputs
+	ErrPublicInputsMismatch = errors.New("zkProof public inputs do not match the zkInputs")
+)
+
+// VerificationKey contains a Groth16 verification key over BN254, in the
+// json format generated by snarkjs (verification_key.json)
+type VerificationKey struct {
+	Protocol string     `json:"protocol"`
+	Curve    string     `json:"curve"`
+	NPublic  int        `json:"nPublic"`
+	Alpha1   []string   `json:"vk_alpha_1"`
+	Beta2    [][]string `json:"vk_beta_2"`
+	Gamma2   [][]string `json:"vk_gamma_2"`
+	Delta2   [][]string `json:"vk_delta_2"`
+	IC       [][]string `json:"IC"`
+
+	alpha *bn256.G1
+	beta  *bn256.G2
+	gamma *bn256.G2
+	delta *bn256.G2
+	ic    []*bn256.G1
+}
+
+// ParseVerificationKey parses the given snarkjs verification key json, checking
+// that all its points are valid
+func ParseVerificationKey(b []byte) (*VerificationKey, error) {
+	var vk VerificationKey
+	if err := json.Unmarshal(b, &vk); err != nil {
+		return nil, err
+	}
+	if vk.Protocol != "groth16" {
+		return nil, fmt.Errorf("unsupported protocol %q", vk.Protocol)
+	}
+	if vk.Curve != "bn128" && vk.Curve != "bn254" {
+		return nil, fmt.Errorf("unsupported curve %q", vk.Curve)
+	}
+	if len(vk.IC) != vk.NPublic+1 {
+		return nil, fmt.Errorf("IC length (%d) does not match nPublic+1 (%d)",
+			len(vk.IC), vk.NPublic+1)
+	}
+
+	var err error
+	if vk.alpha, err = parseG1(vk.Alpha1); err != nil {
+		return nil, fmt.Errorf("vk_alpha_1: %s", err)
+	}
+	if vk.beta, err = parseG2(vk.Beta2); err != nil {
+		return nil, fmt.Errorf("vk_beta_2: %s", err)
+	}
+	if vk.gamma, err = parseG2(vk.Gamma2); err != nil {
+		return nil, fmt.Errorf("vk_gamma_2: %s", err)
+	}
+	if vk.delta, err = parseG2(vk.Delta2); err != nil {
+		return nil, fmt.Errorf("vk_delta_2: %s", err)
+	}
+	vk.ic = make([]*bn256.G1, len(vk.IC))
+	for i := range vk.IC {
+		if vk.ic[i], err = parseG1(vk.IC[i]); err != nil {
+			return nil, fmt.Errorf("IC[%d]: %s", i, err)
+		}
+	}
+	return &vk, nil
+}
+
+// LoadVerificationKey reads and parses the snarkjs verification key json file
+// of the given path
+func LoadVerificationKey(path string) (*VerificationKey, error) {
+	b, err := ioutil.ReadFile(path) //nolint:gosec
+	if err != nil {
+		return nil, err
+	}
+	vk, err := ParseVerificationKey(b)
+	if err != nil {
+		return nil, fmt.Errorf("can not parse verification key %s: %s", path, err)
+	}
+	return vk, nil
+}
+
+// Verify checks that the public inputs of the given zkProof match the public
+// inputs of the given ZKInputs, and that the zkProof is valid
+func (vk *VerificationKey) Verify(zki *types.ZKInputs, zkProof *types.ZKProof) error {
+	if err := CheckPublicInputs(zki, zkProof.PublicInputs); err != nil {
+		return err
+	}
+	return vk.VerifyProof(zkProof)
+}
+
+// VerifyProof checks the Groth16 zkProof for its public inputs. Returns
+// ErrInvalidProof if the pairing check fails.
+func (vk *VerificationKey) VerifyProof(zkProof *types.ZKProof) error {
+	if zkProof.Proof.Protocol != "" && zkProof.Proof.Protocol != "groth16" {
+		return fmt.Errorf("unsupported proof protocol %q", zkProof.Proof.Protocol)
+	}
+	if len(zkProof.PublicInputs) != vk.NPublic {
+		return fmt.Errorf("number of public inputs (%d) does not match nPublic (%d)",
+			len(zkProof.PublicInputs), vk.NPublic)
+	}
+	a, err := parseG1(zkProof.Proof.A)
+	if err != nil {
+		return fmt.Errorf("pi_a: %s", err)
+	}
+	b, err := parseG2(zkProof.Proof.B)
+	if err != nil {
+		return fmt.Errorf("pi_b: %s", err)
+	}
+	c, err := parseG1(zkProof.Proof.C)
+	if err != nil {
+		return fmt.Errorf("pi_c: %s", err)
+	}
+
+	// vkX = IC[0] + sum(publicInputs[i] * IC[i+1])
+	vkX := new(bn256.G1).Set(vk.ic[0])
+	for i, s := range zkProof.PublicInputs {
+		input, err := parseScalar(s)
+		if err != nil {
+			return fmt.Errorf("public input %d: %s", i, err)
+		}
+		vkX.Add(vkX, new(bn256.G1).ScalarMult(vk.ic[i+1], input))
+	}
+
+	// e(A, B) == e(alpha, beta) * e(vkX, gamma) * e(C, delta), which is
+	// checked as e(-A, B) * e(alpha, beta) * e(vkX, gamma) * e(C, delta) == 1
+	ok := bn256.PairingCheck(
+		[]*bn256.G1{new(bn256.G1).Neg(a), vk.alpha, vkX, c},
+		[]*bn256.G2{b, vk.beta, vk.gamma, vk.delta},
+	)
+	if !ok {
+		return ErrInvalidProof
+	}
+	return nil
+}
+
+// CheckPublicInputs checks that the given public inputs of a zkProof match
+// the public inputs of the given ZKInputs
+func CheckPublicInputs(zki *types.ZKInputs, publicInputs []string) error {
+	expected := zki.PublicInputs()
+	if len(publicInputs) != len(expected) {
+		return fmt.Errorf("%w: expected %d public inputs, got %d",
+			ErrPublicInputsMismatch, len(expected), len(publicInputs))
+	}
+	for i := range expected {
+		v, err := parseScalar(publicInputs[i])
+		if err != nil {
+			return fmt.Errorf("public input %d: %s", i, err)
+		}
+		if expected[i] == nil || v.Cmp(expected[i]) != 0 {
+			return fmt.Errorf("%w: public input %d is %s, expected %s",
+				ErrPublicInputsMismatch, i, v, expected[i])
+		}
+	}
+	return nil
+}
+
+// parseScalar parses the given decimal string, checking that it is an element
+// of the BN254 scalar field
+func parseScalar(s string) (*big.Int, error) {
+	v, ok := new(big.Int).SetString(s, 10) //nolint:gomnd
+	if !ok {
+		return nil, fmt.Errorf("can not parse %q", s)
+	}
+	if v.Sign() < 0 || v.Cmp(bn256.Order) >= 0 {
+		return nil, fmt.Errorf("%s is not in the scalar field", s)
+	}
+	return v, nil
+}
+
+// parseCoordinates parses the given decimal strings into a byte array of
+// concatenated 32 byte big-endian field elements
+func parseCoordinates(coords ...string) ([]byte, error) {
+	b := make([]byte, len(coords)*fieldElementLen)
+	for i, s := range coords {
+		v, ok := new(big.Int).SetString(s, 10) //nolint:gomnd
+		if !ok {
+			return nil, fmt.Errorf("can not parse coordinate %q", s)
+		}
+		if v.Sign() < 0 || v.Cmp(bn256.P) >= 0 {
+			return nil, fmt.Errorf("coordinate %s is not in the field", s)
+		}
+		v.FillBytes(b[i*fieldElementLen : (i+1)*fieldElementLen])
+	}
+	return b, nil
+}
+
+// parseG1 parses a G1 point in the snarkjs projective format [x, y, z], where
+// z must be 1 (affine coordinates)
+func parseG1(p []string) (*bn256.G1, error) {
+	if len(p) != 3 { //nolint:gomnd
+		return nil, fmt.Errorf("invalid G1 point length (%d)", len(p))
+	}
+	if p[2] != "1" {
+		return nil, fmt.Errorf("G1 point is not in affine coordinates")
+	}
+	b, err := parseCoordinates(p[0], p[1])
+	if err != nil {
+		return nil, err
+	}
+	g := new(bn256.G1)
+	if _, err := g.Unmarshal(b); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// parseG2 parses a G2 point in the snarkjs projective format
+// [[x0, x1], [y0, y1], [z0, z1]], where z must be [1, 0] (affine coordinates).
+// Each coordinate is an element x0 + x1*i of the quadratic extension field,
+// while the bn256 encoding expects the imaginary part first.
+func parseG2(p [][]string) (*bn256.G2, error) {
+	if len(p) != 3 { //nolint:gomnd
+		return nil, fmt.Errorf("invalid G2 point length (%d)", len(p))
+	}
+	for _, c := range p {
+		if len(c) != 2 { //nolint:gomnd
+			return nil, fmt.Errorf("invalid G2 coordinate length (%d)", len(c))
+		}
+	}
+	if p[2][0] != "1" || p[2][1] != "0" {
+		return nil, fmt.Errorf("G2 point is not in affine coordinates")
+	}
+	b, err := parseCoordinates(p[0][1], p[0][0], p[1][1], p[1][0])
+	if err != nil {
+		return nil, err
+	}
+	g := new(bn256.G2)
+	if _, err := g.Unmarshal(b); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
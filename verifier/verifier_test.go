@@ -0,0 +1,228 @@
+package verifier
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"math/big"
+	"testing"
+
+	"github.com/aragon/zkmultisig-node/types"
+	bn256 "github.com/ethereum/go-ethereum/crypto/bn256/cloudflare"
+	qt "github.com/frankban/quicktest"
+)
+
+// snarkjsG2Generator is the BN254 G2 generator as encoded by snarkjs, which
+// uses it as vk_gamma_2 in the groth16 verification keys
+var snarkjsG2Generator = [][]string{
+	{"10857046999023057135944570762232829481370756359578518086990519993285655852781",
+		"11559732032986387107991004021392285783925812861821192530917403151452391805634"},
+	{"8495653923123431417604973247489272438418190587263600148770280649306958101930",
+		"4082367875863433681332203403145435568316851327593401208105741076214120093531"},
+	{"1", "0"},
+}
+
+func g1ToStrings(p *bn256.G1) []string {
+	b := p.Marshal()
+	return []string{
+		new(big.Int).SetBytes(b[:32]).String(),
+		new(big.Int).SetBytes(b[32:]).String(),
+		"1",
+	}
+}
+
+func g2ToStrings(p *bn256.G2) [][]string {
+	b := p.Marshal()
+	e := make([]string, 4)
+	for i := range e {
+		e[i] = new(big.Int).SetBytes(b[i*32 : (i+1)*32]).String()
+	}
+	return [][]string{{e[1], e[0]}, {e[3], e[2]}, {"1", "0"}}
+}
+
+func randScalar(c *qt.C) *big.Int {
+	k, err := rand.Int(rand.Reader, bn256.Order)
+	c.Assert(err, qt.IsNil)
+	return k
+}
+
+// testSetup contains a verification key together with its trapdoor, which
+// allows to generate valid proofs for any public inputs without a circuit
+type testSetup struct {
+	vk                    *VerificationKey
+	alpha, beta, gamma, d *big.Int
+	u                     []*big.Int
+}
+
+func newTestSetup(c *qt.C, nPublic int) *testSetup {
+	s := &testSetup{
+		alpha: randScalar(c), beta: randScalar(c), gamma: randScalar(c),
+		d: randScalar(c),
+	}
+	vk := VerificationKey{
+		Protocol: "groth16",
+		Curve:    "bn128",
+		NPublic:  nPublic,
+		Alpha1:   g1ToStrings(new(bn256.G1).ScalarBaseMult(s.alpha)),
+		Beta2:    g2ToStrings(new(bn256.G2).ScalarBaseMult(s.beta)),
+		Gamma2:   g2ToStrings(new(bn256.G2).ScalarBaseMult(s.gamma)),
+		Delta2:   g2ToStrings(new(bn256.G2).ScalarBaseMult(s.d)),
+	}
+	for i := 0; i < nPublic+1; i++ {
+		s.u = append(s.u, randScalar(c))
+		vk.IC = append(vk.IC, g1ToStrings(new(bn256.G1).ScalarBaseMult(s.u[i])))
+	}
+	b, err := json.Marshal(vk)
+	c.Assert(err, qt.IsNil)
+	s.vk, err = ParseVerificationKey(b)
+	c.Assert(err, qt.IsNil)
+	return s
+}
+
+// prove returns a valid proof for the given public inputs, with A = x*G1,
+// B = G2 and C = r*G1, where x = alpha*beta + gamma*(u0 + sum(in_i*u_i)) +
+// r*delta
+func (s *testSetup) prove(c *qt.C, publicInputs []*big.Int) *types.ZKProof {
+	vkX := new(big.Int).Set(s.u[0])
+	for i, in := range publicInputs {
+		vkX.Add(vkX, new(big.Int).Mul(in, s.u[i+1]))
+	}
+	r := randScalar(c)
+	x := new(big.Int).Mul(s.alpha, s.beta)
+	x.Add(x, new(big.Int).Mul(s.gamma, vkX))
+	x.Add(x, new(big.Int).Mul(r, s.d))
+	x.Mod(x, bn256.Order)
+
+	zkProof := &types.ZKProof{
+		Proof: types.Groth16Proof{
+			A:        g1ToStrings(new(bn256.G1).ScalarBaseMult(x)),
+			B:        g2ToStrings(new(bn256.G2).ScalarBaseMult(big.NewInt(1))),
+			C:        g1ToStrings(new(bn256.G1).ScalarBaseMult(r)),
+			Protocol: "groth16",
+		},
+	}
+	for _, in := range publicInputs {
+		zkProof.PublicInputs = append(zkProof.PublicInputs, in.String())
+	}
+	return zkProof
+}
+
+func TestParsePoints(t *testing.T) {
+	c := qt.New(t)
+
+	g2, err := parseG2(snarkjsG2Generator)
+	c.Assert(err, qt.IsNil)
+	c.Assert(g2.Marshal(), qt.DeepEquals,
+		new(bn256.G2).ScalarBaseMult(big.NewInt(1)).Marshal())
+
+	g1, err := parseG1([]string{"1", "2", "1"})
+	c.Assert(err, qt.IsNil)
+	c.Assert(g1.Marshal(), qt.DeepEquals,
+		new(bn256.G1).ScalarBaseMult(big.NewInt(1)).Marshal())
+
+	_, err = parseG1([]string{"1", "3", "1"})
+	c.Assert(err, qt.ErrorMatches, ".*malformed point")
+	_, err = parseG1([]string{"1", "2", "0"})
+	c.Assert(err, qt.ErrorMatches, "G1 point is not in affine coordinates")
+	_, err = parseG1([]string{"1", bn256.P.String(), "1"})
+	c.Assert(err, qt.ErrorMatches, "coordinate .* is not in the field")
+	_, err = parseG2(snarkjsG2Generator[:2])
+	c.Assert(err, qt.ErrorMatches, `invalid G2 point length \(2\)`)
+}
+
+func TestVerifyProof(t *testing.T) {
+	c := qt.New(t)
+
+	s := newTestSetup(c, 7)
+	zki := types.NewZKInputs(4, 3)
+	zki.ChainID = big.NewInt(3)
+	zki.ProcessID = big.NewInt(123)
+	zki.CensusRoot, _ = new(big.Int).SetString(
+		"3997482243935470019154908634129466064231369626981967795243271053776626526277", 10)
+	zki.NVotes = big.NewInt(10)
+	zki.Result = big.NewInt(7)
+
+	zkProof := s.prove(c, zki.PublicInputs())
+	c.Assert(s.vk.VerifyProof(zkProof), qt.IsNil)
+	c.Assert(s.vk.Verify(zki, zkProof), qt.IsNil)
+
+	// the proof is not valid for other public inputs
+	zkProof.PublicInputs[5] = "8"
+	c.Assert(s.vk.VerifyProof(zkProof), qt.Equals, ErrInvalidProof)
+	err := s.vk.Verify(zki, zkProof)
+	c.Assert(errors.Is(err, ErrPublicInputsMismatch), qt.IsTrue)
+	c.Assert(err, qt.ErrorMatches, ".*public input 5 is 8, expected 7")
+
+	// a proof for other public inputs is valid, but does not match the
+	// zkInputs
+	zkProof = s.prove(c, []*big.Int{big.NewInt(3), big.NewInt(124), zki.CensusRoot,
+		big.NewInt(0), big.NewInt(10), big.NewInt(7), big.NewInt(0)})
+	c.Assert(s.vk.VerifyProof(zkProof), qt.IsNil)
+	err = s.vk.Verify(zki, zkProof)
+	c.Assert(errors.Is(err, ErrPublicInputsMismatch), qt.IsTrue)
+
+	// tampered proof
+	zkProof = s.prove(c, zki.PublicInputs())
+	zkProof.Proof.C = zkProof.Proof.A
+	c.Assert(s.vk.Verify(zki, zkProof), qt.Equals, ErrInvalidProof)
+
+	// public inputs out of the scalar field
+	zkProof = s.prove(c, zki.PublicInputs())
+	zkProof.PublicInputs[0] = new(big.Int).Add(bn256.Order, big.NewInt(3)).String()
+	c.Assert(s.vk.VerifyProof(zkProof), qt.ErrorMatches,
+		"public input 0: .* is not in the scalar field")
+
+	zkProof.PublicInputs = zkProof.PublicInputs[:6]
+	c.Assert(s.vk.VerifyProof(zkProof), qt.ErrorMatches,
+		`number of public inputs \(6\) does not match nPublic \(7\)`)
+}
+
+func TestParseVerificationKey(t *testing.T) {
+	c := qt.New(t)
+
+	s := newTestSetup(c, 2)
+	vk := *s.vk
+	vk.IC = vk.IC[:2]
+	b, err := json.Marshal(vk)
+	c.Assert(err, qt.IsNil)
+	_, err = ParseVerificationKey(b)
+	c.Assert(err, qt.ErrorMatches, `IC length \(2\) does not match nPublic\+1 \(3\)`)
+
+	vk = *s.vk
+	vk.Protocol = "plonk"
+	b, err = json.Marshal(vk)
+	c.Assert(err, qt.IsNil)
+	_, err = ParseVerificationKey(b)
+	c.Assert(err, qt.ErrorMatches, `unsupported protocol "plonk"`)
+
+	vk = *s.vk
+	vk.Gamma2 = [][]string{{"1", "2"}, {"3", "4"}, {"1", "0"}}
+	b, err = json.Marshal(vk)
+	c.Assert(err, qt.IsNil)
+	_, err = ParseVerificationKey(b)
+	c.Assert(err, qt.ErrorMatches, "vk_gamma_2: .*")
+}
+
+// TestVerifyProofFiles verifies the proof of the testdata, which is in the
+// json format generated by snarkjs & rapidsnark
+func TestVerifyProofFiles(t *testing.T) {
+	c := qt.New(t)
+
+	vk, err := LoadVerificationKey("testdata/verification_key.json")
+	c.Assert(err, qt.IsNil)
+	c.Assert(vk.NPublic, qt.Equals, 7)
+
+	var zkProof types.ZKProof
+	b, err := ioutil.ReadFile("testdata/proof.json")
+	c.Assert(err, qt.IsNil)
+	c.Assert(json.Unmarshal(b, &zkProof.Proof), qt.IsNil)
+	b, err = ioutil.ReadFile("testdata/public.json")
+	c.Assert(err, qt.IsNil)
+	c.Assert(json.Unmarshal(b, &zkProof.PublicInputs), qt.IsNil)
+
+	c.Assert(vk.VerifyProof(&zkProof), qt.IsNil)
+
+	zkProof.PublicInputs[4] = "11"
+	c.Assert(vk.VerifyProof(&zkProof), qt.Equals, ErrInvalidProof)
+}
@@ -0,0 +1,247 @@
+package votesaggregator
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/aragon/zkmultisig-node/proverclient"
+	"github.com/aragon/zkmultisig-node/types"
+	"go.vocdoni.io/dvote/log"
+)
+
+// DefaultProverCheckInterval is the default time between the checks of the
+// status of a prover while it generates a zkProof
+const DefaultProverCheckInterval = 30 * time.Second
+
+// errProverDied is returned when a prover stops replying while generating a
+// zkProof, in which case the proof generation is reassigned to another prover
+var errProverDied = fmt.Errorf("prover not available")
+
+// ProverStatusClient defines the interface of the ProverClients that report
+// their status and their circuits, which is implemented by the
+// proverclient.Client. The zkInputs are only sent to these ProverClients when
+// they are idle and support the circuit, and their proof generation jobs are
+// reassigned if they stop replying.
+type ProverStatusClient interface {
+	// Status returns the status of the prover, or
+	// proverclient.ErrProverBusy if it is busy generating a zkProof
+	Status() (*proverclient.StatusResponse, error)
+	// Circuits returns the circuits available in the prover
+	Circuits() ([]proverclient.CircuitInfo, error)
+	// Circuit returns the name of the circuit used to generate the
+	// zkProofs, which is empty if the prover uses its only circuit
+	Circuit() string
+}
+
+var _ ProverStatusClient = (*proverclient.Client)(nil)
+
+// proverSlot is a prover of a ProverPool, together with the ProofVerifier of
+// its zkProofs and the ZKCircuitMeta of its circuit
+type proverSlot struct {
+	// name identifies the prover of the proof generation jobs stored in
+	// the db
+	name     string
+	client   ProverClient
+	verifier ProofVerifier
+	meta     types.ZKCircuitMeta
+
+	// assigned is set while the prover generates a zkProof for any of
+	// the VotesAggregators of the pool
+	assigned bool
+	// dead is set when the last status check of the prover failed
+	dead bool
+	// supported is set once the prover circuits have been checked
+	supported bool
+}
+
+// ProverPool contains the provers used to generate the zkProofs, which can
+// be shared by the VotesAggregators of several networks. Each prover
+// generates a single zkProof at a time.
+type ProverPool struct {
+	mu            sync.Mutex
+	provers       []*proverSlot
+	checkInterval time.Duration
+}
+
+// NewProverPool returns an empty ProverPool, which checks the status of the
+// provers that generate a zkProof each checkInterval (if 0,
+// DefaultProverCheckInterval is used)
+func NewProverPool(checkInterval time.Duration) *ProverPool {
+	if checkInterval == 0 {
+		checkInterval = DefaultProverCheckInterval
+	}
+	return &ProverPool{checkInterval: checkInterval}
+}
+
+// Add adds to the pool the ProverClient identified by the given name,
+// together with the ProofVerifier used to check its zkProofs and the
+// ZKCircuitMeta of its circuit. The name is stored with the proof generation
+// jobs sent to the prover, so it must not change between restarts.
+func (pp *ProverPool) Add(name string, prover ProverClient, verifier ProofVerifier,
+	circuitMeta types.ZKCircuitMeta) {
+	pp.mu.Lock()
+	defer pp.mu.Unlock()
+	pp.provers = append(pp.provers, &proverSlot{
+		name:     name,
+		client:   prover,
+		verifier: verifier,
+		meta:     circuitMeta,
+	})
+}
+
+// slots returns the provers of the pool
+func (pp *ProverPool) slots() []*proverSlot {
+	pp.mu.Lock()
+	defer pp.mu.Unlock()
+	return append([]*proverSlot{}, pp.provers...)
+}
+
+// available returns false if there is no prover with the given name in the
+// pool, or if the last status check of the prover failed
+func (pp *ProverPool) available(name string) bool {
+	pp.mu.Lock()
+	defer pp.mu.Unlock()
+	for _, p := range pp.provers {
+		if p.name == name {
+			return !p.dead
+		}
+	}
+	return false
+}
+
+// acquire assigns the given prover to a proof generation job if it is not
+// assigned already, it is idle and it supports its circuit. Returns false if
+// the prover can not be assigned.
+func (pp *ProverPool) acquire(p *proverSlot) bool {
+	pp.mu.Lock()
+	if p.assigned {
+		pp.mu.Unlock()
+		return false
+	}
+	p.assigned = true
+	pp.mu.Unlock()
+
+	idle, err := pp.checkStatus(p)
+	if err != nil || !idle {
+		pp.release(p)
+		return false
+	}
+	return true
+}
+
+// release unassigns the given prover
+func (pp *ProverPool) release(p *proverSlot) {
+	pp.mu.Lock()
+	defer pp.mu.Unlock()
+	p.assigned = false
+}
+
+// checkStatus returns whether the given prover is idle, or an error if it
+// does not reply or does not support its circuit. The provers that do not
+// implement ProverStatusClient are always idle.
+func (pp *ProverPool) checkStatus(p *proverSlot) (bool, error) {
+	sc, ok := p.client.(ProverStatusClient)
+	if !ok {
+		return true, nil
+	}
+
+	_, err := sc.Status()
+	idle := err == nil
+	if err == proverclient.ErrProverBusy {
+		err = nil
+	}
+	pp.mu.Lock()
+	supported := p.supported
+	pp.mu.Unlock()
+	if err == nil && !supported {
+		err = checkCircuit(sc, p.meta)
+	}
+
+	pp.mu.Lock()
+	defer pp.mu.Unlock()
+	if err != nil {
+		if !p.dead {
+			log.Warnf("prover %s not available: %s", p.name, err)
+		}
+		// the circuits are checked again once the prover replies, as
+		// it may have been restarted with other circuits
+		p.dead, p.supported = true, false
+		return false, err
+	}
+	if p.dead {
+		log.Infof("prover %s available", p.name)
+	}
+	p.dead, p.supported = false, true
+	return idle, nil
+}
+
+// checkCircuit returns an error if the circuit used by the given prover does
+// not match the given ZKCircuitMeta
+func checkCircuit(sc ProverStatusClient, meta types.ZKCircuitMeta) error {
+	circuits, err := sc.Circuits()
+	if err != nil {
+		return err
+	}
+	name := sc.Circuit()
+	if name == "" && len(circuits) != 1 {
+		return fmt.Errorf("prover has %d circuits, the circuit name is needed",
+			len(circuits))
+	}
+	for _, circuit := range circuits {
+		if name != "" && circuit.Name != name {
+			continue
+		}
+		if circuit.NMaxVotes != meta.NMaxVotes || circuit.NLevels != meta.NLevels ||
+			circuit.NOptions != meta.NOptions {
+			return fmt.Errorf("circuit %s (nMaxVotes: %d, nLevels: %d, nOptions: %d)"+
+				" does not match the expected nMaxVotes: %d, nLevels: %d, nOptions: %d",
+				circuit.Name, circuit.NMaxVotes, circuit.NLevels, circuit.NOptions,
+				meta.NMaxVotes, meta.NLevels, meta.NOptions)
+		}
+		return nil
+	}
+	return fmt.Errorf("circuit %s not available in the prover", name)
+}
+
+// waitProof waits until the zkProof of the given proof generation job id is
+// generated by the given prover, checking its status each checkInterval.
+// Returns errProverDied if the prover stops replying.
+func (pp *ProverPool) waitProof(p *proverSlot, proofID string) (*types.ZKProof, error) {
+	type waitResult struct {
+		zkProof *types.ZKProof
+		err     error
+	}
+	// once abandoned, WaitProof returns when its requests to the prover
+	// fail, or when its timeout is reached
+	done := make(chan waitResult, 1)
+	go func() {
+		zkProof, err := p.client.WaitProof(proofID)
+		done <- waitResult{zkProof: zkProof, err: err}
+	}()
+
+	ticker := time.NewTicker(pp.checkInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case r := <-done:
+			if r.err != nil {
+				return nil, pp.checkAlive(p, r.err)
+			}
+			return r.zkProof, nil
+		case <-ticker.C:
+			if _, err := pp.checkStatus(p); err != nil {
+				return nil, fmt.Errorf("%w: %s", errProverDied, err)
+			}
+		}
+	}
+}
+
+// checkAlive returns errProverDied if the given prover does not reply after
+// it returned the given error, and the error otherwise
+func (pp *ProverPool) checkAlive(p *proverSlot, proverErr error) error {
+	if _, err := pp.checkStatus(p); err != nil {
+		return fmt.Errorf("%w: %s", errProverDied, proverErr)
+	}
+	return proverErr
+}
@@ -0,0 +1,208 @@
+package votesaggregator
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aragon/zkmultisig-node/db"
+	"github.com/aragon/zkmultisig-node/proverclient"
+	"github.com/aragon/zkmultisig-node/types"
+	qt "github.com/frankban/quicktest"
+)
+
+// testStatusProver implements the ProverStatusClient interface for testing
+// purposes, on top of a testProver
+type testStatusProver struct {
+	*testProver
+	circuit  string
+	circuits []proverclient.CircuitInfo
+
+	mu        sync.Mutex
+	statusErr error
+}
+
+func newTestStatusProver(meta types.ZKCircuitMeta) *testStatusProver {
+	return &testStatusProver{
+		testProver: &testProver{},
+		circuits: []proverclient.CircuitInfo{
+			{Name: "test", NMaxVotes: meta.NMaxVotes, NLevels: meta.NLevels},
+		},
+	}
+}
+
+func (p *testStatusProver) setStatus(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.statusErr = err
+}
+
+func (p *testStatusProver) Status() (*proverclient.StatusResponse, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.statusErr != nil {
+		return nil, p.statusErr
+	}
+	return &proverclient.StatusResponse{Status: "ok"}, nil
+}
+
+func (p *testStatusProver) Circuits() ([]proverclient.CircuitInfo, error) {
+	return p.circuits, nil
+}
+
+func (p *testStatusProver) Circuit() string {
+	return p.circuit
+}
+
+func TestProverPoolAcquire(t *testing.T) {
+	c := qt.New(t)
+
+	meta := types.ZKCircuitMeta{NMaxVotes: 8, NLevels: 4}
+	prover := newTestStatusProver(meta)
+	pool := NewProverPool(0)
+	pool.Add("a", prover, testVerifier{}, meta)
+	p := pool.slots()[0]
+
+	c.Assert(pool.acquire(p), qt.IsTrue)
+	c.Assert(pool.acquire(p), qt.IsFalse)
+	pool.release(p)
+
+	// a busy prover is available, but it is not assigned new jobs
+	prover.setStatus(proverclient.ErrProverBusy)
+	c.Assert(pool.acquire(p), qt.IsFalse)
+	c.Assert(pool.available("a"), qt.IsTrue)
+
+	prover.setStatus(fmt.Errorf("connection refused"))
+	c.Assert(pool.acquire(p), qt.IsFalse)
+	c.Assert(pool.available("a"), qt.IsFalse)
+	c.Assert(pool.available("b"), qt.IsFalse)
+
+	// once it replies again, its circuits are checked
+	prover.setStatus(nil)
+	prover.circuits[0].NMaxVotes = 16
+	c.Assert(pool.acquire(p), qt.IsFalse)
+	_, err := pool.checkStatus(p)
+	c.Assert(err, qt.ErrorMatches, "circuit test .* does not match .*")
+
+	prover.circuits = append(prover.circuits, proverclient.CircuitInfo{
+		Name: "test8", NMaxVotes: 8, NLevels: 4})
+	_, err = pool.checkStatus(p)
+	c.Assert(err, qt.ErrorMatches, "prover has 2 circuits, the circuit name is needed")
+	prover.circuit = "test8"
+	c.Assert(pool.acquire(p), qt.IsTrue)
+	c.Assert(pool.available("a"), qt.IsTrue)
+}
+
+func TestSyncProcessesProverDied(t *testing.T) {
+	c := qt.New(t)
+
+	va, _ := baseTestVotesAggregator(c, 3, 123, 2, 60)
+	err := va.db.UpdateProcessStatus(123, types.ProcessStatusFrozen)
+	c.Assert(err, qt.IsNil)
+
+	// the first prover stops replying while generating the proof
+	meta := types.ZKCircuitMeta{NMaxVotes: 8, NLevels: 4}
+	prover1 := newTestStatusProver(meta)
+	stop := make(chan struct{})
+	defer close(stop)
+	prover1.onWait = func() {
+		prover1.setStatus(fmt.Errorf("connection refused"))
+		<-stop
+	}
+	prover2 := newTestStatusProver(meta)
+	pool := NewProverPool(10 * time.Millisecond)
+	pool.Add("a", prover1, testVerifier{}, meta)
+	pool.Add("b", prover2, testVerifier{}, meta)
+	va.SetProverPool(pool)
+
+	proved, err := va.proveNext(pool.slots()[0])
+	c.Assert(err, qt.IsNil)
+	c.Assert(proved, qt.IsTrue)
+	c.Assert(len(prover1.requests), qt.Equals, 1)
+
+	// the process is set to be proven again, without counting an attempt
+	status, err := va.db.GetProcessStatus(123)
+	c.Assert(err, qt.IsNil)
+	c.Assert(status, qt.Equals, types.ProcessStatusFrozen)
+	_, err = va.db.ReadProofID(123)
+	c.Assert(err, qt.Equals, db.ErrProofNotInDB)
+	attempts, _, err := va.db.ReadProofError(123)
+	c.Assert(err, qt.IsNil)
+	c.Assert(attempts, qt.Equals, 0)
+
+	proved, err = va.proveNext(pool.slots()[0])
+	c.Assert(err, qt.IsNil)
+	c.Assert(proved, qt.IsFalse)
+	proved, err = va.proveNext(pool.slots()[1])
+	c.Assert(err, qt.IsNil)
+	c.Assert(proved, qt.IsTrue)
+	c.Assert(len(prover2.requests), qt.Equals, 1)
+	status, err = va.db.GetProcessStatus(123)
+	c.Assert(err, qt.IsNil)
+	c.Assert(status, qt.Equals, types.ProcessStatusProofGenerated)
+}
+
+func TestSyncProcessesReassign(t *testing.T) {
+	c := qt.New(t)
+
+	// the node stopped while prover a was generating the proof
+	va, _ := baseTestVotesAggregator(c, 3, 123, 2, 60)
+	err := va.db.UpdateProcessStatus(123, types.ProcessStatusProofGenerating)
+	c.Assert(err, qt.IsNil)
+	c.Assert(va.db.StoreProofID(123, "proof1", "a"), qt.IsNil)
+
+	meta := types.ZKCircuitMeta{NMaxVotes: 8, NLevels: 4}
+	prover1 := newTestStatusProver(meta)
+	prover2 := newTestStatusProver(meta)
+	pool := NewProverPool(0)
+	pool.Add("a", prover1, testVerifier{}, meta)
+	pool.Add("b", prover2, testVerifier{}, meta)
+	va.SetProverPool(pool)
+
+	// the job is kept for prover a while it is available
+	proved, err := va.proveNext(pool.slots()[1])
+	c.Assert(err, qt.IsNil)
+	c.Assert(proved, qt.IsFalse)
+
+	prover1.setStatus(fmt.Errorf("connection refused"))
+	proved, err = va.proveNext(pool.slots()[0])
+	c.Assert(err, qt.IsNil)
+	c.Assert(proved, qt.IsFalse)
+
+	proved, err = va.proveNext(pool.slots()[1])
+	c.Assert(err, qt.IsNil)
+	c.Assert(proved, qt.IsTrue)
+	c.Assert(len(prover1.requests), qt.Equals, 0)
+	c.Assert(len(prover2.requests), qt.Equals, 1)
+	status, err := va.db.GetProcessStatus(123)
+	c.Assert(err, qt.IsNil)
+	c.Assert(status, qt.Equals, types.ProcessStatusProofGenerated)
+}
+
+func TestSyncProcessesCircuitSize(t *testing.T) {
+	c := qt.New(t)
+
+	va, votes := baseTestVotesAggregator(c, 3, 123, 3, 60)
+	for i := 0; i < len(votes); i++ {
+		c.Assert(va.AddVote(123, votes[i]), qt.IsNil)
+	}
+	err := va.db.UpdateProcessStatus(123, types.ProcessStatusFrozen)
+	c.Assert(err, qt.IsNil)
+
+	// the votes of the process do not fit in the circuit of the first
+	// prover
+	small := &testProver{}
+	big := &testProver{}
+	va.AddProver(small, testVerifier{}, types.ZKCircuitMeta{NMaxVotes: 2, NLevels: 4})
+	va.AddProver(big, testVerifier{}, types.ZKCircuitMeta{NMaxVotes: 8, NLevels: 4})
+
+	proved, err := va.proveNext(va.pool.slots()[0])
+	c.Assert(err, qt.IsNil)
+	c.Assert(proved, qt.IsFalse)
+	proved, err = va.proveNext(va.pool.slots()[1])
+	c.Assert(err, qt.IsNil)
+	c.Assert(proved, qt.IsTrue)
+	c.Assert(len(small.requests), qt.Equals, 0)
+	c.Assert(len(big.requests), qt.Equals, 1)
+}
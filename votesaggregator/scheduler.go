@@ -0,0 +1,193 @@
+package votesaggregator
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/aragon/zkmultisig-node/db"
+	"github.com/aragon/zkmultisig-node/types"
+	"go.vocdoni.io/dvote/log"
+)
+
+const (
+	// DefaultBlockTime is the default average time between Ethereum
+	// blocks, used to convert the estimated proving times to blocks
+	DefaultBlockTime = 12 * time.Second
+	// initialProofTimePerVote is the proving time per vote of the circuit
+	// used to estimate the proving time of a circuit until a zkProof has
+	// been generated with it
+	initialProofTimePerVote = time.Second
+	// publishBlocks is the number of blocks reserved after the zkProof
+	// generation to publish the result in the SmartContract
+	publishBlocks = 2
+)
+
+// ChainHead defines the interface used by the VotesAggregator to get the
+// current Ethereum block number, which is implemented by the eth.Client
+type ChainHead interface {
+	// HeadBlockNum returns the number of the last Ethereum block, or 0
+	// if it is not known yet
+	HeadBlockNum() uint64
+}
+
+// SetChainHead sets the ChainHead used to skip the processes whose zkProof
+// can not be generated before the end of their results publishing window,
+// with the given average time between blocks (if 0, DefaultBlockTime is used)
+func (va *VotesAggregator) SetChainHead(head ChainHead, blockTime time.Duration) {
+	if blockTime == 0 {
+		blockTime = DefaultBlockTime
+	}
+	va.head = head
+	va.blockTime = blockTime
+}
+
+// nextProcess returns the next process whose zkProof has to be generated by
+// the given prover, which is the process waiting for a zkProof (in status
+// ProcessStatusFrozen, or ProcessStatusProofGenerating if the node stopped
+// while its zkProof was being generated) with the earliest end of its results
+// publishing window, that is not being proven by another prover and whose
+// votes and options fit in the circuit of the prover. The proof generation
+// jobs sent to another prover are only reassigned if that prover is not
+// available. The processes whose zkProof is estimated to not be generated
+// before the end of their results publishing window are set to
+// ProcessStatusProofExpired. The returned process is marked as running until
+// it is released.
+func (va *VotesAggregator) nextProcess(p *proverSlot) (*types.Process, error) {
+	va.runningMu.Lock()
+	defer va.runningMu.Unlock()
+
+	processes, err := va.db.ReadProcessesByStatus(types.ProcessStatusProofGenerating)
+	if err != nil {
+		return nil, err
+	}
+	frozen, err := va.db.ReadProcessesByStatus(types.ProcessStatusFrozen)
+	if err != nil {
+		return nil, err
+	}
+	processes = append(processes, frozen...)
+	sort.SliceStable(processes, func(i, j int) bool {
+		di, dj := resPubEndBlock(&processes[i]), resPubEndBlock(&processes[j])
+		if di != dj {
+			return di < dj
+		}
+		return processes[i].ID < processes[j].ID
+	})
+
+	var headBlockNum uint64
+	if va.head != nil {
+		headBlockNum = va.head.HeadBlockNum()
+	}
+	for i := 0; i < len(processes); i++ {
+		process := &processes[i]
+		if va.running[process.ID] {
+			continue
+		}
+		reassign := false
+		if process.Status == types.ProcessStatusProofGenerating {
+			prover, err := va.db.ReadProofProver(process.ID)
+			if err != nil && err != db.ErrProofNotInDB {
+				return nil, err
+			}
+			if err == nil && prover != p.name {
+				if va.pool.available(prover) {
+					continue
+				}
+				reassign = true
+			}
+		}
+		nOptions, err := types.ProcessTypeOptions(process.Type)
+		if err != nil || nOptions != p.meta.NOptions {
+			continue
+		}
+		nVotes, err := va.db.CountVotePackages(process.ID)
+		if err != nil {
+			return nil, err
+		}
+		if nVotes > p.meta.NMaxVotes {
+			continue
+		}
+		if headBlockNum != 0 {
+			proofBlocks := va.estimateProofBlocks(p.meta)
+			if headBlockNum+proofBlocks > resPubEndBlock(process) {
+				log.Warnf("[ProcessID=%d] proof can not be generated before the end"+
+					" of the results publishing window (block %d, estimated %d blocks),"+
+					" skipping it", process.ID, resPubEndBlock(process), proofBlocks)
+				err := va.db.UpdateProcessStatus(process.ID,
+					types.ProcessStatusProofExpired)
+				if err != nil {
+					return nil, err
+				}
+				continue
+			}
+		}
+		if reassign {
+			log.Infof("[ProcessID=%d] prover of the proof generation job not"+
+				" available, reassigning it to prover %s", process.ID, p.name)
+			if err := va.db.ReleaseProofID(process.ID); err != nil {
+				return nil, err
+			}
+		}
+		va.running[process.ID] = true
+		return process, nil
+	}
+	return nil, nil
+}
+
+// release unmarks the given processID as running
+func (va *VotesAggregator) release(processID uint64) {
+	va.runningMu.Lock()
+	defer va.runningMu.Unlock()
+	delete(va.running, processID)
+}
+
+// estimateProofBlocks returns the estimated number of blocks needed to
+// generate a zkProof with the circuit of the given ZKCircuitMeta and to
+// publish its result
+func (va *VotesAggregator) estimateProofBlocks(meta types.ZKCircuitMeta) uint64 {
+	d := va.proofTimes.estimate(meta)
+	blocks := uint64((d + va.blockTime - 1) / va.blockTime)
+	return blocks + publishBlocks
+}
+
+// resPubEndBlock returns the last block of the results publishing window of
+// the given process
+func resPubEndBlock(process *types.Process) uint64 {
+	return process.ResPubStartBlock + process.ResPubWindow
+}
+
+// proofTimes keeps the estimated time to generate a zkProof for each circuit
+// size, as the moving average of the times of the generated zkProofs
+type proofTimes struct {
+	mu    sync.Mutex
+	times map[types.ZKCircuitMeta]time.Duration
+}
+
+func newProofTimes() *proofTimes {
+	return &proofTimes{times: make(map[types.ZKCircuitMeta]time.Duration)}
+}
+
+// estimate returns the estimated time to generate a zkProof with the circuit
+// of the given ZKCircuitMeta, which until a zkProof has been generated with it
+// is proportional to its nMaxVotes
+func (pt *proofTimes) estimate(meta types.ZKCircuitMeta) time.Duration {
+	pt.mu.Lock()
+	defer pt.mu.Unlock()
+	if d, ok := pt.times[meta]; ok {
+		return d
+	}
+	return time.Duration(meta.NMaxVotes) * initialProofTimePerVote
+}
+
+// add adds the time of a generated zkProof with the circuit of the given
+// ZKCircuitMeta, weighting the previous estimation by 3/4
+func (pt *proofTimes) add(meta types.ZKCircuitMeta, d time.Duration) {
+	pt.mu.Lock()
+	defer pt.mu.Unlock()
+	prev, ok := pt.times[meta]
+	if !ok {
+		pt.times[meta] = d
+		return
+	}
+	pt.times[meta] = (3*prev + d) / 4 //nolint:gomnd
+}
@@ -1,9 +1,11 @@
 package votesaggregator
 
 import (
+	"errors"
 	"fmt"
 	"math"
 	"math/big"
+	"sync"
 	"time"
 
 	"github.com/aragon/zkmultisig-node/db"
@@ -12,42 +14,239 @@ import (
 	"go.vocdoni.io/dvote/log"
 )
 
-const syncSleepTime = 6
+const (
+	syncSleepTime = 6
+	// maxProofAttempts determines the number of times that the proof
+	// generation of a process is retried before setting it to
+	// ProcessStatusProofFailed
+	maxProofAttempts = 3
+)
+
+// ProverClient defines the interface used by the VotesAggregator to request
+// the zkProof generation to a prover
+type ProverClient interface {
+	// GenProof sends the given ZKInputs to the prover, and returns the id
+	// of the proof generation job
+	GenProof(zki *types.ZKInputs) (string, error)
+	// WaitProof blocks until the zkProof of the given proof generation job
+	// id is generated, and returns it
+	WaitProof(id string) (*types.ZKProof, error)
+}
+
+// ProofVerifier defines the interface used by the VotesAggregator to verify
+// the zkProofs returned by the prover before storing them
+type ProofVerifier interface {
+	// Verify checks that the given zkProof is valid and that its public
+	// inputs match the given ZKInputs
+	Verify(zki *types.ZKInputs, zkProof *types.ZKProof) error
+}
 
 // VotesAggregator receives the votes and aggregates them to generate a zkProof
 type VotesAggregator struct {
 	db      *db.SQLite
 	chainID uint64 // determined by config
+
+	// pool contains the provers used to generate the zkProofs, which
+	// generate them concurrently
+	pool *ProverPool
+	// head and blockTime are used to schedule the zkProofs by the
+	// remaining blocks of the results publishing window of each process
+	head       ChainHead
+	blockTime  time.Duration
+	proofTimes *proofTimes
+	// running contains the processIDs whose zkProof is being generated
+	// by a prover
+	runningMu sync.Mutex
+	running   map[uint64]bool
+	// voteReplacement allows the voters to replace their vote while the
+	// process is in ProcessStatusOn
+	voteReplacement bool
 }
 
 // New returns a VotesAggregator with the given SQLite db
 func New(sqlite *db.SQLite, chainID uint64) (*VotesAggregator, error) {
-	return &VotesAggregator{db: sqlite, chainID: chainID}, nil
+	return &VotesAggregator{
+		db:         sqlite,
+		chainID:    chainID,
+		pool:       NewProverPool(0),
+		blockTime:  DefaultBlockTime,
+		proofTimes: newProofTimes(),
+		running:    make(map[uint64]bool),
+	}, nil
+}
+
+// SetProver sets the ProverClient that will be used to generate the zkProofs,
+// together with the ProofVerifier used to check them and the ZKCircuitMeta of
+// the circuit used by the prover, replacing the provers already set
+func (va *VotesAggregator) SetProver(prover ProverClient, verifier ProofVerifier,
+	circuitMeta types.ZKCircuitMeta) {
+	va.pool = NewProverPool(0)
+	va.AddProver(prover, verifier, circuitMeta)
+}
+
+// AddProver adds a ProverClient to the provers used to generate the zkProofs,
+// together with the ProofVerifier used to check them and the ZKCircuitMeta of
+// the circuit used by the prover. Each prover generates the zkProof of a
+// different process concurrently. The prover is identified by its position,
+// use SetProverPool to identify the provers by name.
+func (va *VotesAggregator) AddProver(prover ProverClient, verifier ProofVerifier,
+	circuitMeta types.ZKCircuitMeta) {
+	va.pool.Add(fmt.Sprintf("prover%d", len(va.pool.slots())), prover, verifier,
+		circuitMeta)
+}
+
+// SetProverPool sets the ProverPool used to generate the zkProofs, which can
+// be shared with the VotesAggregators of other networks, replacing the
+// provers already set
+func (va *VotesAggregator) SetProverPool(pool *ProverPool) {
+	va.pool = pool
+}
+
+// SetVoteReplacement sets whether a newer vote of a census index replaces its
+// stored vote while the process is in ProcessStatusOn. The replaced votes are
+// kept in the vote history of the db. By default, only the first vote of each
+// census index is accepted.
+func (va *VotesAggregator) SetVoteReplacement(enabled bool) {
+	va.voteReplacement = enabled
 }
 
 // SyncProcesses actively checks if there are any processes closed, to trigger
-// the generation of the zkInputs & zkProof of them. This method is designed to
-// be called in a goroutine
+// the generation of the zkInputs & zkProof of them, in each of the provers
+// concurrently. This method is designed to be called in a goroutine
 func (va *VotesAggregator) SyncProcesses() {
-	for {
-		// if there are Frozen processes, generate their zkProofs
-		processes, err := va.db.ReadProcessesByStatus(types.ProcessStatusFrozen)
+	provers := va.pool.slots()
+	if len(provers) == 0 {
+		log.Error("can not generate proofs, prover not set")
+		return
+	}
+	var wg sync.WaitGroup
+	for _, p := range provers {
+		wg.Add(1)
+		go func(p *proverSlot) {
+			defer wg.Done()
+			for {
+				proved, err := va.proveNext(p)
+				if err != nil {
+					log.Error(err)
+				}
+				if !proved {
+					time.Sleep(syncSleepTime * time.Second)
+				}
+			}
+		}(p)
+	}
+	wg.Wait()
+}
+
+// proveNext generates with the given prover the zkProof of the next scheduled
+// process, see nextProcess. Returns false if the prover is not available or
+// there was no process to prove.
+func (va *VotesAggregator) proveNext(p *proverSlot) (bool, error) {
+	if !va.pool.acquire(p) {
+		return false, nil
+	}
+	defer va.pool.release(p)
+	process, err := va.nextProcess(p)
+	if err != nil || process == nil {
+		return false, err
+	}
+	defer va.release(process.ID)
+
+	processID := process.ID
+	err = va.generateProof(p, processID)
+	if err == nil {
+		return true, nil
+	}
+	if errors.Is(err, errProverDied) {
+		// the proof is requested again to another prover, without
+		// counting it as a failed attempt
+		log.Warnf("[ProcessID=%d] proof generation interrupted, prover %s: %s",
+			processID, p.name, err)
+		if err := va.db.ReleaseProofID(processID); err != nil {
+			return true, err
+		}
+		return true, va.db.UpdateProcessStatus(processID, types.ProcessStatusFrozen)
+	}
+	log.Warnf("[ProcessID=%d] proof generation failed: %s", processID, err)
+
+	// store the error, and depending on the number of attempts, set the
+	// process to be retried or to ProcessStatusProofFailed
+	attempts, errDB := va.db.StoreProofError(processID, err)
+	if errDB != nil {
+		return true, errDB
+	}
+	if attempts >= maxProofAttempts {
+		return true, va.db.UpdateProcessStatus(processID, types.ProcessStatusProofFailed)
+	}
+	return true, va.db.UpdateProcessStatus(processID, types.ProcessStatusFrozen)
+}
+
+// generateProof computes the zkInputs of the given processID, sends them to
+// the prover and waits until the proof is generated, storing it in the db
+// once it has been verified. If a proof generation job was already sent to
+// the prover for the processID, instead of sending the zkInputs again it
+// waits for the stored job. Returns errProverDied if the prover stops
+// replying.
+func (va *VotesAggregator) generateProof(p *proverSlot, processID uint64) error {
+	proofID, err := va.db.ReadProofID(processID)
+	if err != nil && err != db.ErrProofNotInDB {
+		return err
+	}
+	jobSent := err == nil
+
+	// generate zkInputs for the process, which are also needed to verify
+	// the public inputs of the proof of an already sent job
+	zki, err := va.GenerateZKInputs(processID, p.meta.NMaxVotes, p.meta.NLevels)
+	if err != nil {
+		return err
+	}
+	start := time.Now()
+	if !jobSent {
+		err = va.db.UpdateProcessStatus(processID, types.ProcessStatusProofGenerating)
+		if err != nil {
+			return err
+		}
+
+		// send the zkInputs to the prover
+		proofID, err = p.client.GenProof(zki)
 		if err != nil {
-			log.Error(err)
+			return va.pool.checkAlive(p, err)
 		}
-		if len(processes) > 0 {
-			process := processes[0]
-			// WIP
-			_ = process
-			// generate zkInputs for the process
-			// wait until prover is ready
-			// send the zkInputs to the prover
-			// zkProof, err := va.proverClient.ComputeProof(zkInputs)
-			// update ProcessStatus to types.ProcessStatusFinished
+		if err := va.db.StoreProofID(processID, proofID, p.name); err != nil {
+			return err
 		}
+		log.Infof("[ProcessID=%d] proof requested to the prover %s, id: %s",
+			processID, p.name, proofID)
+	}
+
+	// wait until the proof is generated
+	zkProof, err := va.pool.waitProof(p, proofID)
+	if err != nil {
+		return err
+	}
+	if err := p.verifier.Verify(zki, zkProof); err != nil {
+		return fmt.Errorf("proof %s verification failed: %w", proofID, err)
+	}
+	// the time of the resumed jobs is not known
+	if !jobSent {
+		va.proofTimes.add(p.meta, time.Since(start))
+	}
 
-		time.Sleep(syncSleepTime * time.Second)
+	if err := va.db.StoreProof(processID, zkProof); err != nil {
+		return err
 	}
+	log.Infof("[ProcessID=%d] proof generated", processID)
+
+	// the result may have been published, or the process closed, while
+	// the proof was being generated, in which case the status is kept
+	status, err := va.db.GetProcessStatus(processID)
+	if err != nil {
+		return err
+	}
+	if status != types.ProcessStatusProofGenerating {
+		return nil
+	}
+	return va.db.UpdateProcessStatus(processID, types.ProcessStatusProofGenerated)
 }
 
 // ProcessInfo returns info about the Process
@@ -56,33 +255,50 @@ func (va *VotesAggregator) ProcessInfo(processID uint64) (*types.Process, error)
 	return va.db.ReadProcessByID(processID)
 }
 
+// ProcessResult returns the result of the Process published in the
+// SmartContract, flagging if it does not match the one computed by the node
+func (va *VotesAggregator) ProcessResult(processID uint64) (*types.ProcessResult, error) {
+	return va.db.ReadResult(processID)
+}
+
 // AddVote adds to the VotesAggregator's db the given vote for the given
-// CensusRoot
+// CensusRoot. If the vote replacement is enabled, the vote replaces the
+// previous vote of its census index.
 func (va *VotesAggregator) AddVote(processID uint64, votePackage types.VotePackage) error {
-	// for this initial version, only vote values with 0 or 1 are supported
-	// TODO check vote value inside range
-
 	// get the process from the db. It's assumed that if the processID
 	// exists in the db, it exists in the SmartContract
 	process, err := va.db.ReadProcessByID(processID)
 	if err != nil {
 		return err
 	}
+	if process.Status == types.ProcessStatusQuarantined {
+		return fmt.Errorf("process data does not match the SmartContract," +
+			" votes can not be added")
+	}
 	if process.Status != types.ProcessStatusOn {
 		return fmt.Errorf("process ResPubStartBlock (%d) reached,"+
 			" votes can not be added", process.ResPubStartBlock)
 	}
 
-	// check signature (babyjubjub) and MerkleProof
-	if err := votePackage.Verify(va.chainID, processID, process.CensusRoot); err != nil {
+	// check signature (babyjubjub), MerkleProof and vote value
+	err = votePackage.Verify(va.chainID, processID, process.CensusRoot, process.Type)
+	if err != nil {
 		return err
 	}
 
 	// store VotePackage in the SQL DB for the given CensusRoot
+	if va.voteReplacement {
+		// the process status is checked again by the db, as the process
+		// may have been frozen meanwhile
+		return va.db.ReplaceVotePackage(processID, votePackage)
+	}
 	return va.db.StoreVotePackage(processID, votePackage)
 }
 
-// GenerateZKInputs will generate the zkInputs for the given processID
+// GenerateZKInputs will generate the zkInputs for the given processID. For
+// the multi-choice processes, the zkInputs contain the weighted sum of the
+// votes of each option instead of the weighted sum of the vote values, as the
+// vote value is the index of the chosen option, and their Result is 0.
 func (va *VotesAggregator) GenerateZKInputs(processID uint64, nMaxVotes,
 	nLevels /* tmp */ int) (*types.ZKInputs, error) {
 	// TODO TMP, nMaxVotes & nLevels will be defined by the compiled circuits
@@ -95,6 +311,17 @@ func (va *VotesAggregator) GenerateZKInputs(processID uint64, nMaxVotes,
 		return nil, err
 	}
 	z.CensusRoot = arbo.BytesToBigInt(process.CensusRoot)
+	nOptions, err := types.ProcessTypeOptions(process.Type)
+	if err != nil {
+		return nil, err
+	}
+	if nOptions > 0 {
+		z.Meta.NOptions = nOptions
+		z.Results = make([]*big.Int, nOptions)
+		for i := range z.Results {
+			z.Results[i] = big.NewInt(0)
+		}
+	}
 
 	var receiptsKeys [][]byte
 	var receiptsValues [][]byte
@@ -107,11 +334,19 @@ func (va *VotesAggregator) GenerateZKInputs(processID uint64, nMaxVotes,
 	}
 	r := big.NewInt(0)
 	for i := 0; i < len(votes); i++ {
+		// the votes are checked when added, but the ones stored by
+		// previous versions of the node were not
+		if err := votes[i].CheckVoteValue(process.Type); err != nil {
+			return nil, fmt.Errorf("vote of index %d: %w", votes[i].CensusProof.Index, err)
+		}
 		voteBI := arbo.BytesToBigInt(votes[i].Vote)
-		if voteBI.Cmp(big.NewInt(1)) == 1 { // voteBI > 1:
-			return nil, fmt.Errorf("invalid vote value") // TODO better error handling
+		if nOptions > 0 {
+			option := voteBI.Int64()
+			z.Results[option] = new(big.Int).Add(z.Results[option],
+				votes[i].CensusProof.Weight)
+		} else {
+			r = new(big.Int).Add(r, new(big.Int).Mul(voteBI, votes[i].CensusProof.Weight))
 		}
-		r = new(big.Int).Add(r, new(big.Int).Mul(voteBI, votes[i].CensusProof.Weight))
 		// TODO ensure that Weight does not overflow the field
 		z.Vote[i] = voteBI
 		z.Index[i] = big.NewInt(int64(votes[i].CensusProof.Index))
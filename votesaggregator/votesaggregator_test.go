@@ -3,20 +3,33 @@ package votesaggregator
 import (
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
+	"math/big"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/aragon/zkmultisig-node/db"
 	"github.com/aragon/zkmultisig-node/test"
 	"github.com/aragon/zkmultisig-node/types"
+	"github.com/aragon/zkmultisig-node/verifier"
 	qt "github.com/frankban/quicktest"
 	_ "github.com/mattn/go-sqlite3"
 )
 
 func baseTestVotesAggregator(c *qt.C, chainID, processID uint64, nVotes, ratio int) (
 	*VotesAggregator, []types.VotePackage) {
+	va := newTestVotesAggregator(c, chainID)
+	keys := test.GenUserKeys(nVotes)
+	testCensus := storeTestProcess(c, va, processID, types.ProcessTypeReferendum, keys)
+	votes := test.GenVotes(c, testCensus, chainID, processID, ratio)
+	return va, votes
+}
+
+// newTestVotesAggregator returns a VotesAggregator with an empty db
+func newTestVotesAggregator(c *qt.C, chainID uint64) *VotesAggregator {
 	sqlDB, err := sql.Open("sqlite3", filepath.Join(c.TempDir(), "testdb.sqlite3"))
 	c.Assert(err, qt.IsNil)
 
@@ -26,17 +39,21 @@ func baseTestVotesAggregator(c *qt.C, chainID, processID uint64, nVotes, ratio i
 
 	va, err := New(sqlite, chainID)
 	c.Assert(err, qt.IsNil)
+	return va
+}
 
+// storeTestProcess stores a process of the given type with a census of the
+// given keys, and returns the census to generate the votes of the process
+func storeTestProcess(c *qt.C, va *VotesAggregator, processID uint64, typ uint8,
+	keys test.Keys) *test.Census {
 	// prepare the census
-	keys := test.GenUserKeys(nVotes)
 	testCensus := test.GenCensus(c, keys)
-	err = testCensus.Census.Close()
+	err := testCensus.Census.Close()
 	c.Assert(err, qt.IsNil)
 
 	censusRoot, err := testCensus.Census.Root()
 	c.Assert(err, qt.IsNil)
 	censusSize := uint64(len(keys.PublicKeys))
-	votes := test.GenVotes(c, testCensus, chainID, processID, ratio)
 
 	// store a process for the test
 	ethBlockNum := uint64(10)
@@ -44,13 +61,12 @@ func baseTestVotesAggregator(c *qt.C, chainID, processID uint64, nVotes, ratio i
 	resultsPublishingWindow := uint64(20)
 	minParticipation := uint8(20)
 	minPositiveVotes := uint8(60)
-	typ := uint8(1)
-	err = sqlite.StoreProcess(processID, censusRoot, censusSize,
+	err = va.db.StoreProcess(processID, censusRoot, censusSize,
 		ethBlockNum, ethEndBlockNum, resultsPublishingWindow, minParticipation,
 		minPositiveVotes, typ)
 	c.Assert(err, qt.IsNil)
 
-	return va, votes
+	return testCensus
 }
 
 func TestStoreAndReadVotes(t *testing.T) {
@@ -70,7 +86,7 @@ func TestStoreAndReadVotes(t *testing.T) {
 	// try to store a vote with already stored index
 	err = va.AddVote(processID, votes[0])
 	c.Assert(err, qt.Not(qt.IsNil))
-	c.Assert(err.Error(), qt.Equals, "UNIQUE constraint failed: votepackages.indx")
+	c.Assert(err.Error(), qt.Equals, "vote with index 0 already exists for ProcessID=123")
 
 	// try to store invalid merkleproofs
 	votes[0].CensusProof.Index = 11
@@ -83,6 +99,146 @@ func TestStoreAndReadVotes(t *testing.T) {
 	c.Assert(err.Error(), qt.Equals, "signature verification failed")
 }
 
+func TestAddVoteQuarantined(t *testing.T) {
+	c := qt.New(t)
+
+	chainID := uint64(3)
+	processID := uint64(123)
+	va, votes := baseTestVotesAggregator(c, chainID, processID, 2, 60)
+
+	err := va.db.UpdateProcessStatus(processID, types.ProcessStatusQuarantined)
+	c.Assert(err, qt.IsNil)
+	err = va.AddVote(processID, votes[0])
+	c.Assert(err, qt.ErrorMatches,
+		"process data does not match the SmartContract, votes can not be added")
+}
+
+func TestAddVoteValue(t *testing.T) {
+	c := qt.New(t)
+
+	chainID := uint64(3)
+	va := newTestVotesAggregator(c, chainID)
+
+	for _, tc := range []struct {
+		typ    uint8
+		values []uint64
+		errs   []string
+	}{
+		{types.ProcessTypeReferendum, []uint64{1, 0, 2, 1 << 40},
+			[]string{"", "", "invalid vote value 2, .*", "invalid vote value 1099511627776, .*"}},
+		{types.ProcessTypeMultiChoice | 3, []uint64{2, 3},
+			[]string{"", "invalid vote value 3, the maximum vote value of the process type 131 is 2"}},
+		{2, []uint64{0}, []string{"unknown process type 2"}},
+	} {
+		processID := uint64(tc.typ)
+		testCensus := storeTestProcess(c, va, processID, tc.typ,
+			test.GenUserKeys(len(tc.values)))
+		votes := test.GenVotesWithValues(c, testCensus, chainID, processID, tc.values)
+		for i := range votes {
+			err := va.AddVote(processID, votes[i])
+			if tc.errs[i] == "" {
+				c.Assert(err, qt.IsNil)
+				continue
+			}
+			c.Assert(err, qt.ErrorMatches, tc.errs[i])
+		}
+	}
+}
+
+func TestAddVoteReplacement(t *testing.T) {
+	c := qt.New(t)
+
+	chainID := uint64(3)
+	processID := uint64(123)
+	va := newTestVotesAggregator(c, chainID)
+	keys := test.GenUserKeys(3)
+	testCensus := storeTestProcess(c, va, processID, types.ProcessTypeReferendum, keys)
+	votes := test.GenVotesWithValues(c, testCensus, chainID, processID,
+		[]uint64{1, 1, 1})
+	for i := range votes {
+		c.Assert(va.AddVote(processID, votes[i]), qt.IsNil)
+	}
+	newVotes := test.GenVotesWithValues(c, testCensus, chainID, processID,
+		[]uint64{0, 1, 0})
+
+	// by default, a census index can only vote once
+	err := va.AddVote(processID, newVotes[0])
+	var dupErr *db.DuplicateVoteError
+	c.Assert(errors.As(err, &dupErr), qt.IsTrue)
+
+	va.SetVoteReplacement(true)
+	c.Assert(va.AddVote(processID, newVotes[0]), qt.IsNil)
+	c.Assert(va.AddVote(processID, newVotes[2]), qt.IsNil)
+	// the replacement votes are also verified
+	invalid := newVotes[1]
+	invalid.Vote = []byte{2}
+	c.Assert(va.AddVote(processID, invalid), qt.ErrorMatches, "signature verification failed")
+
+	// only the latest vote of each index is used
+	zki, err := va.GenerateZKInputs(processID, 8, 4)
+	c.Assert(err, qt.IsNil)
+	c.Assert(zki.NVotes.String(), qt.Equals, "3")
+	c.Assert(zki.Result.String(), qt.Equals, "1")
+	history, err := va.db.ReadVoteHistory(processID, votes[0].CensusProof.Index)
+	c.Assert(err, qt.IsNil)
+	c.Assert(len(history), qt.Equals, 1)
+	c.Assert(history[0].Vote, qt.DeepEquals, votes[0].Vote)
+
+	// once the process is frozen, the votes can not be replaced
+	err = va.db.UpdateProcessStatus(processID, types.ProcessStatusFrozen)
+	c.Assert(err, qt.IsNil)
+	err = va.AddVote(processID, votes[0])
+	c.Assert(err, qt.ErrorMatches, "process ResPubStartBlock .* reached, votes can not be added")
+}
+
+func TestGenerateZKInputsMultiChoice(t *testing.T) {
+	c := qt.New(t)
+
+	chainID := uint64(3)
+	processID := uint64(123)
+	va := newTestVotesAggregator(c, chainID)
+	keys := test.GenUserKeys(5)
+	for i := range keys.Weights {
+		keys.Weights[i] = big.NewInt(int64(i + 1))
+	}
+	testCensus := storeTestProcess(c, va, processID, types.ProcessTypeMultiChoice|3, keys)
+	votes := test.GenVotesWithValues(c, testCensus, chainID, processID,
+		[]uint64{0, 2, 2, 1, 2})
+	for i := range votes {
+		c.Assert(va.AddVote(processID, votes[i]), qt.IsNil)
+	}
+
+	// the results contain the weighted sum of the votes of each option,
+	// and the result the weighted sum of the vote values
+	zki, err := va.GenerateZKInputs(processID, 8, 4)
+	c.Assert(err, qt.IsNil)
+	c.Assert(zki.Meta, qt.Equals, types.ZKCircuitMeta{NMaxVotes: 8, NLevels: 4, NOptions: 3})
+	c.Assert(fmt.Sprint(zki.Results), qt.Equals, "[1 4 10]")
+	c.Assert(zki.Result.String(), qt.Equals, "0")
+	c.Assert(zki.NVotes.String(), qt.Equals, "5")
+	c.Assert(zki.CheckDimensions(zki.Meta), qt.IsNil)
+	c.Assert(len(zki.PublicInputs()), qt.Equals, 10)
+
+	// the process is only proven by the provers of circuits of 3 options
+	err = va.db.UpdateProcessStatus(processID, types.ProcessStatusFrozen)
+	c.Assert(err, qt.IsNil)
+	binary := &testProver{}
+	multiChoice := &testProver{}
+	va.AddProver(binary, testVerifier{}, types.ZKCircuitMeta{NMaxVotes: 8, NLevels: 4})
+	va.AddProver(multiChoice, testVerifier{},
+		types.ZKCircuitMeta{NMaxVotes: 8, NLevels: 4, NOptions: 3})
+	proved, err := va.proveNext(va.pool.slots()[0])
+	c.Assert(err, qt.IsNil)
+	c.Assert(proved, qt.IsFalse)
+	proved, err = va.proveNext(va.pool.slots()[1])
+	c.Assert(err, qt.IsNil)
+	c.Assert(proved, qt.IsTrue)
+	c.Assert(len(multiChoice.requests), qt.Equals, 1)
+	zkProof, err := va.db.ReadProof(processID)
+	c.Assert(err, qt.IsNil)
+	c.Assert(zkProof.PublicInputs[7:], qt.DeepEquals, []string{"1", "4", "10"})
+}
+
 func TestGenerateZKInputs(t *testing.T) {
 	c := qt.New(t)
 	testGenerateZKInputs(c, 3, 3, 1, 60)
@@ -116,3 +272,358 @@ func testGenerateZKInputs(c *qt.C, nMaxVotes, nLevels, nVotes, ratio int) {
 	err = ioutil.WriteFile(filename, s, 0600)
 	c.Assert(err, qt.IsNil)
 }
+
+// testProver implements the ProverClient interface for testing purposes. The
+// returned proofs contain the public inputs of the requested zkInputs, unless
+// wrongPublicInputs is set.
+type testProver struct {
+	err               error
+	wrongPublicInputs bool
+	requests          []*types.ZKInputs
+	// onWait is called when waiting a proof, if it is set
+	onWait func()
+}
+
+func (p *testProver) GenProof(zki *types.ZKInputs) (string, error) {
+	if p.err != nil {
+		return "", p.err
+	}
+	p.requests = append(p.requests, zki)
+	return fmt.Sprintf("proof%d", len(p.requests)), nil
+}
+
+func (p *testProver) WaitProof(id string) (*types.ZKProof, error) {
+	var i int
+	if _, err := fmt.Sscanf(id, "proof%d", &i); err != nil || i > len(p.requests) {
+		return nil, fmt.Errorf("proof %s not found", id)
+	}
+	if p.onWait != nil {
+		p.onWait()
+	}
+	zkProof := &types.ZKProof{
+		Proof: types.Groth16Proof{A: []string{id}, Protocol: "groth16"},
+	}
+	for _, v := range p.requests[i-1].PublicInputs() {
+		zkProof.PublicInputs = append(zkProof.PublicInputs, v.String())
+	}
+	if p.wrongPublicInputs {
+		zkProof.PublicInputs[4] = "1000"
+	}
+	return zkProof, nil
+}
+
+// testVerifier implements the ProofVerifier interface for testing purposes,
+// checking only the public inputs of the proofs
+type testVerifier struct{}
+
+func (testVerifier) Verify(zki *types.ZKInputs, zkProof *types.ZKProof) error {
+	return verifier.CheckPublicInputs(zki, zkProof.PublicInputs)
+}
+
+// syncProcesses generates with the first prover the zkProof of the next
+// scheduled process
+func (va *VotesAggregator) syncProcesses() error {
+	_, err := va.proveNext(va.pool.slots()[0])
+	return err
+}
+
+func TestSyncProcesses(t *testing.T) {
+	c := qt.New(t)
+
+	nVotes := 5
+	chainID := uint64(3)
+	processID := uint64(123)
+	va, votes := baseTestVotesAggregator(c, chainID, processID, nVotes, 60)
+
+	prover := &testProver{}
+	va.SetProver(prover, testVerifier{}, types.ZKCircuitMeta{NMaxVotes: 8, NLevels: 4})
+
+	for i := 0; i < len(votes); i++ {
+		err := va.AddVote(processID, votes[i])
+		c.Assert(err, qt.IsNil)
+	}
+
+	// process is not frozen yet, expect no proof requested
+	err := va.syncProcesses()
+	c.Assert(err, qt.IsNil)
+	c.Assert(len(prover.requests), qt.Equals, 0)
+
+	err = va.db.UpdateProcessStatus(processID, types.ProcessStatusFrozen)
+	c.Assert(err, qt.IsNil)
+
+	err = va.syncProcesses()
+	c.Assert(err, qt.IsNil)
+	c.Assert(len(prover.requests), qt.Equals, 1)
+	c.Assert(prover.requests[0].NVotes.Int64(), qt.Equals, int64(nVotes))
+
+	status, err := va.db.GetProcessStatus(processID)
+	c.Assert(err, qt.IsNil)
+	c.Assert(status, qt.Equals, types.ProcessStatusProofGenerated)
+
+	zkProof, err := va.db.ReadProof(processID)
+	c.Assert(err, qt.IsNil)
+	c.Assert(zkProof.Proof.A, qt.DeepEquals, []string{"proof1"})
+	c.Assert(zkProof.PublicInputs[:2], qt.DeepEquals, []string{"3", "123"})
+}
+
+func TestSyncProcessesResultPublished(t *testing.T) {
+	c := qt.New(t)
+
+	nVotes := 5
+	chainID := uint64(3)
+	processID := uint64(123)
+	va, votes := baseTestVotesAggregator(c, chainID, processID, nVotes, 60)
+
+	// the result is published by another party while the proof is being
+	// generated, with a different number of votes
+	prover := &testProver{onWait: func() {
+		err := va.db.StoreResult(&types.ProcessResult{ProcessID: processID,
+			Publisher: []byte("publisher"), ReceiptsRoot: []byte("root"),
+			Result: 3, NVotes: uint64(nVotes + 1), EthBlockNum: 25})
+		c.Assert(err, qt.IsNil)
+	}}
+	va.SetProver(prover, testVerifier{}, types.ZKCircuitMeta{NMaxVotes: 8, NLevels: 4})
+
+	for i := 0; i < len(votes); i++ {
+		err := va.AddVote(processID, votes[i])
+		c.Assert(err, qt.IsNil)
+	}
+	err := va.db.UpdateProcessStatus(processID, types.ProcessStatusFrozen)
+	c.Assert(err, qt.IsNil)
+
+	err = va.syncProcesses()
+	c.Assert(err, qt.IsNil)
+	c.Assert(len(prover.requests), qt.Equals, 1)
+
+	// the process keeps the status set by the result, which is flagged as
+	// it does not match the generated proof
+	status, err := va.db.GetProcessStatus(processID)
+	c.Assert(err, qt.IsNil)
+	c.Assert(status, qt.Equals, types.ProcessStatusResultsPublished)
+	result, err := va.db.ReadResult(processID)
+	c.Assert(err, qt.IsNil)
+	c.Assert(result.Mismatch, qt.IsTrue)
+}
+
+func TestSyncProcessesInvalidProof(t *testing.T) {
+	c := qt.New(t)
+
+	chainID := uint64(3)
+	processID := uint64(123)
+	va, votes := baseTestVotesAggregator(c, chainID, processID, 3, 60)
+
+	prover := &testProver{wrongPublicInputs: true}
+	va.SetProver(prover, testVerifier{}, types.ZKCircuitMeta{NMaxVotes: 8, NLevels: 4})
+
+	for i := 0; i < len(votes); i++ {
+		err := va.AddVote(processID, votes[i])
+		c.Assert(err, qt.IsNil)
+	}
+	err := va.db.UpdateProcessStatus(processID, types.ProcessStatusFrozen)
+	c.Assert(err, qt.IsNil)
+
+	// the proof is not stored, and the process is set back to Frozen to
+	// request a new proof
+	err = va.syncProcesses()
+	c.Assert(err, qt.IsNil)
+	status, err := va.db.GetProcessStatus(processID)
+	c.Assert(err, qt.IsNil)
+	c.Assert(status, qt.Equals, types.ProcessStatusFrozen)
+	_, err = va.db.ReadProof(processID)
+	c.Assert(err, qt.Equals, db.ErrProofNotInDB)
+	attempts, lastErr, err := va.db.ReadProofError(processID)
+	c.Assert(err, qt.IsNil)
+	c.Assert(attempts, qt.Equals, 1)
+	c.Assert(lastErr, qt.Matches, "proof proof1 verification failed: .*public input 4.*")
+
+	prover.wrongPublicInputs = false
+	err = va.syncProcesses()
+	c.Assert(err, qt.IsNil)
+	c.Assert(len(prover.requests), qt.Equals, 2)
+	status, err = va.db.GetProcessStatus(processID)
+	c.Assert(err, qt.IsNil)
+	c.Assert(status, qt.Equals, types.ProcessStatusProofGenerated)
+}
+
+func TestSyncProcessesRetries(t *testing.T) {
+	c := qt.New(t)
+
+	chainID := uint64(3)
+	processID := uint64(123)
+	va, votes := baseTestVotesAggregator(c, chainID, processID, 3, 60)
+
+	prover := &testProver{err: fmt.Errorf("prover busy")}
+	va.SetProver(prover, testVerifier{}, types.ZKCircuitMeta{NMaxVotes: 8, NLevels: 4})
+
+	var err error
+	for i := 0; i < len(votes); i++ {
+		err = va.AddVote(processID, votes[i])
+		c.Assert(err, qt.IsNil)
+	}
+
+	err = va.db.UpdateProcessStatus(processID, types.ProcessStatusFrozen)
+	c.Assert(err, qt.IsNil)
+
+	// the process goes back to Frozen until maxProofAttempts is reached
+	for i := 1; i < maxProofAttempts; i++ {
+		err = va.syncProcesses()
+		c.Assert(err, qt.IsNil)
+		status, err := va.db.GetProcessStatus(processID)
+		c.Assert(err, qt.IsNil)
+		c.Assert(status, qt.Equals, types.ProcessStatusFrozen)
+
+		attempts, lastErr, err := va.db.ReadProofError(processID)
+		c.Assert(err, qt.IsNil)
+		c.Assert(attempts, qt.Equals, i)
+		c.Assert(lastErr, qt.Equals, "prover busy")
+	}
+
+	err = va.syncProcesses()
+	c.Assert(err, qt.IsNil)
+	status, err := va.db.GetProcessStatus(processID)
+	c.Assert(err, qt.IsNil)
+	c.Assert(status, qt.Equals, types.ProcessStatusProofFailed)
+}
+
+// storeFrozenProcess stores a frozen process with the census of the given
+// process and the given results publishing window
+func storeFrozenProcess(c *qt.C, va *VotesAggregator, censusProcessID, processID,
+	resPubStartBlock, resPubWindow uint64) {
+	p, err := va.db.ReadProcessByID(censusProcessID)
+	c.Assert(err, qt.IsNil)
+	err = va.db.StoreProcess(processID, p.CensusRoot, p.CensusSize, 10,
+		resPubStartBlock, resPubWindow, 20, 60, 1)
+	c.Assert(err, qt.IsNil)
+	err = va.db.UpdateProcessStatus(processID, types.ProcessStatusFrozen)
+	c.Assert(err, qt.IsNil)
+}
+
+// testChainHead implements the ChainHead interface for testing purposes
+type testChainHead struct {
+	blockNum uint64
+}
+
+func (h testChainHead) HeadBlockNum() uint64 {
+	return h.blockNum
+}
+
+func TestSyncProcessesDeadlineOrder(t *testing.T) {
+	c := qt.New(t)
+
+	// the results publishing window of process 123 ends at block 40
+	va, _ := baseTestVotesAggregator(c, 3, 123, 2, 60)
+	prover := &testProver{}
+	va.SetProver(prover, testVerifier{}, types.ZKCircuitMeta{NMaxVotes: 8, NLevels: 4})
+
+	storeFrozenProcess(c, va, 123, 2, 20, 30) // ends at block 50
+	err := va.db.UpdateProcessStatus(123, types.ProcessStatusFrozen)
+	c.Assert(err, qt.IsNil)
+	storeFrozenProcess(c, va, 123, 1, 20, 5) // ends at block 25
+
+	// the processes are proven by the end of their window, whatever
+	// their creation order
+	for i := 0; i < 4; i++ {
+		c.Assert(va.syncProcesses(), qt.IsNil)
+	}
+	c.Assert(len(prover.requests), qt.Equals, 3)
+	for i, processID := range []int64{1, 123, 2} {
+		c.Assert(prover.requests[i].ProcessID.Int64(), qt.Equals, processID)
+	}
+}
+
+func TestSyncProcessesExpired(t *testing.T) {
+	c := qt.New(t)
+
+	va, _ := baseTestVotesAggregator(c, 3, 123, 2, 60)
+	prover := &testProver{}
+	va.SetProver(prover, testVerifier{}, types.ZKCircuitMeta{NMaxVotes: 8, NLevels: 4})
+	err := va.db.UpdateProcessStatus(123, types.ProcessStatusFrozen)
+	c.Assert(err, qt.IsNil)
+	storeFrozenProcess(c, va, 123, 1, 20, 5)  // ends at block 25
+	storeFrozenProcess(c, va, 123, 2, 20, 12) // ends at block 32
+
+	// the proof of a circuit of 8 votes is estimated in 8s, 1 block of
+	// 10s, plus the blocks to publish the result
+	va.SetChainHead(testChainHead{blockNum: 30}, 10*time.Second)
+	c.Assert(va.estimateProofBlocks(types.ZKCircuitMeta{NMaxVotes: 8, NLevels: 4}),
+		qt.Equals, uint64(1+publishBlocks))
+
+	c.Assert(va.syncProcesses(), qt.IsNil)
+	c.Assert(len(prover.requests), qt.Equals, 1)
+	c.Assert(prover.requests[0].ProcessID.Int64(), qt.Equals, int64(123))
+	for processID, expected := range map[uint64]types.ProcessStatus{
+		1:   types.ProcessStatusProofExpired,
+		2:   types.ProcessStatusProofExpired,
+		123: types.ProcessStatusProofGenerated,
+	} {
+		status, err := va.db.GetProcessStatus(processID)
+		c.Assert(err, qt.IsNil)
+		c.Assert(status, qt.Equals, expected)
+	}
+}
+
+func TestSyncProcessesConcurrent(t *testing.T) {
+	c := qt.New(t)
+
+	va, _ := baseTestVotesAggregator(c, 3, 123, 2, 60)
+	err := va.db.UpdateProcessStatus(123, types.ProcessStatusFrozen)
+	c.Assert(err, qt.IsNil)
+	storeFrozenProcess(c, va, 123, 1, 20, 5)
+
+	// the first prover blocks until the second one has generated its
+	// proof
+	started := make(chan struct{})
+	release := make(chan struct{})
+	prover1 := &testProver{onWait: func() {
+		close(started)
+		<-release
+	}}
+	prover2 := &testProver{}
+	meta := types.ZKCircuitMeta{NMaxVotes: 8, NLevels: 4}
+	va.AddProver(prover1, testVerifier{}, meta)
+	va.AddProver(prover2, testVerifier{}, meta)
+
+	done := make(chan error)
+	go func() {
+		_, err := va.proveNext(va.pool.slots()[0])
+		done <- err
+	}()
+	<-started
+
+	// the process being proven by the first prover is not scheduled
+	// again
+	proved, err := va.proveNext(va.pool.slots()[1])
+	c.Assert(err, qt.IsNil)
+	c.Assert(proved, qt.IsTrue)
+	proved, err = va.proveNext(va.pool.slots()[1])
+	c.Assert(err, qt.IsNil)
+	c.Assert(proved, qt.IsFalse)
+	close(release)
+	c.Assert(<-done, qt.IsNil)
+
+	c.Assert(prover1.requests[0].ProcessID.Int64(), qt.Equals, int64(1))
+	c.Assert(len(prover2.requests), qt.Equals, 1)
+	c.Assert(prover2.requests[0].ProcessID.Int64(), qt.Equals, int64(123))
+	for _, processID := range []uint64{1, 123} {
+		status, err := va.db.GetProcessStatus(processID)
+		c.Assert(err, qt.IsNil)
+		c.Assert(status, qt.Equals, types.ProcessStatusProofGenerated)
+	}
+}
+
+func TestProofTimes(t *testing.T) {
+	c := qt.New(t)
+
+	pt := newProofTimes()
+	meta := types.ZKCircuitMeta{NMaxVotes: 8, NLevels: 4}
+	c.Assert(pt.estimate(meta), qt.Equals, 8*time.Second)
+
+	pt.add(meta, 4*time.Second)
+	c.Assert(pt.estimate(meta), qt.Equals, 4*time.Second)
+	pt.add(meta, 8*time.Second)
+	c.Assert(pt.estimate(meta), qt.Equals, 5*time.Second)
+
+	// the estimations are kept by circuit size
+	c.Assert(pt.estimate(types.ZKCircuitMeta{NMaxVotes: 16, NLevels: 4}),
+		qt.Equals, 16*time.Second)
+}
@@ -0,0 +1,44 @@
+;; Minimal module implementing the interface of the circuit.wasm generated by
+;; circom 2, used to test the witnesscalc package. The field is the prime
+;; 0xfffffffb (so each element uses one 32 bit word), the circuit has one input
+;; signal "in" of size 2, and the witness is [1, in[0], in[1], in[0]+in[1]].
+;; Setting an input to 0 triggers an "Assert Failed" exception.
+(module
+  (import "runtime" "exceptionHandler" (func $exceptionHandler (param i32)))
+  (import "runtime" "printErrorMessage" (func $printErrorMessage))
+  (import "runtime" "writeBufferMessage" (func $writeBufferMessage))
+  (import "runtime" "showSharedRWMemory" (func $showSharedRWMemory))
+  (memory 1)
+  (global $shared (mut i32) (i32.const 0))
+  (func (export "getVersion") (result i32) i32.const 2)
+  (func (export "getFieldNumLen32") (result i32) i32.const 1)
+  (func (export "getRawPrime") i32.const 0xfffffffb global.set $shared)
+  (func (export "readSharedRWMemory") (param i32) (result i32) global.get $shared)
+  (func (export "writeSharedRWMemory") (param i32 i32) local.get 1 global.set $shared)
+  (func (export "init") (param i32) i32.const 0 i32.const 1 i32.store)
+  ;; fnv1a64("in") = 0x08b73807b55c4bbe
+  (func (export "getInputSignalSize") (param i32 i32) (result i32)
+    local.get 0 i32.const 0x08b73807 i32.eq
+    local.get 1 i32.const 0xb55c4bbe i32.eq
+    i32.and
+    if (result i32) i32.const 2 else i32.const -1 end)
+  (func (export "setInputSignal") (param i32 i32 i32)
+    global.get $shared i32.eqz
+    if i32.const 4 call $exceptionHandler unreachable end
+    local.get 2 i32.const 1 i32.add i32.const 4 i32.mul
+    global.get $shared
+    i32.store)
+  (func (export "getWitnessSize") (result i32) i32.const 4)
+  (func (export "getWitness") (param i32)
+    local.get 0 i32.const 3 i32.eq
+    if
+      i32.const 4 i32.load i64.extend_i32_u
+      i32.const 8 i32.load i64.extend_i32_u
+      i64.add
+      i64.const 0xfffffffb
+      i64.rem_u
+      i32.wrap_i64
+      global.set $shared
+    else
+      local.get 0 i32.const 4 i32.mul i32.load global.set $shared
+    end))
@@ -0,0 +1,440 @@
+package witnesscalc
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"go.vocdoni.io/dvote/log"
+)
+
+// exportedFuncs are the functions that the circuit.wasm generated by circom
+// must export to compute the witness
+var exportedFuncs = []string{
+	"getFieldNumLen32",
+	"getRawPrime",
+	"readSharedRWMemory",
+	"writeSharedRWMemory",
+	"init",
+	"getInputSignalSize",
+	"setInputSignal",
+	"getWitnessSize",
+	"getWitness",
+}
+
+// exceptionMsgs are the messages of the exception codes of the circom wasm
+// runtime
+var exceptionMsgs = map[uint32]string{
+	1: "Signal not found",
+	2: "Too many signals set",
+	3: "Signal already set",
+	4: "Assert Failed",
+	5: "Not enough memory",
+	6: "Input signal array access exceeds the size",
+}
+
+// Calculator computes the witness of a circom circuit, running the
+// circuit.wasm generated by circom in an embedded WebAssembly runtime
+type Calculator struct {
+	runtime  wazero.Runtime
+	compiled wazero.CompiledModule
+}
+
+// Witness contains the witness of a circuit, together with the prime of the
+// circuit field
+type Witness struct {
+	Prime *big.Int
+	// N32 is the number of 32 bit words used by each field element
+	N32    int
+	Values []*big.Int
+}
+
+// instanceState contains the messages written by a circuit instance through
+// the runtime imports, and the exception raised by it if any
+type instanceState struct {
+	errMsg    strings.Builder
+	msg       strings.Builder
+	exception error
+}
+
+type instanceStateKey struct{}
+
+func getInstanceState(ctx context.Context) *instanceState {
+	if s, ok := ctx.Value(instanceStateKey{}).(*instanceState); ok {
+		return s
+	}
+	return &instanceState{}
+}
+
+// New compiles the given circuit.wasm and returns a new Calculator for it
+func New(wasm []byte) (*Calculator, error) {
+	ctx := context.Background()
+	r := wazero.NewRuntime(ctx)
+	c := &Calculator{runtime: r}
+
+	_, err := r.NewHostModuleBuilder("runtime").
+		NewFunctionBuilder().WithFunc(exceptionHandler).Export("exceptionHandler").
+		NewFunctionBuilder().WithFunc(printErrorMessage).Export("printErrorMessage").
+		NewFunctionBuilder().WithFunc(writeBufferMessage).Export("writeBufferMessage").
+		NewFunctionBuilder().WithFunc(showSharedRWMemory).Export("showSharedRWMemory").
+		Instantiate(ctx)
+	if err != nil {
+		_ = c.Close()
+		return nil, err
+	}
+
+	c.compiled, err = r.CompileModule(ctx, wasm)
+	if err != nil {
+		_ = c.Close()
+		return nil, fmt.Errorf("can not compile circuit wasm: %s", err)
+	}
+	exported := c.compiled.ExportedFunctions()
+	for _, name := range exportedFuncs {
+		if _, ok := exported[name]; !ok {
+			_ = c.Close()
+			return nil, fmt.Errorf("circuit wasm does not export %s", name)
+		}
+	}
+	return c, nil
+}
+
+// Close releases the resources of the Calculator
+func (c *Calculator) Close() error {
+	return c.runtime.Close(context.Background())
+}
+
+// CalculateWitness computes the witness of the circuit for the given inputs.
+// The inputs map the signal names to values or (nested) arrays of values, as
+// returned by ParseInputs.
+func (c *Calculator) CalculateWitness(inputs map[string]interface{}) (*Witness, error) {
+	ctx := context.WithValue(context.Background(), instanceStateKey{}, &instanceState{})
+	mod, err := c.runtime.InstantiateModule(ctx, c.compiled,
+		wazero.NewModuleConfig().WithName(""))
+	if err != nil {
+		return nil, err
+	}
+	defer mod.Close(ctx) //nolint:errcheck
+	call := newCaller(ctx, mod)
+
+	n32, err := call("getFieldNumLen32")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := call("getRawPrime"); err != nil {
+		return nil, err
+	}
+	prime, err := readShared(call, n32)
+	if err != nil {
+		return nil, err
+	}
+
+	// init(1) enables the sanity checks of the circuit
+	if _, err := call("init", 1); err != nil {
+		return nil, err
+	}
+
+	// the inputs are set in a deterministic order, so the errors are
+	// reproducible
+	names := make([]string, 0, len(inputs))
+	for name := range inputs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		values, err := flattenInput(inputs[name])
+		if err != nil {
+			return nil, fmt.Errorf("input %s: %s", name, err)
+		}
+		hMSB, hLSB := fnvHash(name)
+		size, err := call("getInputSignalSize", uint64(hMSB), uint64(hLSB))
+		if err != nil {
+			return nil, err
+		}
+		if int32(size) < 0 {
+			return nil, fmt.Errorf("signal %s not found", name)
+		}
+		if len(values) != int(size) {
+			return nil, fmt.Errorf("signal %s expects %d values, %d given",
+				name, size, len(values))
+		}
+		for i, v := range values {
+			if err := writeShared(call, n32, normalize(v, prime)); err != nil {
+				return nil, err
+			}
+			_, err := call("setInputSignal", uint64(hMSB), uint64(hLSB), uint64(i))
+			if err != nil {
+				return nil, fmt.Errorf("signal %s[%d]: %s", name, i, err)
+			}
+		}
+	}
+
+	witnessSize, err := call("getWitnessSize")
+	if err != nil {
+		return nil, err
+	}
+	values := make([]*big.Int, witnessSize)
+	for i := range values {
+		if _, err := call("getWitness", uint64(i)); err != nil {
+			return nil, err
+		}
+		if values[i], err = readShared(call, n32); err != nil {
+			return nil, err
+		}
+	}
+	return &Witness{Prime: prime, N32: int(n32), Values: values}, nil
+}
+
+// CalculateWTNS computes the witness of the circuit for the given inputs, and
+// returns it encoded in the binary .wtns format used by snarkjs & rapidsnark
+func (c *Calculator) CalculateWTNS(inputs map[string]interface{}) ([]byte, error) {
+	w, err := c.CalculateWitness(inputs)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := w.WriteWTNS(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// WriteWTNS writes the Witness in the binary .wtns format (version 2), which
+// contains a header section with the field prime and the number of witness
+// values, followed by a section with the values. All the numbers are encoded
+// in little-endian.
+func (w *Witness) WriteWTNS(out io.Writer) error {
+	n8 := w.N32 * 4 //nolint:gomnd
+	var buf bytes.Buffer
+	buf.WriteString("wtns")
+	writeUint32(&buf, 2) // version
+	writeUint32(&buf, 2) // number of sections
+
+	// header section
+	writeUint32(&buf, 1)
+	writeUint64(&buf, uint64(n8+4+4)) //nolint:gomnd
+	writeUint32(&buf, uint32(n8))
+	if err := writeElement(&buf, w.Prime, n8); err != nil {
+		return err
+	}
+	writeUint32(&buf, uint32(len(w.Values)))
+
+	// witness section
+	writeUint32(&buf, 2) //nolint:gomnd
+	writeUint64(&buf, uint64(len(w.Values)*n8))
+	for i, v := range w.Values {
+		if err := writeElement(&buf, v, n8); err != nil {
+			return fmt.Errorf("witness value %d: %s", i, err)
+		}
+	}
+	_, err := out.Write(buf.Bytes())
+	return err
+}
+
+// ParseInputs parses the given json inputs of a circuit, keeping the numbers
+// as json.Number so that they don't lose precision
+func ParseInputs(b []byte) (map[string]interface{}, error) {
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+	var inputs map[string]interface{}
+	if err := d.Decode(&inputs); err != nil {
+		return nil, err
+	}
+	return inputs, nil
+}
+
+// flattenInput returns the values of the given input in row-major order. The
+// input can be a number (json.Number, string or *big.Int), or an array of
+// inputs.
+func flattenInput(in interface{}) ([]*big.Int, error) {
+	switch v := in.(type) {
+	case []interface{}:
+		var values []*big.Int
+		for _, e := range v {
+			ev, err := flattenInput(e)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, ev...)
+		}
+		return values, nil
+	case []*big.Int:
+		return v, nil
+	case *big.Int:
+		return []*big.Int{v}, nil
+	case json.Number:
+		return parseNumber(string(v))
+	case string:
+		return parseNumber(v)
+	}
+	return nil, fmt.Errorf("unsupported value type %T", in)
+}
+
+func parseNumber(s string) ([]*big.Int, error) {
+	n, ok := new(big.Int).SetString(s, 0)
+	if !ok {
+		return nil, fmt.Errorf("can not parse number %q", s)
+	}
+	return []*big.Int{n}, nil
+}
+
+// normalize returns the given value reduced into the field of the given prime
+func normalize(v, prime *big.Int) *big.Int {
+	return new(big.Int).Mod(v, prime)
+}
+
+// fnvHash returns the most and least significant 32 bits of the 64 bit
+// FNV-1a hash of the given signal name, which is used by circom to identify
+// the signals
+func fnvHash(s string) (uint32, uint32) {
+	h := uint64(0xcbf29ce484222325)
+	for _, c := range []byte(s) {
+		h ^= uint64(c)
+		h *= 0x100000001b3
+	}
+	return uint32(h >> 32), uint32(h) //nolint:gomnd
+}
+
+// caller calls the given exported function of a circuit instance, returning
+// its i32 result if any
+type caller func(name string, params ...uint64) (uint32, error)
+
+func newCaller(ctx context.Context, m api.Module) caller {
+	return func(name string, params ...uint64) (uint32, error) {
+		res, err := m.ExportedFunction(name).Call(ctx, params...)
+		if err != nil {
+			// return the circuit exception without the wasm stack trace
+			if exception := getInstanceState(ctx).exception; exception != nil {
+				return 0, exception
+			}
+			return 0, err
+		}
+		if len(res) == 0 {
+			return 0, nil
+		}
+		return uint32(res[0]), nil
+	}
+}
+
+// readShared reads a field element from the shared memory of the circuit,
+// where the word 0 is the least significant one
+func readShared(call caller, n32 uint32) (*big.Int, error) {
+	b := make([]byte, n32*4) //nolint:gomnd
+	for j := uint32(0); j < n32; j++ {
+		w, err := call("readSharedRWMemory", uint64(j))
+		if err != nil {
+			return nil, err
+		}
+		binary.BigEndian.PutUint32(b[(n32-1-j)*4:], w) //nolint:gomnd
+	}
+	return new(big.Int).SetBytes(b), nil
+}
+
+// writeShared writes the given field element into the shared memory of the
+// circuit
+func writeShared(call caller, n32 uint32, v *big.Int) error {
+	b := make([]byte, n32*4) //nolint:gomnd
+	v.FillBytes(b)
+	for j := uint32(0); j < n32; j++ {
+		w := binary.BigEndian.Uint32(b[(n32-1-j)*4:]) //nolint:gomnd
+		if _, err := call("writeSharedRWMemory", uint64(j), uint64(w)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeUint32(buf *bytes.Buffer, v uint32) {
+	var b [4]byte
+	binary.LittleEndian.PutUint32(b[:], v)
+	buf.Write(b[:])
+}
+
+func writeUint64(buf *bytes.Buffer, v uint64) {
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], v)
+	buf.Write(b[:])
+}
+
+// writeElement writes the given field element in n8 bytes little-endian
+func writeElement(buf *bytes.Buffer, v *big.Int, n8 int) error {
+	if v.Sign() < 0 || v.BitLen() > n8*8 { //nolint:gomnd
+		return fmt.Errorf("value %s does not fit in %d bytes", v, n8)
+	}
+	b := make([]byte, n8)
+	v.FillBytes(b)
+	for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+		b[i], b[j] = b[j], b[i]
+	}
+	buf.Write(b)
+	return nil
+}
+
+// getMessage reads the message that the circuit exposes through the
+// getMessageChar export, if any
+func getMessage(ctx context.Context, m api.Module) string {
+	f := m.ExportedFunction("getMessageChar")
+	if f == nil {
+		return ""
+	}
+	var msg strings.Builder
+	for {
+		res, err := f.Call(ctx)
+		if err != nil || len(res) == 0 || uint32(res[0]) == 0 {
+			return msg.String()
+		}
+		msg.WriteByte(byte(res[0]))
+	}
+}
+
+// exceptionHandler is called by the circuit when the witness can not be
+// computed. The panic aborts the execution of the circuit, and is returned as
+// an error by the function call that caused it.
+func exceptionHandler(ctx context.Context, code uint32) {
+	msg, ok := exceptionMsgs[code]
+	if !ok {
+		msg = "Unknown error"
+	}
+	state := getInstanceState(ctx)
+	if errMsg := state.errMsg.String(); errMsg != "" {
+		msg += ": " + strings.TrimSpace(errMsg)
+	}
+	state.exception = fmt.Errorf("circuit exception (%d): %s", code, msg)
+	panic(state.exception)
+}
+
+func printErrorMessage(ctx context.Context, m api.Module) {
+	state := getInstanceState(ctx)
+	state.errMsg.WriteString(getMessage(ctx, m) + "\n")
+}
+
+func writeBufferMessage(ctx context.Context, m api.Module) {
+	state := getInstanceState(ctx)
+	msg := getMessage(ctx, m)
+	if msg != "\n" {
+		state.msg.WriteString(msg)
+		return
+	}
+	log.Debugf("circuit: %s", state.msg.String())
+	state.msg.Reset()
+}
+
+func showSharedRWMemory(ctx context.Context, m api.Module) {
+	call := newCaller(ctx, m)
+	n32, err := call("getFieldNumLen32")
+	if err != nil {
+		return
+	}
+	v, err := readShared(call, n32)
+	if err != nil {
+		return
+	}
+	getInstanceState(ctx).msg.WriteString(v.String())
+}
@@ -0,0 +1,133 @@
+package witnesscalc
+
+import (
+	"bytes"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+)
+
+var wasmPath = flag.String("wasm", "", "path to a circom circuit.wasm to test")
+var inputsPath = flag.String("inputs", "", "path to the json inputs for the -wasm circuit")
+
+// testdata/circuit.wasm is compiled from testdata/circuit.wat
+func newTestCalculator(c *qt.C) *Calculator {
+	wasm, err := ioutil.ReadFile("testdata/circuit.wasm")
+	c.Assert(err, qt.IsNil)
+	calc, err := New(wasm)
+	c.Assert(err, qt.IsNil)
+	c.Cleanup(func() { c.Assert(calc.Close(), qt.IsNil) })
+	return calc
+}
+
+func TestFnvHash(t *testing.T) {
+	c := qt.New(t)
+
+	hMSB, hLSB := fnvHash("in")
+	c.Assert(hMSB, qt.Equals, uint32(0x08b73807))
+	c.Assert(hLSB, qt.Equals, uint32(0xb55c4bbe))
+}
+
+func TestFlattenInput(t *testing.T) {
+	c := qt.New(t)
+
+	inputs, err := ParseInputs([]byte(`{"a": "12", "b": [[1, 2], ["3", 4]],
+		"c": "21888242871839275222246405745257275088548364400416034343698204186575808495617"}`))
+	c.Assert(err, qt.IsNil)
+
+	values, err := flattenInput(inputs["a"])
+	c.Assert(err, qt.IsNil)
+	c.Assert(fmt.Sprint(values), qt.Equals, "[12]")
+
+	values, err = flattenInput(inputs["b"])
+	c.Assert(err, qt.IsNil)
+	c.Assert(fmt.Sprint(values), qt.Equals, "[1 2 3 4]")
+
+	// numbers bigger than 2^64 keep their precision
+	values, err = flattenInput(inputs["c"])
+	c.Assert(err, qt.IsNil)
+	c.Assert(values[0].String(), qt.Equals,
+		"21888242871839275222246405745257275088548364400416034343698204186575808495617")
+
+	_, err = flattenInput(map[string]interface{}{})
+	c.Assert(err, qt.ErrorMatches, "unsupported value type .*")
+	_, err = flattenInput("0xzz")
+	c.Assert(err, qt.ErrorMatches, "can not parse number .*")
+}
+
+func TestCalculateWitness(t *testing.T) {
+	c := qt.New(t)
+	calc := newTestCalculator(c)
+
+	inputs, err := ParseInputs([]byte(`{"in": ["4294967290", -1]}`))
+	c.Assert(err, qt.IsNil)
+	w, err := calc.CalculateWitness(inputs)
+	c.Assert(err, qt.IsNil)
+	c.Assert(w.Prime.String(), qt.Equals, "4294967291")
+	c.Assert(w.N32, qt.Equals, 1)
+	// negative values are reduced into the field
+	c.Assert(fmt.Sprint(w.Values), qt.Equals, "[1 4294967290 4294967290 4294967289]")
+
+	wtns, err := calc.CalculateWTNS(inputs)
+	c.Assert(err, qt.IsNil)
+	c.Assert(hex.EncodeToString(wtns), qt.Equals,
+		hex.EncodeToString([]byte("wtns"))+
+			"02000000"+"02000000"+
+			"01000000"+"0c00000000000000"+"04000000"+"fbffffff"+"04000000"+
+			"02000000"+"1000000000000000"+
+			"01000000"+"faffffff"+"faffffff"+"f9ffffff")
+
+	// the calculator can be reused
+	inputs, err = ParseInputs([]byte(`{"in": [1, 2]}`))
+	c.Assert(err, qt.IsNil)
+	w, err = calc.CalculateWitness(inputs)
+	c.Assert(err, qt.IsNil)
+	c.Assert(w.Values[3].String(), qt.Equals, "3")
+
+	// errors
+	_, err = calc.CalculateWitness(map[string]interface{}{"x": "1"})
+	c.Assert(err, qt.ErrorMatches, "signal x not found")
+	_, err = calc.CalculateWitness(map[string]interface{}{"in": "1"})
+	c.Assert(err, qt.ErrorMatches, "signal in expects 2 values, 1 given")
+	_, err = calc.CalculateWitness(map[string]interface{}{"in": []interface{}{"1", "0"}})
+	c.Assert(err, qt.ErrorMatches, `signal in\[1\]: circuit exception \(4\): Assert Failed`)
+}
+
+func TestNewInvalidWasm(t *testing.T) {
+	c := qt.New(t)
+
+	_, err := New([]byte("not wasm"))
+	c.Assert(err, qt.ErrorMatches, "can not compile circuit wasm: .*")
+
+	// empty module, without the circom exports
+	_, err = New([]byte("\x00asm\x01\x00\x00\x00"))
+	c.Assert(err, qt.ErrorMatches, "circuit wasm does not export getFieldNumLen32")
+}
+
+// TestCircuitWasm computes the witness of a circuit compiled with circom,
+// usage: go test ./witnesscalc -run TestCircuitWasm -wasm=circuit.wasm
+// -inputs=inputs.json
+func TestCircuitWasm(t *testing.T) {
+	if *wasmPath == "" || *inputsPath == "" {
+		t.Skip()
+	}
+	c := qt.New(t)
+
+	wasm, err := ioutil.ReadFile(*wasmPath)
+	c.Assert(err, qt.IsNil)
+	calc, err := New(wasm)
+	c.Assert(err, qt.IsNil)
+	defer calc.Close() //nolint:errcheck
+
+	b, err := ioutil.ReadFile(*inputsPath)
+	c.Assert(err, qt.IsNil)
+	inputs, err := ParseInputs(b)
+	c.Assert(err, qt.IsNil)
+	wtns, err := calc.CalculateWTNS(inputs)
+	c.Assert(err, qt.IsNil)
+	c.Assert(bytes.HasPrefix(wtns, []byte("wtns")), qt.IsTrue)
+}